@@ -0,0 +1,9 @@
+package main
+
+import "fmt"
+
+// formatRealtimeFactor formats a realtime factor for --benchmark: a bare
+// number with two decimals, easy to chart across versions.
+func formatRealtimeFactor(factor float64) string {
+	return fmt.Sprintf("%.2f", factor)
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+
+	"github.com/linuxmatters/jivedrop/internal/app"
+)
+
+// Exit codes are a contract with scripts that drive jivedrop, so keep them
+// stable and keep the README table in step.
+const (
+	exitOK            = 0
+	exitUsage         = 2 // invalid flags, arguments, or input values
+	exitInputNotFound = 3 // an input file (audio, markdown, cover, chapters) is missing
+	exitEncode        = 4 // encoding, including cover art processing, failed or was cancelled
+	exitIO            = 5 // writing an output failed or would: output path, disk space, frontmatter, RSS item, log file
+)
+
+// exitCodeFor returns exitInputNotFound when err is a missing file, exitIO
+// when the output disk is too full, and fallback otherwise.
+func exitCodeFor(err error, fallback int) int {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return exitInputNotFound
+	case errors.Is(err, app.ErrInsufficientSpace):
+		return exitIO
+	}
+	return fallback
+}
+
+// exitCodeForRun returns the exit code for an error from app.Run, from the
+// kind the app gave it.
+func exitCodeForRun(err error) int {
+	var runErr *app.Error
+	if !errors.As(err, &runErr) {
+		return exitCodeFor(err, exitEncode)
+	}
+	switch runErr.Kind {
+	case app.KindUsage:
+		return exitUsage
+	case app.KindInputNotFound:
+		return exitInputNotFound
+	case app.KindIO:
+		return exitIO
+	default:
+		return exitEncode
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/linuxmatters/jivedrop/internal/app"
+)
+
+func TestExitCodeFor(t *testing.T) {
+	_, statErr := os.Stat("/nonexistent/episode.md")
+
+	tests := []struct {
+		name     string
+		err      error
+		fallback int
+		want     int
+	}{
+		{name: "missing file", err: statErr, fallback: exitUsage, want: exitInputNotFound},
+		{name: "wrapped missing file", err: fmt.Errorf("cover art not accessible: %w", statErr), fallback: exitEncode, want: exitInputNotFound},
+		{name: "disk full", err: fmt.Errorf("%w: the output needs about 1.0 GiB", app.ErrInsufficientSpace), fallback: exitEncode, want: exitIO},
+		{name: "other error", err: errors.New("standalone mode requires --num flag"), fallback: exitUsage, want: exitUsage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exitCodeFor(tt.err, tt.fallback); got != tt.want {
+				t.Errorf("exitCodeFor() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExitCodeForRun(t *testing.T) {
+	_, statErr := os.Stat("/nonexistent/episode.md")
+
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "usage", err: &app.Error{Kind: app.KindUsage, Err: errors.New("bad flag")}, want: exitUsage},
+		{name: "input not found", err: &app.Error{Kind: app.KindInputNotFound, Err: statErr}, want: exitInputNotFound},
+		{name: "encode", err: &app.Error{Kind: app.KindEncode, Err: errors.New("encoding failed")}, want: exitEncode},
+		{name: "io", err: &app.Error{Kind: app.KindIO, Err: errors.New("read-only")}, want: exitIO},
+		// The kind wins over the wrapped error: a missing output directory is
+		// an output problem, not a missing input.
+		{name: "kind wins", err: &app.Error{Kind: app.KindIO, Err: statErr}, want: exitIO},
+		{name: "plain error", err: errors.New("boom"), want: exitEncode},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exitCodeForRun(tt.err); got != tt.want {
+				t.Errorf("exitCodeForRun() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
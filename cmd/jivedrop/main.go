@@ -3,15 +3,15 @@ package main
 import (
 	"fmt"
 	"os"
-	"path/filepath"
 	"strings"
+	"time"
 
 	tea "charm.land/bubbletea/v2"
 	"github.com/alecthomas/kong"
 	"github.com/charmbracelet/x/term"
+	"github.com/linuxmatters/jivedrop/internal/app"
 	"github.com/linuxmatters/jivedrop/internal/cli"
 	"github.com/linuxmatters/jivedrop/internal/encoder"
-	"github.com/linuxmatters/jivedrop/internal/id3"
 	"github.com/linuxmatters/jivedrop/internal/ui"
 )
 
@@ -19,186 +19,163 @@ import (
 // tag (e.g. "v0.1.0") for releases.
 var version = "dev"
 
-// coverArtResult carries the outcome of concurrent cover art processing back
-// to the encode pipeline.
-type coverArtResult struct {
-	data []byte
-	err  error
+// debugLogName is the FFmpeg trace file written to the current directory by
+// --debug. The previous run's log is kept as jivedrop-debug.log.1.
+const debugLogName = "jivedrop-debug.log"
+
+// envPrefix names the environment variable behind each flag: --id3-version
+// is JIVEDROP_ID3_VERSION. A flag on the command line wins over the saved
+// --reuse settings, which win over the environment, which wins over the
+// built-in default.
+const envPrefix = "JIVEDROP"
+
+// flagEnvars names the environment variable behind each flag from its long
+// name, upper-cased with hyphens as underscores after the prefix. Unlike
+// kong.DefaultEnvars it does not split names at digits, which would make
+// --id3-version JIVEDROP_ID_3_VERSION. A flag tagged env:"-" has none.
+func flagEnvars(prefix string) kong.Option {
+	return kong.PostBuild(func(k *kong.Kong) error {
+		for _, flag := range k.Model.Flags {
+			if flag.Name == "help" {
+				continue
+			}
+			if len(flag.Envs) == 1 && flag.Envs[0] == "-" {
+				flag.Envs = nil
+				continue
+			}
+			if len(flag.Envs) > 0 {
+				continue
+			}
+			name := prefix + "_" + strings.ToUpper(strings.ReplaceAll(flag.Name, "-", "_"))
+			flag.Envs = []string{name}
+			flag.Value.Tag.Envs = []string{name}
+		}
+		return nil
+	})
 }
 
-// WorkflowMode selects how metadata is sourced: from Hugo frontmatter or from
-// CLI flags alone.
-type WorkflowMode int
-
-const (
-	// HugoMode reads metadata from an episode markdown file's frontmatter.
-	HugoMode WorkflowMode = iota
-	// StandaloneMode takes all metadata from CLI flags.
-	StandaloneMode
-)
-
 var CLI struct {
-	AudioFile string `arg:"" name:"audio-file" help:"Path to audio file (WAV, FLAC)" optional:""`
+	AudioFile string `arg:"" name:"audio-file" help:"Path to audio file (WAV, FLAC, AIFF, or M4A/MP4)" optional:""`
 	EpisodeMD string `arg:"" name:"episode-md" help:"Path to episode markdown file (Hugo mode)" optional:""`
 
+	// Explicit mode selection, in place of guessing from the second argument
+	MarkdownFile string `name:"episode-md" help:"Path to episode markdown file, in place of the second argument; selects Hugo mode" placeholder:"PATH"`
+	Hugo         bool   `help:"Require Hugo mode: fail unless an episode markdown file is given" xor:"mode"`
+	Standalone   bool   `help:"Use standalone mode, ignoring a second argument that ends in .md" xor:"mode"`
+
 	// Metadata flags (standalone mode or Hugo overrides)
-	Num        string `help:"Episode number"`
-	Title      string `help:"Episode title"`
-	Artist     string `help:"Artist name (defaults to 'Linux Matters' in Hugo mode)"`
-	Album      string `help:"Album name (defaults to artist value if omitted)"`
-	Date       string `help:"Release date (YYYY-MM-DD format)"`
-	Comment    string `help:"Comment URL (defaults to 'https://linuxmatters.sh' in Hugo mode)"`
-	Cover      string `help:"Cover art path"`
-	OutputPath string `help:"Output file or directory path"`
+	Num              string   `help:"Episode number"`
+	Title            string   `help:"Episode title"`
+	Artist           string   `help:"Artist name (defaults to 'Linux Matters' in Hugo mode)"`
+	Album            string   `help:"Album name (defaults to artist value if omitted)"`
+	AlbumArtist      string   `help:"Album artist (TPE2) that players group the show's episodes by (defaults to the artist in Hugo mode, otherwise none)" placeholder:"NAME"`
+	Date             string   `help:"Release date: YYYY, YYYY-MM, YYYY-MM-DD, or RFC3339 (defaults to today in standalone mode)"`
+	FullDate         bool     `help:"Keep the full release date (YYYY-MM-DD, plus time of day when known) instead of YYYY-MM"`
+	Comment          []string `help:"Comment URL or text, repeatable for several comments (defaults to 'https://linuxmatters.sh' in Hugo mode)" sep:"none"`
+	CommentLang      string   `help:"ISO 639-2 language code of the MP3 comment frames, e.g. deu or fra" default:"eng" placeholder:"CODE"`
+	Cover            string   `help:"Cover art path, or an http(s) URL to download it from"`
+	NoCover          bool     `help:"Embed no artwork at all, ignoring --cover, --back-cover and the frontmatter episode_image"`
+	CoverDescription string   `help:"Description stored with the embedded front cover, e.g. 'Episode 67 artwork' (default: none)"`
+	BackCover        string   `help:"Back cover image path or http(s) URL, embedded after the front cover" placeholder:"PATH"`
+	CoverOut         string   `help:"Also write the scaled cover art that gets embedded to this PNG path" placeholder:"PATH"`
+	MaxCoverBytes    int      `help:"Shrink the embedded cover art, down to 1400x1400 at the smallest, until it fits in this many bytes"`
+	CoverScaler      string   `help:"Cover art resampling: bilinear (fast) or catmullrom (sharper on large downscales)" enum:"bilinear,catmullrom" default:"bilinear"`
+	OutputPath       string   `help:"Output file or directory path"`
+	OutputDir        string   `help:"Directory to write the output to under its generated filename; the path is always taken as a directory" placeholder:"DIR"`
+	FilenameTemplate string   `help:"Output filename template using {num}, {title}, {artist}, {date}, {slug} and {prefix}, e.g. '{date}-{slug}-ep{num}'; the extension follows --format"`
+	Mkdir            bool     `help:"Create the output directory if it does not exist"`
+	PreserveMtime    bool     `help:"Give the output file the input file's modification time, so an archive sorts by recording date"`
+	Chapters         string   `help:"Chapter list file, one '[HH:]MM:SS Title' line per chapter"`
+	ChaptersJSON     bool     `name:"chapters-json" help:"Also write the chapters as a JSON chapters file (podcast namespace, read by Apple Podcasts) to NAME.chapters.json beside the output"`
+	Transcript       string   `help:"Timed transcript to embed in the MP3 as a SYLT frame, so apps can follow along: '[MM:SS] text' lines, SRT or WebVTT" placeholder:"FILE"`
+	Cue              string   `help:"CUE sheet exported by a DAW, read for chapters: one per TRACK, at its INDEX 01 and named by its TITLE" placeholder:"FILE"`
+	Explicit         bool     `help:"Mark the episode explicit with the iTunes advisory tag (Hugo mode also reads 'explicit' from frontmatter)"`
+
+	// Metadata file for standalone mode, in place of the metadata flags
+	Meta string `help:"Read episode, title, artist, album, date, comment, cover and explicit from a YAML or JSON file; flags given still override it" placeholder:"PATH"`
+
+	// Chapter marks detected at pauses, in place of --chapters
+	AutoChapters     bool          `help:"Add a chapter wherever the audio resumes after a pause, and print the list for refining into a --chapters file"`
+	SilenceThreshold float64       `help:"Level below which --auto-chapters counts audio as a pause, in dB" default:"-50" placeholder:"DB"`
+	SilenceGap       time.Duration `help:"Shortest pause --auto-chapters splits at" default:"2s" placeholder:"DURATION"`
+
+	// Chapter marks made from the transcript cues, in place of --chapters
+	TranscriptChapters time.Duration `help:"Also make chapters from the --transcript, each at least DURATION long, e.g. 5m, starting at a pause between cues and titled with its opening words" placeholder:"DURATION"`
+
+	// Parts for hosts that cap the file length
+	SplitDuration time.Duration `help:"Also write the MP3 in parts of at most this length, e.g. 60m, as NAME-part1.mp3, NAME-part2.mp3 and so on" placeholder:"DURATION"`
+
+	// Prompting for missing standalone metadata
+	Interactive bool `help:"Ask for the title, episode number and cover art when standalone mode is missing them (only on a terminal)"`
+
+	// Short clip for auditioning settings
+	Preview time.Duration `help:"Encode only the first DURATION of the audio, e.g. 30s, to NAME-preview beside the usual output, without updating the frontmatter" placeholder:"DURATION"`
+
+	// Frontmatter write-back (Hugo mode)
+	Yes      bool `short:"y" help:"Update podcast_duration and podcast_bytes in the frontmatter without asking" xor:"update"`
+	NoUpdate bool `help:"Never update the frontmatter; only print the podcast stats" xor:"update"`
+	Diff     bool `help:"Print the frontmatter update as a unified diff instead of writing it" xor:"update"`
+
+	// RSS snippet
+	RSS     string `name:"rss" help:"Write a ready-to-paste RSS <item> for the episode to FILE, or '-' to print it; needs --base-url" placeholder:"FILE"`
+	BaseURL string `name:"base-url" help:"URL the episode file is published under, for the RSS enclosure link"`
+
+	// Title tag presentation
+	TitleFormat    string `help:"Title tag template using {num} and {title} placeholders (default: '{num}: {title}')"`
+	NoNumberPrefix bool   `help:"Write the bare episode title to the title tag, without the number prefix"`
+
+	// Remembered settings, kept per directory in .jivedrop-last.json
+	Remember bool `help:"After a successful run, save the artist, album, comments, format, channels and title format to .jivedrop-last.json in this directory"`
+	Reuse    bool `help:"Use the settings saved by --remember in this directory as defaults; flags given still override them" env:"-"`
+
+	// Re-tagging: skip the encoder and copy the audio of an MP3 input
+	MetadataOnly bool `name:"metadata-only" aliases:"retag" help:"Re-tag an existing MP3 without re-encoding: the audio is copied as-is and only the tags, cover art and chapters are rewritten"`
+
+	// Peak limiting at the end of the filter chain
+	Limit        bool    `help:"Run a peak limiter before encoding so no peak exceeds the ceiling"`
+	LimitCeiling float64 `name:"limit-ceiling" help:"Peak ceiling for --limit, in dB from -24 to 0" default:"-1" placeholder:"DB"`
 
 	// Encoding options
-	Format  string `help:"Output format: mp3, aac, or opus" enum:"mp3,opus,aac" default:"mp3"`
-	Stereo  bool   `help:"Encode as stereo at the format's stereo bitrate (default: mono)"`
-	Version bool   `help:"Show version information"`
+	AudioTrack     int    `help:"Audio track to encode from a multi-track input, numbered from 1 (default: the input's default track)"`
+	Format         string `help:"Output format: mp3, aac (or m4a), m4b, or opus; sets the codec, container and extension" aliases:"output-format" enum:"mp3,opus,aac,m4a,m4b" default:"mp3"`
+	Stereo         bool   `help:"Encode as stereo at the format's stereo bitrate (default: mono)" negatable:""`
+	Dither         bool   `help:"Dither 24-bit and float sources when reducing them to 16-bit samples for MP3, instead of truncating"`
+	MatchInputRate bool   `help:"Encode a source whose sample rate is below the format's (44.1kHz, or 48kHz for Opus) at its own rate instead of upsampling, where the codec allows it"`
+	Downmix        string `help:"How a stereo source becomes mono: average both channels, or keep only the left or right" enum:"average,left,right" default:"average"`
+	ID3Version     int    `name:"id3-version" help:"ID3v2 tag version for MP3 output: 3 for legacy players, or 4" enum:"3,4" default:"4"`
+	Gapless        bool   `help:"Write the Xing/LAME header to MP3 output: the frame count for an exact duration, and the encoder delay and padding for gapless playback" negatable:"" default:"true"`
+	IORetries      int    `name:"io-retries" help:"Retry opening and replacing the output file this many times, with backoff, for flaky network filesystems" default:"0" placeholder:"N"`
+	Verbose        bool   `help:"Show FFmpeg warnings and info messages"`
+	LogFile        string `help:"Append a plain-text record of the run (settings, messages, summary) to this file" placeholder:"PATH"`
+	NoColor        bool   `help:"Disable coloured output (also set by a non-empty NO_COLOR, and off when output is not a terminal)"`
+	Debug          bool   `help:"Write an FFmpeg debug trace to jivedrop-debug.log for bug reports"`
+	Version        bool   `help:"Show version information, with the linked FFmpeg and MP3 encoder" env:"-"`
+	Probe          bool   `help:"Print the audio file's codec, sample rate, channels, bit depth, sample format, duration, tracks and tags, then exit without encoding"`
+	Benchmark      bool   `help:"Encode without the progress display and print only the realtime factor to stdout, for tracking performance"`
+
+	// Machine-readable progress for build systems
+	ProgressStderr bool `help:"Also write a 'PROGRESS N' line to stderr each time the encode passes another whole percent" xor:"progress"`
+	ProgressJSON   bool `name:"progress-json" help:"Write JSON progress events to stderr in place of the progress display, ending with a done event that carries the result" xor:"progress"`
 }
 
-// detectMode determines if this is Hugo or Standalone workflow
-func detectMode(audioFile, episodeMD string) WorkflowMode {
-	// With no audio file the mode is irrelevant; run() shows help and exits.
-	if audioFile == "" {
-		return HugoMode
-	}
-
-	// A .md second argument signals Hugo mode.
-	if episodeMD != "" && strings.HasSuffix(strings.ToLower(episodeMD), ".md") {
-		return HugoMode
-	}
-
-	return StandaloneMode
-}
-
-// sanitiseForFilename lowercases the string, replaces spaces with hyphens, and
-// strips anything that is not alphanumeric, hyphen, underscore, or dot, so the
-// result is safe to use as a filename.
-func sanitiseForFilename(s string) string {
-	s = strings.ReplaceAll(s, " ", "-")
-	s = strings.ToLower(s)
-	return strings.Map(func(r rune) rune {
-		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '-' || r == '_' || r == '.' {
-			return r
-		}
-		return -1
-	}, s)
-}
-
-// generateFilename creates the output filename based on mode and metadata.
-// cliArtist is the raw --artist flag value, used in Hugo mode to decide whether
-// the default LMP prefix is overridden; artist is the resolved metadata artist.
-// ext is the output file extension including the leading dot (e.g. ".mp3").
-func generateFilename(mode WorkflowMode, num, artist, cliArtist, ext string) string {
-	if mode == HugoMode {
-		// Hugo mode: LMP{num}{ext} unless artist is overridden
-		if cliArtist != "" && cliArtist != HugoDefaultArtist {
-			sanitisedArtist := sanitiseForFilename(artist)
-			return fmt.Sprintf("%s-%s%s", sanitisedArtist, num, ext)
-		}
-		return fmt.Sprintf("%s%s%s", HugoDefaultPrefix, num, ext)
-	}
-
-	// Standalone mode: {artist}-{num}{ext} or episode-{num}{ext} fallback
-	if artist != "" {
-		sanitisedArtist := sanitiseForFilename(artist)
-		return fmt.Sprintf("%s-%s%s", sanitisedArtist, num, ext)
-	}
-
-	return fmt.Sprintf("episode-%s%s", num, ext)
-}
-
-// resolveOutputPath determines final output file path. outputPath is the raw
-// --output-path flag value; cliArtist is the raw --artist flag value passed
-// through to generateFilename; ext is the output file extension including the
-// leading dot.
-func resolveOutputPath(mode WorkflowMode, num, artist, cliArtist, ext, outputPath string) (string, error) {
-	if outputPath == "" {
-		// No path given: write a generated filename in the current directory.
-		filename := generateFilename(mode, num, artist, cliArtist, ext)
-		return filename, nil
-	}
-
-	stat, err := os.Stat(outputPath)
-	if err == nil {
-		if stat.IsDir() {
-			filename := generateFilename(mode, num, artist, cliArtist, ext)
-			return filepath.Join(outputPath, filename), nil
-		}
-		return outputPath, nil
-	}
-
-	// A trailing slash on a non-existent path means the user meant a directory,
-	// which must already exist; treat the absence as an error.
-	if strings.HasSuffix(outputPath, "/") {
-		return "", fmt.Errorf("output directory does not exist: %s", outputPath)
-	}
-
-	// Treat the path as a file; its parent directory must exist.
-	dir := filepath.Dir(outputPath)
-	if dir != "." && dir != "" {
-		if stat, err := os.Stat(dir); err != nil || !stat.IsDir() {
-			return "", fmt.Errorf("output directory does not exist: %s", dir)
-		}
-	}
-
-	return outputPath, nil
-}
-
-// EncodeRequest carries everything the encode pipeline needs, sourced from the
-// CLI flags by the caller so encode itself reads no package-level state.
-type EncodeRequest struct {
-	Mode         WorkflowMode
-	TagInfo      id3.TagInfo
-	CoverArtPath string
-	OutputPath   string
-	AudioFile    string
-	EpisodeMD    string
-	Format       string
-	Stereo       bool
-}
-
-// printEncodePlan prints the pre-encode summary: the request metadata lines and
-// the encoder's resolved input-info line. enc must already be initialised, since
-// the input line reads enc.GetInputInfo().
-func printEncodePlan(req EncodeRequest, enc *encoder.Encoder) {
-	cli.PrintSuccessLabel("Ready to encode:", fmt.Sprintf("%s -> %s", req.AudioFile, strings.ToUpper(req.Format)))
-	cli.PrintLabelValue("• Episode:", fmt.Sprintf("%s - %s", req.TagInfo.EpisodeNumber, req.TagInfo.Title))
-	if req.Mode == HugoMode {
-		cli.PrintLabelValue("• Episode markdown:", req.EpisodeMD)
-	}
-	cli.PrintLabelValue("• Output:", req.OutputPath)
-	channelLabel := "Mono"
-	if req.Stereo {
-		channelLabel = "Stereo"
-	}
-	cli.PrintLabelValue("• Encoding mode:", fmt.Sprintf("%s %dkbps", channelLabel, enc.Bitrate()))
-
-	sampleRate, channels, format := enc.GetInputInfo()
-	channelMode := encoder.FormatChannelMode(channels)
-	cli.PrintLabelValue("• Input:", fmt.Sprintf("%s %d㎐ %s", format, sampleRate, channelMode))
-}
-
-// encodeOutcome reports how the Bubbletea encoding UI finished. err is non-nil
-// when the run failed; partialFile is true when that failure left a truncated
-// output file that the caller must discard (cancel or encode error, but not a UI error).
-type encodeOutcome struct {
-	err         error
-	partialFile bool
-}
-
-// runEncodeUI drives the Bubbletea encoding UI to completion. It detects a TTY,
-// builds the matching program, runs it, and reports the resolved outcome. The
-// caller owns partial-file cleanup, guided by outcome.partialFile.
-func runEncodeUI(enc *encoder.Encoder, outputMode string, outputBitrate int) encodeOutcome {
+// runEncodeUI is the app.EncodeFunc behind the Bubbletea encoding UI. It
+// detects a TTY, builds the matching program, and runs it until the encode
+// finishes, fails or is cancelled. Run discards the output file on error.
+// A non-nil progress also receives every progress update.
+func runEncodeUI(enc *encoder.Encoder, progress *percentReporter) (encoder.EncodeResult, error) {
 	// Drive the TUI only on a real terminal. Without a TTY the renderer is
 	// disabled so no ANSI box-drawing or cursor escapes reach the pipe.
 	isTTY := term.IsTerminal(os.Stdout.Fd())
-	encodeModel := ui.NewEncodeModel(enc, outputMode, outputBitrate, !isTTY)
+	encodeModel := ui.NewEncodeModel(enc, enc.ChannelMode(), enc.Bitrate(), !isTTY)
+	if progress != nil {
+		encodeModel.OnProgress(progress.update)
+	}
 	var p *tea.Program
 	if isTTY {
-		p = tea.NewProgram(encodeModel, tea.WithFPS(60))
+		if !cli.ColourDisabled() {
+			encodeModel.ShowProgressInTitle()
+		}
+		p = tea.NewProgram(encodeModel, tea.WithFPS(60), tea.WithColorProfile(cli.ColourProfile()))
 	} else {
 		// WithoutRenderer stops output; WithInput(nil) stops Bubbletea
 		// opening /dev/tty for input, which would fail in a pipe or CI.
@@ -207,19 +184,18 @@ func runEncodeUI(enc *encoder.Encoder, outputMode string, outputBitrate int) enc
 
 	finalModel, err := p.Run()
 	if err != nil {
-		// A UI failure leaves no truncated output file to discard.
-		return encodeOutcome{err: fmt.Errorf("UI error: %w", err)}
+		return encoder.EncodeResult{}, fmt.Errorf("UI error: %w", err)
 	}
 
 	if encModel, ok := finalModel.(*ui.EncodeModel); ok {
 		if encModel.Cancelled() {
 			// User interrupted with Ctrl+C. Encode has already returned (the model
-			// quits only after EncodingCompleteMsg), so the caller's deferred Close
-			// is safe. Report the interrupt; the caller discards the truncated file.
-			return encodeOutcome{err: fmt.Errorf("encoding cancelled"), partialFile: true}
+			// quits only after EncodingCompleteMsg), so the deferred Close in the
+			// app is safe. Report the interrupt; the truncated file is discarded.
+			return encoder.EncodeResult{}, fmt.Errorf("encoding cancelled")
 		}
 		if encModel.Error() != nil {
-			return encodeOutcome{err: fmt.Errorf("encoding failed: %w", encModel.Error()), partialFile: true}
+			return encoder.EncodeResult{}, fmt.Errorf("encoding failed: %w", encModel.Error())
 		}
 	}
 
@@ -231,92 +207,24 @@ func runEncodeUI(enc *encoder.Encoder, outputMode string, outputBitrate int) enc
 	if !isTTY {
 		fmt.Println("Audio encoded, embedding metadata...")
 	}
-
-	return encodeOutcome{}
-}
-
-// embedMetadata finishes the job after a successful encode: tags and cover art
-// are written by the encoder during Initialize/Encode, so this only extracts
-// file statistics. The returned partial flag is true when the output file was written
-// successfully but stats extraction failed; in that case stats is nil.
-func embedMetadata(req EncodeRequest, enc *encoder.Encoder) (stats *encoder.FileStats, partial bool) {
-	cli.PrintSuccessLabel("Complete:", req.OutputPath)
-
-	// Extract file statistics using duration from encoder (avoids re-opening file)
-	durationSecs := enc.GetDurationSecs()
-	stats, err := encoder.GetFileStats(req.OutputPath, durationSecs)
-	if err != nil {
-		cli.PrintWarning(fmt.Sprintf("Could not extract file statistics: %v", err))
-		return nil, true
+	if progress != nil {
+		progress.done()
 	}
 
-	return stats, false
+	return encodeModel.Result(), nil
 }
 
-// encode orchestrates the full encoding pipeline: print the plan, create and
-// initialise the encoder, scale cover art concurrently, run the Bubbletea UI,
-// handle the outcome, then embed metadata and extract statistics. The returned
-// partial flag is true when the output file was written successfully but stats
-// extraction failed; in that case stats is nil and error is nil.
-func encode(req EncodeRequest) (stats *encoder.FileStats, partial bool, err error) {
-	// The encoder now embeds the cover as an attached-picture stream during
-	// Initialize/Encode, so the scaled bytes must exist before Initialize. Scale
-	// up front rather than overlapping the encode; this drops the old
-	// scale/encode concurrency, but scaling is fast so the cost is negligible.
-	coverArtChan := make(chan coverArtResult, 1)
-	go func() {
-		if req.CoverArtPath == "" {
-			coverArtChan <- coverArtResult{data: nil, err: nil}
-			return
-		}
-
-		artwork, artErr := id3.ScaleCoverArt(req.CoverArtPath)
-		coverArtChan <- coverArtResult{data: artwork, err: artErr}
-	}()
-
-	coverResult := <-coverArtChan
-	if coverResult.err != nil {
-		return nil, false, fmt.Errorf("failed to process cover art: %w", coverResult.err)
-	}
-
-	enc, err := encoder.New(encoder.Config{
-		InputPath:  req.AudioFile,
-		OutputPath: req.OutputPath,
-		Format:     req.Format,
-		Stereo:     req.Stereo,
-		CoverArt:   coverResult.data,
-		Metadata: encoder.Metadata{
-			EpisodeNumber: req.TagInfo.EpisodeNumber,
-			Title:         req.TagInfo.Title,
-			Artist:        req.TagInfo.Artist,
-			Album:         req.TagInfo.Album,
-			Date:          req.TagInfo.Date,
-			Comment:       req.TagInfo.Comment,
-		},
-	})
+// runEncodeJSON is the app.EncodeFunc for --progress-json: no progress
+// display, only JSON progress events on stderr ending with a done event.
+func runEncodeJSON(enc *encoder.Encoder) (encoder.EncodeResult, error) {
+	sampleRate, _, _ := enc.GetInputInfo()
+	progress := ui.NewJSONProgress(os.Stderr, sampleRate)
+	result, err := enc.Encode(progress.Update)
+	progress.Done(result, err)
 	if err != nil {
-		return nil, false, fmt.Errorf("failed to create encoder: %w", err)
-	}
-	defer enc.Close()
-
-	if err := enc.Initialize(); err != nil {
-		return nil, false, fmt.Errorf("failed to initialize encoder: %w", err)
-	}
-
-	printEncodePlan(req, enc)
-
-	outcome := runEncodeUI(enc, enc.ChannelMode(), enc.Bitrate())
-	if outcome.err != nil {
-		if outcome.partialFile {
-			// Discard the truncated output file so a cancelled or failed run leaves no
-			// partial file.
-			os.Remove(req.OutputPath)
-		}
-		return nil, false, outcome.err
+		return encoder.EncodeResult{}, fmt.Errorf("encoding failed: %w", err)
 	}
-
-	stats, partial = embedMetadata(req, enc)
-	return stats, partial, nil
+	return result, nil
 }
 
 func main() {
@@ -324,85 +232,216 @@ func main() {
 }
 
 func run() int {
+	// The settings saved by --remember become flag defaults, so they must be
+	// loaded before the command line is parsed.
+	var resolvers []kong.Resolver
+	if reuseRequested(os.Args[1:]) {
+		resolver, err := lastSettingsResolver(".")
+		if err != nil {
+			cli.PrintError(err.Error())
+			return exitUsage
+		}
+		resolvers = append(resolvers, resolver)
+	}
+
 	ctx := kong.Parse(&CLI,
+		kong.Resolvers(resolvers...),
+		flagEnvars(envPrefix),
 		kong.Name("jivedrop"),
 		kong.Description("Drop the mix, ship the show—metadata, cover art, and all."),
 		kong.Vars{"version": version},
 		kong.UsageOnError(),
+		// Any flag or argument error exits with the usage code.
+		kong.Exit(func(code int) {
+			if code != 0 {
+				code = exitUsage
+			}
+			os.Exit(code)
+		}),
 		kong.Help(cli.StyledHelpPrinter(kong.HelpOptions{Compact: true})),
 	)
 
-	if CLI.Version {
-		cli.PrintVersion(version)
-		return 0
+	if CLI.NoColor {
+		cli.DisableColour()
+	}
+	// Keep stdout for the realtime factor alone.
+	if CLI.Benchmark {
+		cli.MessagesToStderr()
+	}
+	if CLI.LogFile != "" {
+		runLog, err := cli.StartRunLog(CLI.LogFile, os.Args[1:])
+		if err != nil {
+			cli.PrintError(err.Error())
+			return exitIO
+		}
+		defer runLog.Close()
 	}
 
-	if CLI.AudioFile == "" {
-		_ = ctx.PrintUsage(false)
-		return 0
+	for _, warning := range cli.ThemeWarnings() {
+		cli.PrintWarning(warning)
 	}
 
-	mode := detectMode(CLI.AudioFile, CLI.EpisodeMD)
-	opts := CLIOptions{
-		EpisodeMD: CLI.EpisodeMD,
-		Num:       CLI.Num,
-		Title:     CLI.Title,
-		Artist:    CLI.Artist,
-		Album:     CLI.Album,
-		Date:      CLI.Date,
-		Comment:   CLI.Comment,
-		Cover:     CLI.Cover,
+	if CLI.Version {
+		libs := encoder.QueryLibraryVersions()
+		cli.PrintVersion(version, libs.FFmpeg, libs.AVCodec, libs.MP3Encoder)
+		return exitOK
 	}
-	wf := newWorkflow(mode, opts)
 
-	// Audio-file existence is mode-independent, so check it once here before
-	// the mode-specific workflow validation.
-	if _, err := os.Stat(CLI.AudioFile); err != nil {
-		cli.PrintError(fmt.Errorf("audio file not accessible: %w", err).Error())
-		return 1
+	if CLI.AudioFile == "" {
+		_ = ctx.PrintUsage(false)
+		return exitOK
 	}
 
-	if err := wf.Validate(); err != nil {
-		cli.PrintError(err.Error())
-		return 1
+	// Probing needs only the audio file, so it runs before the app.
+	if CLI.Probe {
+		if _, err := os.Stat(CLI.AudioFile); err != nil {
+			cli.PrintError(fmt.Errorf("audio file not accessible: %w", err).Error())
+			return exitInputNotFound
+		}
+		return probeInput(CLI.AudioFile, CLI.AudioTrack)
 	}
 
-	tagInfo, coverArtPath, err := wf.CollectMetadata()
-	if err != nil {
-		cli.PrintError(err.Error())
-		return 1
+	if CLI.Benchmark && CLI.MetadataOnly {
+		cli.PrintError("--benchmark times an encode, so it cannot be used with --metadata-only")
+		return exitUsage
 	}
 
-	outputPath, err := resolveOutputPath(mode, tagInfo.EpisodeNumber, tagInfo.Artist, CLI.Artist, encoder.ExtensionFor(CLI.Format), CLI.OutputPath)
-	if err != nil {
-		cli.PrintError(fmt.Sprintf("Failed to resolve output path: %v", err))
-		return 1
+	debugLog := ""
+	if CLI.Debug {
+		debugLog = debugLogName
 	}
 
-	stats, partial, err := encode(EncodeRequest{
-		Mode:         mode,
-		TagInfo:      tagInfo,
-		CoverArtPath: coverArtPath,
-		OutputPath:   outputPath,
+	opts := app.Options{
 		AudioFile:    CLI.AudioFile,
 		EpisodeMD:    CLI.EpisodeMD,
-		Format:       CLI.Format,
-		Stereo:       CLI.Stereo,
-	})
+		MarkdownFile: CLI.MarkdownFile,
+		Hugo:         CLI.Hugo,
+		Standalone:   CLI.Standalone,
+		Episode: app.EpisodeOptions{
+			Num:         CLI.Num,
+			Title:       CLI.Title,
+			Artist:      CLI.Artist,
+			Album:       CLI.Album,
+			AlbumArtist: CLI.AlbumArtist,
+			Date:        CLI.Date,
+			FullDate:    CLI.FullDate,
+			Comments:    CLI.Comment,
+			Cover:       CLI.Cover,
+			NoCover:     CLI.NoCover,
+			Explicit:    CLI.Explicit,
+			Yes:         CLI.Yes,
+			NoUpdate:    CLI.NoUpdate,
+			Diff:        CLI.Diff,
+		},
+		Meta:               CLI.Meta,
+		TitleFormat:        CLI.TitleFormat,
+		NoNumberPrefix:     CLI.NoNumberPrefix,
+		CoverDescription:   CLI.CoverDescription,
+		BackCover:          CLI.BackCover,
+		CoverOut:           CLI.CoverOut,
+		MaxCoverBytes:      CLI.MaxCoverBytes,
+		CoverScaler:        CLI.CoverScaler,
+		OutputPath:         CLI.OutputPath,
+		OutputDir:          CLI.OutputDir,
+		Mkdir:              CLI.Mkdir,
+		PreserveMtime:      CLI.PreserveMtime,
+		SplitDuration:      CLI.SplitDuration,
+		Preview:            CLI.Preview,
+		Interactive:        CLI.Interactive && term.IsTerminal(os.Stdin.Fd()) && term.IsTerminal(os.Stdout.Fd()),
+		UI:                 terminalUI{},
+		AutoChapters:       CLI.AutoChapters,
+		SilenceThreshold:   CLI.SilenceThreshold,
+		SilenceGap:         CLI.SilenceGap,
+		FilenameTemplate:   CLI.FilenameTemplate,
+		Format:             CLI.Format,
+		Stereo:             CLI.Stereo,
+		Dither:             CLI.Dither,
+		Downmix:            CLI.Downmix,
+		Limit:              CLI.Limit,
+		LimitCeiling:       CLI.LimitCeiling,
+		ID3Version:         CLI.ID3Version,
+		CommentLang:        CLI.CommentLang,
+		MatchInputRate:     CLI.MatchInputRate,
+		NoGapless:          !CLI.Gapless,
+		IORetries:          CLI.IORetries,
+		AudioTrack:         CLI.AudioTrack,
+		Chapters:           CLI.Chapters,
+		Cue:                CLI.Cue,
+		Transcript:         CLI.Transcript,
+		TranscriptChapters: CLI.TranscriptChapters,
+		ChaptersJSON:       CLI.ChaptersJSON,
+		MetadataOnly:       CLI.MetadataOnly,
+		Verbose:            CLI.Verbose,
+		DebugLog:           debugLog,
+		RSS:                CLI.RSS,
+		BaseURL:            CLI.BaseURL,
+	}
+	var progress *percentReporter
+	if CLI.ProgressStderr {
+		progress = newPercentReporter(os.Stderr)
+	}
+	switch {
+	case CLI.ProgressJSON:
+		// The events take the place of the progress display.
+		opts.Encode = runEncodeJSON
+	case CLI.Benchmark && progress != nil:
+		opts.Encode = func(enc *encoder.Encoder) (encoder.EncodeResult, error) {
+			result, err := enc.Encode(progress.update)
+			if err == nil {
+				progress.done()
+			}
+			return result, err
+		}
+	case CLI.Benchmark:
+		// No progress display.
+		opts.Encode = nil
+	default:
+		opts.Encode = func(enc *encoder.Encoder) (encoder.EncodeResult, error) {
+			return runEncodeUI(enc, progress)
+		}
+	}
+	if CLI.Benchmark {
+		// Nothing after the encode.
+		opts.EncodeOnly = true
+	}
+
+	result, err := app.Run(opts)
+	if debugLog != "" {
+		cli.PrintInfo("FFmpeg debug log written to " + debugLog)
+	}
 	if err != nil {
 		cli.PrintError(err.Error())
-		return 1
+		return exitCodeForRun(err)
 	}
 
-	// Encoding succeeded but stats extraction failed, so skip PostEncode.
-	if partial {
-		return 0
+	if CLI.Benchmark {
+		fmt.Println(formatRealtimeFactor(result.Encode.RealtimeFactor()))
+		return exitOK
 	}
 
-	if err := wf.PostEncode(stats); err != nil {
-		cli.PrintError(err.Error())
-		return 1
+	if result.RSSItem != nil {
+		fmt.Print("\n" + string(result.RSSItem))
+	}
+
+	if CLI.Remember {
+		settings := lastSettings{
+			Artist:      result.Episode.Artist,
+			Album:       result.Episode.Album,
+			Comment:     result.Episode.Comments,
+			Format:      CLI.Format,
+			Stereo:      CLI.Stereo,
+			TitleFormat: CLI.TitleFormat,
+		}
+		if CLI.NoNumberPrefix {
+			settings.TitleFormat = "{title}"
+		}
+		// The episode is already done, so a failed save is only a warning.
+		if err := saveLastSettings(".", settings); err != nil {
+			cli.PrintWarning(err.Error())
+		} else {
+			cli.PrintInfo("Settings saved to " + lastSettingsFile + " for --reuse")
+		}
 	}
 
-	return 0
+	return exitOK
 }
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+
+	"github.com/linuxmatters/jivedrop/internal/cli"
+	"github.com/linuxmatters/jivedrop/internal/encoder"
+)
+
+// probeFields lays out the --probe report: the stream details first, then
+// each audio track when there are several, then the container tags in
+// alphabetical order.
+func probeFields(info encoder.InputInfo) []cli.SummaryField {
+	bitDepth := "n/a"
+	if info.BitDepth > 0 {
+		bitDepth = strconv.Itoa(info.BitDepth) + "-bit"
+	}
+	sampleFmt := "unknown"
+	if info.SampleFmt != "" {
+		sampleFmt = info.SampleFmt
+	}
+	duration := "unknown"
+	if info.Duration > 0 {
+		duration = info.DurationHMS()
+	}
+
+	fields := []cli.SummaryField{
+		{Label: "Container", Value: info.Container},
+		{Label: "Codec", Value: info.Codec},
+		{Label: "Sample rate", Value: fmt.Sprintf("%d㎐", info.SampleRate)},
+		{Label: "Channels", Value: fmt.Sprintf("%d (%s)", info.Channels, encoder.FormatChannelMode(info.Channels))},
+		{Label: "Bit depth", Value: bitDepth},
+		{Label: "Sample format", Value: sampleFmt},
+		{Label: "Duration", Value: duration},
+	}
+
+	if len(info.Tracks) > 1 {
+		for _, track := range info.Tracks {
+			fields = append(fields, cli.SummaryField{Label: "Track", Value: track.String()})
+		}
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(info.Metadata)) {
+		fields = append(fields, cli.SummaryField{Label: "Tag " + key, Value: info.Metadata[key]})
+	}
+	return fields
+}
+
+// probeInput prints the --probe report for path and returns the exit code.
+func probeInput(path string, audioTrack int) int {
+	info, err := encoder.ProbeInput(path, audioTrack)
+	if err != nil {
+		cli.PrintError(err.Error())
+		return exitCodeFor(err, exitEncode)
+	}
+	cli.PrintSummaryBox(path, probeFields(info))
+	return exitOK
+}
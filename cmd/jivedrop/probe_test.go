@@ -0,0 +1,68 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/linuxmatters/jivedrop/internal/cli"
+	"github.com/linuxmatters/jivedrop/internal/encoder"
+)
+
+func TestProbeFields(t *testing.T) {
+	tests := []struct {
+		name string
+		info encoder.InputInfo
+		want []cli.SummaryField
+	}{
+		{
+			name: "lossless with tags",
+			info: encoder.InputInfo{
+				Container: "flac", Codec: "flac", SampleRate: 48000, Channels: 2, BitDepth: 24, SampleFmt: "s32",
+				Duration: 3249 * time.Second,
+				Tracks:   []encoder.AudioTrack{{Number: 1, Codec: "flac", SampleRate: 48000, Channels: 2}},
+				Metadata: map[string]string{"title": "Mirrors", "artist": "Linux Matters"},
+			},
+			want: []cli.SummaryField{
+				{Label: "Container", Value: "flac"},
+				{Label: "Codec", Value: "flac"},
+				{Label: "Sample rate", Value: "48000㎐"},
+				{Label: "Channels", Value: "2 (stereo)"},
+				{Label: "Bit depth", Value: "24-bit"},
+				{Label: "Sample format", Value: "s32"},
+				{Label: "Duration", Value: "00:54:09"},
+				{Label: "Tag artist", Value: "Linux Matters"},
+				{Label: "Tag title", Value: "Mirrors"},
+			},
+		},
+		{
+			name: "lossy multi-track without duration",
+			info: encoder.InputInfo{
+				Container: "matroska,webm", Codec: "aac", SampleRate: 44100, Channels: 1,
+				Tracks: []encoder.AudioTrack{
+					{Number: 1, Codec: "aac", SampleRate: 44100, Channels: 1, Default: true},
+					{Number: 2, Codec: "aac", SampleRate: 44100, Channels: 2},
+				},
+			},
+			want: []cli.SummaryField{
+				{Label: "Container", Value: "matroska,webm"},
+				{Label: "Codec", Value: "aac"},
+				{Label: "Sample rate", Value: "44100㎐"},
+				{Label: "Channels", Value: "1 (mono)"},
+				{Label: "Bit depth", Value: "n/a"},
+				{Label: "Sample format", Value: "unknown"},
+				{Label: "Duration", Value: "unknown"},
+				{Label: "Track", Value: "1: aac 44100㎐ mono (default)"},
+				{Label: "Track", Value: "2: aac 44100㎐ stereo"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := probeFields(tt.info); !slices.Equal(got, tt.want) {
+				t.Errorf("probeFields() =\n%v\nwant\n%v", got, tt.want)
+			}
+		})
+	}
+}
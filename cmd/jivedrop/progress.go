@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+// percentReporter writes "PROGRESS N" lines for --progress-stderr, one each
+// time the whole-number percentage advances, for build systems and shell
+// scripts that drive their own progress bars.
+type percentReporter struct {
+	w    io.Writer
+	last int
+}
+
+// newPercentReporter returns a reporter writing to w. Nothing is written
+// until the first progress update.
+func newPercentReporter(w io.Writer) *percentReporter {
+	return &percentReporter{w: w, last: -1}
+}
+
+// update is an encoder.ProgressCallback. Updates without a known total are
+// ignored, as no percentage can be given for them.
+func (r *percentReporter) update(samplesProcessed, totalSamples int64) {
+	if totalSamples <= 0 {
+		return
+	}
+	r.report(int(min(samplesProcessed*100/totalSamples, 100)))
+}
+
+// done reports 100 once the encode has finished, as the last update before
+// the encoder flushes may fall short of it.
+func (r *percentReporter) done() {
+	r.report(100)
+}
+
+func (r *percentReporter) report(percent int) {
+	if percent <= r.last {
+		return
+	}
+	r.last = percent
+	fmt.Fprintf(r.w, "PROGRESS %d\n", percent)
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPercentReporter(t *testing.T) {
+	var out strings.Builder
+	r := newPercentReporter(&out)
+
+	r.update(0, 0) // unknown total: nothing to report
+	r.update(0, 1000)
+	r.update(4, 1000) // still 0%
+	r.update(10, 1000)
+	r.update(15, 1000) // still 1%
+	r.update(420, 1000)
+	r.update(410, 1000) // never goes backwards
+	r.done()
+	r.done()
+
+	want := "PROGRESS 0\nPROGRESS 1\nPROGRESS 42\nPROGRESS 100\n"
+	if got := out.String(); got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/alecthomas/kong"
+)
+
+// lastSettingsFile holds the settings saved by --remember. It lives in the
+// working directory, so each show's directory keeps its own.
+const lastSettingsFile = ".jivedrop-last.json"
+
+// lastSettings are the show-level settings --remember saves and --reuse loads.
+// The JSON keys are the flag names in snake_case, which is how the kong.JSON
+// resolver looks them up. Only flags the command line can set back are saved:
+// --stereo has --no-stereo, and --no-number-prefix is saved as the title
+// format it stands for, which --title-format overrides.
+type lastSettings struct {
+	Artist      string   `json:"artist,omitempty"`
+	Album       string   `json:"album,omitempty"`
+	Comment     []string `json:"comment,omitempty"`
+	Format      string   `json:"format,omitempty"`
+	Stereo      bool     `json:"stereo,omitempty"`
+	TitleFormat string   `json:"title_format,omitempty"`
+}
+
+// reuseRequested reports whether args ask for --reuse, as --reuse or
+// --reuse=true (or any other value strconv.ParseBool takes, as kong does). It
+// is checked before kong parses the command line, as the saved settings feed
+// that parse.
+func reuseRequested(args []string) bool {
+	reuse := false
+	for _, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if arg == "--reuse" {
+			reuse = true
+			continue
+		}
+		if value, ok := strings.CutPrefix(arg, "--reuse="); ok {
+			if v, err := strconv.ParseBool(value); err == nil {
+				reuse = v
+			}
+		}
+	}
+	return reuse
+}
+
+// lastSettingsResolver returns a kong resolver that fills any flag left off
+// the command line from the settings saved in dir.
+func lastSettingsResolver(dir string) (kong.Resolver, error) {
+	path := filepath.Join(dir, lastSettingsFile)
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("no saved settings in %s: run with --remember first", lastSettingsFile)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open saved settings: %w", err)
+	}
+	defer f.Close()
+
+	resolver, err := kong.JSON(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read saved settings %s: %w", path, err)
+	}
+	return resolver, nil
+}
+
+// saveLastSettings writes settings to dir for a later --reuse.
+func saveLastSettings(dir string, settings lastSettings) error {
+	data, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode settings: %w", err)
+	}
+	data = append(data, '\n')
+	if err := os.WriteFile(filepath.Join(dir, lastSettingsFile), data, 0o644); err != nil { //nolint:gosec // the settings are not secret
+		return fmt.Errorf("failed to save settings: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/alecthomas/kong"
+)
+
+func TestReuseRequested(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want bool
+	}{
+		{name: "absent", args: []string{"episode.flac", "--stereo"}, want: false},
+		{name: "present", args: []string{"episode.flac", "--reuse", "--num", "67"}, want: true},
+		{name: "after separator", args: []string{"--", "--reuse"}, want: false},
+		{name: "with value", args: []string{"episode.flac", "--reuse=true"}, want: true},
+		{name: "turned off", args: []string{"--reuse", "--reuse=false"}, want: false},
+		{name: "bad value", args: []string{"--reuse=maybe"}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := reuseRequested(tt.args); got != tt.want {
+				t.Errorf("reuseRequested(%q) = %v, want %v", tt.args, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestLastSettingsRoundTrip saves settings, loads them as flag defaults, and
+// checks that flags on the command line still win.
+func TestLastSettingsRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	saved := lastSettings{
+		Artist:      "Linux Matters",
+		Comment:     []string{"https://linuxmatters.sh", "Thanks for listening"},
+		Format:      "opus",
+		Stereo:      true,
+		TitleFormat: "{title} ({num})",
+	}
+	if err := saveLastSettings(dir, saved); err != nil {
+		t.Fatalf("saveLastSettings() error: %v", err)
+	}
+
+	var flags struct {
+		Artist      string
+		Album       string
+		Comment     []string `sep:"none"`
+		Format      string   `enum:"mp3,opus,aac" default:"mp3"`
+		Stereo      bool     `negatable:""`
+		TitleFormat string
+	}
+	resolver, err := lastSettingsResolver(dir)
+	if err != nil {
+		t.Fatalf("lastSettingsResolver() error: %v", err)
+	}
+	parser, err := kong.New(&flags, kong.Resolvers(resolver))
+	if err != nil {
+		t.Fatalf("kong.New() error: %v", err)
+	}
+	if _, err := parser.Parse([]string{"--format", "aac"}); err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	if flags.Artist != saved.Artist || flags.TitleFormat != saved.TitleFormat || !flags.Stereo {
+		t.Errorf("saved settings not applied: %+v", flags)
+	}
+	if !slices.Equal(flags.Comment, saved.Comment) {
+		t.Errorf("comment = %q, want %q", flags.Comment, saved.Comment)
+	}
+	if flags.Format != "aac" {
+		t.Errorf("format = %q, want the command line's aac", flags.Format)
+	}
+	if flags.Album != "" {
+		t.Errorf("album = %q, want none", flags.Album)
+	}
+
+	if _, err := parser.Parse([]string{"--no-stereo"}); err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	if flags.Stereo {
+		t.Error("--no-stereo did not override the saved stereo setting")
+	}
+}
+
+func TestLastSettingsResolver_Errors(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := lastSettingsResolver(dir); err == nil {
+		t.Error("expected an error when nothing has been saved")
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, lastSettingsFile), []byte("{not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := lastSettingsResolver(dir); err == nil {
+		t.Error("expected an error for a corrupt settings file")
+	}
+}
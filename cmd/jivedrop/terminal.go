@@ -0,0 +1,34 @@
+package main
+
+import (
+	"github.com/linuxmatters/jivedrop/internal/app"
+	"github.com/linuxmatters/jivedrop/internal/cli"
+)
+
+// terminalUI is the app.UI behind the jivedrop command: it prints what the
+// app reports through the cli package and asks its questions on the
+// terminal.
+type terminalUI struct{}
+
+func (terminalUI) Info(msg string)             { cli.PrintInfo(msg) }
+func (terminalUI) Success(msg string)          { cli.PrintSuccess(msg) }
+func (terminalUI) Warning(msg string)          { cli.PrintWarning(msg) }
+func (terminalUI) Error(msg string)            { cli.PrintError(msg) }
+func (terminalUI) Detail(label, value string)  { cli.PrintLabelValue(label, value) }
+func (terminalUI) Done(label, value string)    { cli.PrintSuccessLabel(label, value) }
+func (terminalUI) Cover(edge int, size string) { cli.PrintCover(edge, size) }
+func (terminalUI) Diff(diff string)            { cli.PrintDiff(diff) }
+
+func (terminalUI) Summary(title string, fields []app.SummaryField) {
+	rows := make([]cli.SummaryField, len(fields))
+	for i, f := range fields {
+		rows[i] = cli.SummaryField{Label: f.Label, Value: f.Value}
+	}
+	cli.PrintSummaryBox(title, rows)
+}
+
+func (terminalUI) Confirm(question string) bool { return cli.Confirm(question) }
+
+func (terminalUI) Prompt(question string, validate func(string) error) (string, bool) {
+	return cli.Prompt(question, validate)
+}
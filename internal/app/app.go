@@ -0,0 +1,1284 @@
+// Package app runs the whole jivedrop workflow: detect the mode, gather the
+// metadata, resolve the output path, encode or re-tag, read the statistics and
+// finish with the frontmatter update and RSS item. The jivedrop command parses
+// its flags into Options and calls Run; tests and other programs can do the
+// same.
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/linuxmatters/jivedrop/internal/encoder"
+	"github.com/linuxmatters/jivedrop/internal/id3"
+	"github.com/linuxmatters/jivedrop/internal/transcript"
+)
+
+// Options is everything Run needs, as the jivedrop flags give it. Each field
+// matches the flag of the same name; zero values mean the flag was not given,
+// except Format, which defaults to mp3 when empty.
+type Options struct {
+	AudioFile string
+	// EpisodeMD is the markdown positional argument and MarkdownFile the
+	// --episode-md flag; Hugo and Standalone force the mode.
+	EpisodeMD    string
+	MarkdownFile string
+	Hugo         bool
+	Standalone   bool
+
+	// Episode carries the metadata and cover flags; its EpisodeMD is set by Run.
+	Episode        EpisodeOptions
+	Meta           string // metadata file path, filling flags left unset
+	TitleFormat    string
+	NoNumberPrefix bool
+
+	CoverDescription string
+	BackCover        string
+	CoverOut         string
+	MaxCoverBytes    int
+	CoverScaler      string
+
+	OutputPath       string
+	OutputDir        string
+	Mkdir            bool
+	FilenameTemplate string
+	PreserveMtime    bool
+
+	Format         string
+	Stereo         bool
+	Dither         bool
+	Downmix        string
+	Limit          bool
+	LimitCeiling   float64
+	ID3Version     int
+	CommentLang    string // ISO 639-2 code for MP3 comment frames; empty is eng
+	MatchInputRate bool   // encode a low-rate input at its own rate
+	NoGapless      bool
+	IORetries      int
+	AudioTrack     int
+	Chapters       string // chapters file path
+	Cue            string // CUE sheet path, read for chapters in place of Chapters
+	ChaptersJSON   bool   // also write the chapters to NAME.chapters.json
+	Transcript     string // timed transcript path, embedded in MP3 as SYLT
+
+	// TranscriptChapters makes chapters from the Transcript cues, each at
+	// least this long; zero makes none.
+	TranscriptChapters time.Duration
+	MetadataOnly       bool
+	Verbose            bool
+	DebugLog           string // FFmpeg debug trace path; empty disables it
+
+	// AutoChapters adds a chapter wherever the audio resumes after at least
+	// SilenceGap below SilenceThreshold dB; zero values take the encoder
+	// defaults.
+	AutoChapters     bool
+	SilenceThreshold float64
+	SilenceGap       time.Duration
+
+	// SplitDuration also writes the MP3 out in parts of this length; zero
+	// writes the one file.
+	SplitDuration time.Duration
+
+	// Interactive asks on the terminal for the standalone fields the flags
+	// leave missing. The caller sets it only when stdin and stdout are both
+	// terminals.
+	Interactive bool
+
+	// Preview encodes only this much of the start of the audio, to NAME-preview
+	// beside the usual output, and stops there: no frontmatter update, parts
+	// or RSS item. Zero encodes everything.
+	Preview time.Duration
+
+	RSS     string // RSS item destination, "-" for stdout
+	BaseURL string
+
+	// UI receives everything Run has to say as it goes, and answers the
+	// --interactive and frontmatter questions. nil reports nothing and
+	// answers nothing.
+	UI UI
+
+	// Encode runs the encode once the encoder is initialised, so a caller
+	// can drive a progress display. nil encodes without reporting progress.
+	Encode EncodeFunc
+	// EncodeOnly stops after the encode: no completion summary, frontmatter
+	// update or RSS item. Result.Encode still reports the encode.
+	EncodeOnly bool
+}
+
+// EncodeFunc runs enc.Encode and returns its result. Run removes the output
+// file when it returns an error.
+type EncodeFunc func(enc *encoder.Encoder) (encoder.EncodeResult, error)
+
+// Result reports a successful Run.
+type Result struct {
+	Mode       WorkflowMode
+	OutputPath string
+	Encode     encoder.EncodeResult
+	// Stats is nil when the output was written but its statistics could not
+	// be read, and with EncodeOnly.
+	Stats *encoder.FileStats
+	// Episode is the metadata the run used, after any tags taken from the
+	// input file.
+	Episode EpisodeOptions
+	// RSSItem is the RSS item snippet when Options.RSS is "-", for the
+	// caller to print; Run writes it to any other destination itself.
+	RSSItem []byte
+}
+
+// ErrorKind says which kind of problem stopped a Run, so the caller can map
+// it to an exit code.
+type ErrorKind int
+
+const (
+	// KindUsage is an invalid flag, argument or input value.
+	KindUsage ErrorKind = iota + 1
+	// KindInputNotFound is a missing input file.
+	KindInputNotFound
+	// KindEncode is a failed, cancelled or impossible encode.
+	KindEncode
+	// KindIO is a failed output write, or one that would fail.
+	KindIO
+)
+
+// Error is the error Run returns, carrying its ErrorKind.
+type Error struct {
+	Kind ErrorKind
+	Err  error
+}
+
+func (e *Error) Error() string { return e.Err.Error() }
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// fail wraps err as an Error of the given kind.
+func fail(kind ErrorKind, err error) error {
+	return &Error{Kind: kind, Err: err}
+}
+
+// failFor wraps err as an Error of kind, unless err is a missing file or a
+// full disk, which are always KindInputNotFound and KindIO.
+func failFor(err error, kind ErrorKind) error {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		kind = KindInputNotFound
+	case errors.Is(err, ErrInsufficientSpace):
+		kind = KindIO
+	}
+	return fail(kind, err)
+}
+
+// Run takes one episode from audio file to finished output. Progress,
+// warnings and the completion summary are reported through opts.UI as it
+// goes; Run prints nothing itself. Any failure is returned as an *Error.
+func Run(opts Options) (Result, error) {
+	if opts.Format == "" {
+		opts.Format = "mp3"
+	}
+	if opts.UI == nil {
+		opts.UI = quietUI{}
+	}
+	ui := opts.UI
+
+	episodeMD, explicitMode, err := resolveModeFlags(opts.EpisodeMD, opts.MarkdownFile, opts.Hugo, opts.Standalone)
+	if err != nil {
+		return Result{}, fail(KindUsage, err)
+	}
+	mode := detectMode(opts.AudioFile, episodeMD, explicitMode)
+	// Trim the metadata flags once here so validation, tagging, and filename
+	// generation all see the same values.
+	episode := opts.Episode
+	episode.EpisodeMD = episodeMD
+	episode = episode.trimmed()
+
+	// A metadata file stands in for the flags without a Hugo site, so it is
+	// standalone only. It fills in after the flags and before any input tags.
+	if opts.Meta != "" {
+		if mode == HugoMode {
+			return Result{}, fail(KindUsage, errors.New("--meta is for standalone mode; Hugo mode reads the episode markdown frontmatter"))
+		}
+		meta, err := readSidecar(opts.Meta)
+		if err != nil {
+			return Result{}, failFor(err, KindUsage)
+		}
+		episode = episode.withSidecar(meta).trimmed()
+	}
+
+	// Audio-file existence is mode-independent, so check it once here before
+	// the mode-specific workflow validation.
+	input, err := os.Stat(opts.AudioFile)
+	if err != nil {
+		return Result{}, fail(KindInputNotFound, fmt.Errorf("audio file not accessible: %w", err))
+	}
+
+	if err := checkFlags(opts); err != nil {
+		return Result{}, fail(KindUsage, err)
+	}
+
+	// Re-tagging copies the MP3 audio as it is, so the input must already be
+	// an MP3.
+	if opts.MetadataOnly {
+		if err := encoder.CheckMP3(opts.AudioFile); err != nil {
+			return Result{}, failFor(fmt.Errorf("Cannot re-tag %s: %w", opts.AudioFile, err), KindUsage) //nolint:revive,staticcheck // user-facing message
+		}
+	}
+
+	// Without a markdown file, tags already in the source file fill any
+	// metadata flags left unset. A probe failure is not reported here: the
+	// encoder opens the same file and reports the real error.
+	if mode == StandaloneMode {
+		if tags, err := encoder.ProbeInputMetadata(opts.AudioFile); err == nil {
+			var used []string
+			episode, used = episode.withInputTags(tags)
+			if len(used) > 0 {
+				ui.Info("Using input file tags for: " + strings.Join(used, ", "))
+			}
+		}
+	}
+
+	// Fail fast on a minimal FFmpeg build, before any metadata work.
+	if err := encoder.CheckCapabilities(opts.Format); err != nil {
+		return Result{}, fail(KindEncode, err)
+	}
+
+	if mode == StandaloneMode && opts.Interactive {
+		episode = promptMissing(episode, ui.Prompt)
+	}
+	wf := newWorkflow(mode, episode, ui)
+
+	if err := wf.Validate(); err != nil {
+		return Result{}, failFor(err, KindUsage)
+	}
+
+	titleFormat, err := resolveTitleFormat(opts.TitleFormat, opts.NoNumberPrefix)
+	if err != nil {
+		return Result{}, fail(KindUsage, err)
+	}
+
+	var baseURL *url.URL
+	if opts.RSS != "" {
+		baseURL, err = parseBaseURL(opts.BaseURL)
+		if err != nil {
+			return Result{}, fail(KindUsage, err)
+		}
+	}
+
+	var chapters []encoder.Chapter
+	if opts.Chapters != "" {
+		chapters, err = encoder.ReadChaptersFile(opts.Chapters)
+		if err != nil {
+			return Result{}, failFor(err, KindUsage)
+		}
+	}
+	if opts.Cue != "" {
+		chapters, err = encoder.ReadCueFile(opts.Cue)
+		if err != nil {
+			return Result{}, failFor(err, KindUsage)
+		}
+	}
+	if opts.AutoChapters {
+		chapters, err = detectChapters(opts)
+		if err != nil {
+			return Result{}, failFor(err, KindEncode)
+		}
+	}
+	var lines []encoder.TranscriptLine
+	if opts.Transcript != "" {
+		cues, err := readTranscript(opts.Transcript)
+		if err != nil {
+			return Result{}, failFor(err, KindUsage)
+		}
+		if opts.Format == "mp3" {
+			lines, err = transcriptLines(ui, cues, opts.ID3Version)
+			if err != nil {
+				return Result{}, failFor(err, KindUsage)
+			}
+		} else {
+			ui.Info(fmt.Sprintf("Transcript used for chapters only: %s has no SYLT frame", opts.Format))
+		}
+		if opts.TranscriptChapters > 0 {
+			chapters = transcriptChapters(ui, cues, opts.TranscriptChapters)
+		}
+	}
+	if len(chapters) > 0 {
+		var warnings []string
+		chapters, warnings = encoder.CheckChapters(chapters, opts.Format)
+		for _, warning := range warnings {
+			ui.Warning(warning)
+		}
+	}
+
+	backCover := opts.BackCover
+	if episode.NoCover {
+		backCover = ""
+	}
+
+	// A back cover URL is downloaded at encode time, so only local paths are
+	// checked.
+	if backCover != "" && !id3.IsCoverURL(backCover) {
+		if _, err := os.Stat(backCover); err != nil {
+			return Result{}, fail(KindInputNotFound, fmt.Errorf("back cover art not accessible: %w", err))
+		}
+	}
+
+	tagInfo, coverArtPath, err := wf.CollectMetadata()
+	if err != nil {
+		return Result{}, failFor(err, KindUsage)
+	}
+
+	// Check local cover art now rather than after the audio has been decoded,
+	// so a non-square or unreadable image fails in seconds. URLs are checked
+	// once downloaded.
+	for _, cover := range []string{coverArtPath, backCover} {
+		if cover == "" || id3.IsCoverURL(cover) {
+			continue
+		}
+		if err := id3.ValidateCoverArt(cover); err != nil {
+			return Result{}, failFor(fmt.Errorf("Invalid cover art %s: %w", cover, err), KindUsage) //nolint:revive,staticcheck // user-facing message
+		}
+	}
+
+	ext := encoder.ExtensionFor(opts.Format)
+	filename := generateFilename(mode, tagInfo.EpisodeNumber, tagInfo.Artist, episode.Artist, ext)
+	if opts.FilenameTemplate != "" {
+		fields := filenameFields{Num: tagInfo.EpisodeNumber, Title: tagInfo.Title, Artist: tagInfo.Artist, Date: tagInfo.Date}
+		filename, err = expandFilenameTemplate(opts.FilenameTemplate, fields, ext)
+		if err != nil {
+			return Result{}, fail(KindUsage, err)
+		}
+	}
+
+	outputPath, err := resolveOutputPath(ui, filename, opts.OutputPath, opts.Mkdir)
+	if opts.OutputDir != "" {
+		outputPath, err = resolveOutputDir(ui, filename, opts.OutputDir, opts.Mkdir)
+	}
+	if err != nil {
+		return Result{}, fail(KindIO, fmt.Errorf("Failed to resolve output path: %w", err)) //nolint:revive,staticcheck // user-facing message
+	}
+	if opts.Preview > 0 {
+		outputPath = previewPath(outputPath)
+	}
+
+	if err := checkOutputWritable(outputPath); err != nil {
+		return Result{}, fail(KindIO, err)
+	}
+
+	req := encodeRequest{
+		Mode:             mode,
+		TagInfo:          tagInfo,
+		CoverArtPath:     coverArtPath,
+		CoverDescription: strings.TrimSpace(opts.CoverDescription),
+		BackCoverPath:    backCover,
+		CoverOut:         opts.CoverOut,
+		MaxCoverBytes:    opts.MaxCoverBytes,
+		CoverScaler:      opts.CoverScaler,
+		OutputPath:       outputPath,
+		AudioFile:        opts.AudioFile,
+		EpisodeMD:        episodeMD,
+		Format:           opts.Format,
+		Stereo:           opts.Stereo,
+		Dither:           opts.Dither,
+		Downmix:          opts.Downmix,
+		Limit:            opts.Limit,
+		LimitCeiling:     opts.LimitCeiling,
+		ID3Version:       opts.ID3Version,
+		CommentLang:      opts.CommentLang,
+		MatchInputRate:   opts.MatchInputRate,
+		NoGapless:        opts.NoGapless,
+		IORetries:        opts.IORetries,
+		TitleFormat:      titleFormat,
+		Verbose:          opts.Verbose,
+		DebugLog:         opts.DebugLog,
+		AudioTrack:       opts.AudioTrack,
+		Chapters:         chapters,
+		Transcript:       lines,
+		SplitDuration:    opts.SplitDuration,
+		Preview:          opts.Preview,
+		UI:               ui,
+		Encode:           opts.Encode,
+		EncodeOnly:       opts.EncodeOnly,
+	}
+
+	pipeline := encode
+	if opts.MetadataOnly {
+		pipeline = retag
+	}
+	encoded, stats, err := pipeline(req)
+	if err != nil {
+		return Result{}, failFor(err, KindEncode)
+	}
+
+	// The pipeline has written every tag, so nothing touches the output
+	// after this.
+	if opts.PreserveMtime {
+		preserveModTime(ui, outputPath, input.ModTime())
+	}
+
+	result := Result{Mode: mode, OutputPath: outputPath, Encode: encoded, Stats: stats, Episode: episode}
+	// A preview is for listening to, not publishing.
+	if opts.EncodeOnly || opts.Preview > 0 {
+		return result, nil
+	}
+
+	if opts.ChaptersJSON && len(chapters) > 0 {
+		path := chaptersJSONPath(outputPath)
+		if err := writeChaptersJSON(path, chapters); err != nil {
+			return Result{}, fail(KindIO, err)
+		}
+		ui.Done("Chapters JSON:", path)
+	}
+
+	// Encoding succeeded but stats extraction failed, so skip PostEncode.
+	if stats == nil {
+		if opts.RSS != "" {
+			ui.Warning("RSS item not written: it needs the file statistics")
+		}
+		return result, nil
+	}
+
+	if err := wf.PostEncode(stats); err != nil {
+		return Result{}, fail(KindIO, err)
+	}
+
+	if opts.RSS != "" {
+		title := encoder.RenderTitle(titleFormat, tagInfo.EpisodeNumber, tagInfo.Title)
+		item, err := buildRSSItem(title, enclosureURL(baseURL, outputPath), encoder.MIMETypeFor(opts.Format), stats, wf.ReleaseTime())
+		if err != nil {
+			return Result{}, fail(KindIO, err)
+		}
+		if opts.RSS == "-" {
+			result.RSSItem = item
+		} else {
+			if err := writeRSSItem(opts.RSS, item); err != nil {
+				return Result{}, fail(KindIO, err)
+			}
+			ui.Done("RSS item:", opts.RSS)
+		}
+	}
+
+	return result, nil
+}
+
+// checkFlags rejects flag values and flag combinations that cannot work.
+func checkFlags(opts Options) error {
+	if opts.AutoChapters && opts.Chapters != "" {
+		return errors.New("--auto-chapters and --chapters cannot be used together")
+	}
+	if opts.Cue != "" && (opts.Chapters != "" || opts.AutoChapters) {
+		return errors.New("--cue cannot be used with --chapters or --auto-chapters")
+	}
+	if opts.TranscriptChapters < 0 {
+		return fmt.Errorf("invalid --transcript-chapters %s: must not be negative", opts.TranscriptChapters)
+	}
+	if opts.TranscriptChapters > 0 && opts.Transcript == "" {
+		return errors.New("--transcript-chapters needs a --transcript to make the chapters from")
+	}
+	if opts.TranscriptChapters > 0 && (opts.Chapters != "" || opts.Cue != "" || opts.AutoChapters) {
+		return errors.New("--transcript-chapters cannot be used with --chapters, --cue or --auto-chapters")
+	}
+	if opts.ChaptersJSON && opts.Chapters == "" && opts.Cue == "" && !opts.AutoChapters && opts.TranscriptChapters == 0 {
+		return errors.New("--chapters-json needs chapters from --chapters, --cue, --auto-chapters or --transcript-chapters")
+	}
+
+	// Other formats can still take chapters from the transcript.
+	if opts.Transcript != "" && opts.Format != "mp3" && opts.TranscriptChapters == 0 {
+		return fmt.Errorf("--transcript embeds a SYLT frame, which only MP3 has, not %s; add --transcript-chapters to use it for chapters alone", opts.Format)
+	}
+
+	// Parts are copied out of the finished file, which only MP3 allows.
+	if opts.SplitDuration < 0 {
+		return fmt.Errorf("invalid --split-duration %s: must not be negative", opts.SplitDuration)
+	}
+	if opts.SplitDuration > 0 && opts.Format != "mp3" {
+		return fmt.Errorf("--split-duration splits MP3 output only, not %s", opts.Format)
+	}
+
+	if opts.OutputPath != "" && opts.OutputDir != "" {
+		return errors.New("--output-path and --output-dir cannot be used together")
+	}
+
+	if opts.RSS != "" && opts.BaseURL == "" {
+		return errors.New("--rss needs --base-url for the enclosure link")
+	}
+
+	if opts.Preview < 0 {
+		return fmt.Errorf("invalid --preview %s: must not be negative", opts.Preview)
+	}
+	if opts.Preview > 0 && opts.Preview < encoder.MinInputDuration {
+		return fmt.Errorf("invalid --preview %s: must be at least %s, the shortest audio jivedrop encodes", opts.Preview, encoder.MinInputDuration)
+	}
+	if opts.Preview > 0 && opts.MetadataOnly {
+		return errors.New("--preview needs the audio encoded, so it cannot be used with --metadata-only")
+	}
+	if opts.Preview > 0 && opts.SplitDuration > 0 {
+		return errors.New("--preview and --split-duration cannot be used together")
+	}
+
+	if opts.CommentLang != "" {
+		if err := encoder.CheckLanguageCode(opts.CommentLang); err != nil {
+			return fmt.Errorf("invalid --comment-lang: %w", err)
+		}
+	}
+
+	// Re-tagging copies the MP3 audio as it is, so the output must be an MP3
+	// too.
+	if opts.MetadataOnly {
+		if opts.Format != "mp3" {
+			return fmt.Errorf("--metadata-only writes MP3 only, not %s", opts.Format)
+		}
+		if opts.Limit {
+			return errors.New("--limit needs the audio re-encoded, so it cannot be used with --metadata-only")
+		}
+	}
+	return nil
+}
+
+// readTranscript reads the --transcript file, which must hold at least one
+// timed line.
+func readTranscript(path string) ([]transcript.Cue, error) {
+	cues, err := transcript.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(cues) == 0 {
+		return nil, fmt.Errorf("invalid transcript %s: it has no timed lines", path)
+	}
+	return cues, nil
+}
+
+// transcriptLines returns the cues to embed as a SYLT frame, checking the
+// frame fits in the tag and warning when it is big enough to delay the start
+// of playback.
+func transcriptLines(ui UI, cues []transcript.Cue, id3Version int) ([]encoder.TranscriptLine, error) {
+	lines := make([]encoder.TranscriptLine, len(cues))
+	for i, cue := range cues {
+		lines[i] = encoder.TranscriptLine{Start: cue.Start, Text: cue.Text}
+	}
+	size := encoder.TranscriptFrameSize(lines, id3Version)
+	if size > encoder.MaxTranscriptBytes {
+		return nil, fmt.Errorf("transcript too large to embed: %s (at most %s)", encoder.FormatBytes(int64(size)), encoder.FormatBytes(encoder.MaxTranscriptBytes))
+	}
+	if size > encoder.LargeTranscriptBytes {
+		ui.Warning(fmt.Sprintf("Transcript adds %s to the ID3 tag; players read the whole tag before playback starts", encoder.FormatBytes(int64(size))))
+	}
+	return lines, nil
+}
+
+// transcriptChapters makes coarse chapters from the transcript cues and
+// reports them, as detectChapters does, for refining into a chapters file.
+func transcriptChapters(ui UI, cues []transcript.Cue, minLength time.Duration) []encoder.Chapter {
+	var chapters []encoder.Chapter
+	for _, ch := range transcript.Chapters(cues, minLength) {
+		chapters = append(chapters, encoder.Chapter{Start: ch.Start, Title: ch.Title})
+	}
+	ui.Info(fmt.Sprintf("Made %d chapters from the transcript, each at least %s:", len(chapters), minLength))
+	for _, ch := range chapters {
+		ui.Info("  " + ch.String())
+	}
+	return chapters
+}
+
+// writeChaptersJSON writes the chapters to path as a JSON chapters file, for
+// hosts that take chapters as a separate upload.
+func writeChaptersJSON(path string, chapters []encoder.Chapter) error {
+	data, err := encoder.ChaptersJSON(chapters)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil { //nolint:gosec // path sits beside the user-chosen output
+		return fmt.Errorf("failed to write chapters JSON: %w", err)
+	}
+	return nil
+}
+
+// coverArtResult carries the outcome of concurrent cover art processing back
+// to the encode pipeline.
+type coverArtResult struct {
+	data []byte
+	size int // edge length in pixels of the square artwork
+	err  error
+}
+
+// WorkflowMode selects how metadata is sourced: from Hugo frontmatter or from
+// CLI flags alone.
+type WorkflowMode int
+
+const (
+	// HugoMode reads metadata from an episode markdown file's frontmatter.
+	HugoMode WorkflowMode = iota
+	// StandaloneMode takes all metadata from CLI flags.
+	StandaloneMode
+)
+
+// autoMode asks detectMode to choose the mode from the arguments.
+const autoMode WorkflowMode = -1
+
+// detectMode determines if this is Hugo or Standalone workflow. An explicit
+// mode (from --hugo, --standalone or --episode-md) wins; with autoMode the
+// mode is guessed from the second argument.
+func detectMode(audioFile, episodeMD string, explicit WorkflowMode) WorkflowMode {
+	// With no audio file the mode is irrelevant; jivedrop shows help and exits.
+	if audioFile == "" {
+		return HugoMode
+	}
+
+	if explicit != autoMode {
+		return explicit
+	}
+
+	// A .md second argument signals Hugo mode.
+	if episodeMD != "" && strings.HasSuffix(strings.ToLower(episodeMD), ".md") {
+		return HugoMode
+	}
+
+	return StandaloneMode
+}
+
+// resolveModeFlags reconciles the markdown positional argument with the
+// --episode-md, --hugo and --standalone flags. It returns the markdown path to
+// use and the explicit mode, or autoMode when nothing was made explicit.
+func resolveModeFlags(positionalMD, flagMD string, hugo, standalone bool) (string, WorkflowMode, error) {
+	explicit := autoMode
+	switch {
+	case hugo:
+		explicit = HugoMode
+	case standalone:
+		explicit = StandaloneMode
+	}
+
+	if flagMD == "" {
+		return positionalMD, explicit, nil
+	}
+	if positionalMD != "" {
+		return "", autoMode, fmt.Errorf("episode markdown given twice: pass it as the second argument or with --episode-md, not both")
+	}
+	if standalone {
+		return "", autoMode, fmt.Errorf("--episode-md is for Hugo mode and cannot be used with --standalone")
+	}
+	return flagMD, HugoMode, nil
+}
+
+// sanitiseForFilename lowercases the string, replaces spaces with hyphens, and
+// strips anything that is not alphanumeric, hyphen, underscore, or dot, so the
+// result is safe to use as a filename.
+func sanitiseForFilename(s string) string {
+	s = strings.ReplaceAll(s, " ", "-")
+	s = strings.ToLower(s)
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '-' || r == '_' || r == '.' {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// generateFilename creates the default output filename from the mode's
+// fallback template (see defaultFilenameTemplate). cliArtist is the raw
+// --artist flag value, used in Hugo mode to decide whether the default LMP
+// prefix is overridden; artist is the resolved metadata artist. ext is the
+// output file extension including the leading dot (e.g. ".mp3").
+func generateFilename(mode WorkflowMode, num, artist, cliArtist, ext string) string {
+	template := defaultFilenameTemplate(mode, artist, cliArtist)
+	// The episode number is always set, so a default template never expands
+	// to an empty stem.
+	filename, err := expandFilenameTemplate(template, filenameFields{Num: num, Artist: artist}, ext)
+	if err != nil {
+		return fmt.Sprintf("episode-%s%s", num, ext)
+	}
+	return filename
+}
+
+// resolveOutputPath determines final output file path. filename is the
+// generated name, used when outputPath (the raw --output-path flag value) is
+// empty or a directory. mkdir is the --mkdir flag: when set, a missing output
+// directory is created instead of being reported as an error.
+func resolveOutputPath(ui UI, filename, outputPath string, mkdir bool) (string, error) {
+	if outputPath == "" {
+		// No path given: write the generated filename in the current directory.
+		return filename, nil
+	}
+
+	stat, err := os.Stat(outputPath)
+	if err == nil {
+		if stat.IsDir() {
+			return filepath.Join(outputPath, filename), nil
+		}
+		return outputPath, nil
+	}
+
+	// A trailing slash on a non-existent path means the user meant a directory,
+	// which must already exist unless --mkdir asks for it to be created.
+	if strings.HasSuffix(outputPath, "/") {
+		if err := ensureOutputDir(ui, outputPath, mkdir); err != nil {
+			return "", err
+		}
+		return filepath.Join(outputPath, filename), nil
+	}
+
+	// Treat the path as a file; its parent directory must exist.
+	dir := filepath.Dir(outputPath)
+	if dir != "." && dir != "" {
+		if err := ensureOutputDir(ui, dir, mkdir); err != nil {
+			return "", err
+		}
+	}
+
+	return outputPath, nil
+}
+
+// resolveOutputDir returns the path of filename in dir, the --output-dir flag
+// value. Unlike --output-path, dir is always a directory, so a path that does
+// not exist yet is never mistaken for a file name; it must exist unless mkdir
+// asks for it to be created.
+func resolveOutputDir(ui UI, filename, dir string, mkdir bool) (string, error) {
+	if err := ensureOutputDir(ui, dir, mkdir); err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, filename), nil
+}
+
+// ensureOutputDir checks that dir is an existing directory. A missing dir is
+// an error by default, so a typo never silently creates a folder; with mkdir
+// set it is created, parents included, and reported through ui.
+func ensureOutputDir(ui UI, dir string, mkdir bool) error {
+	stat, err := os.Stat(dir)
+	if err == nil {
+		if !stat.IsDir() {
+			return fmt.Errorf("output directory is not a directory: %s", dir)
+		}
+		return nil
+	}
+	if !mkdir || !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("output directory does not exist: %s (use --mkdir to create it)", dir)
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	ui.Info("Created output directory: " + dir)
+	return nil
+}
+
+// checkOutputWritable probes the output location before the encode: it
+// creates and removes a temporary file in the output directory, and opens an
+// existing output file for writing without truncating it. A read-only
+// directory or file, or a full disk, then fails up front rather than at the
+// muxer's first write after minutes of encoding.
+func checkOutputWritable(outputPath string) error {
+	probe, err := os.CreateTemp(filepath.Dir(outputPath), ".jivedrop-probe-*")
+	if err != nil {
+		return fmt.Errorf("output directory is not writable: %w", err)
+	}
+	_, writeErr := probe.Write([]byte{0})
+	closeErr := probe.Close()
+	os.Remove(probe.Name())
+	if err := errors.Join(writeErr, closeErr); err != nil {
+		return fmt.Errorf("output directory is not writable: %w", err)
+	}
+
+	if _, err := os.Stat(outputPath); err == nil {
+		f, err := os.OpenFile(outputPath, os.O_WRONLY, 0)
+		if err != nil {
+			return fmt.Errorf("output file is not writable: %w", err)
+		}
+		f.Close()
+	}
+	return nil
+}
+
+// encodeRequest carries everything the encode pipeline needs, resolved by Run
+// from the Options and the workflow's metadata.
+type encodeRequest struct {
+	Mode             WorkflowMode
+	TagInfo          id3.TagInfo
+	CoverArtPath     string
+	CoverDescription string
+	BackCoverPath    string
+	CoverOut         string
+	MaxCoverBytes    int
+	CoverScaler      string
+	OutputPath       string
+	AudioFile        string
+	EpisodeMD        string
+	Format           string
+	Stereo           bool
+	Dither           bool
+	Downmix          string
+	Limit            bool
+	LimitCeiling     float64
+	ID3Version       int
+	CommentLang      string
+	MatchInputRate   bool
+	NoGapless        bool
+	IORetries        int
+	TitleFormat      string
+	Verbose          bool
+	DebugLog         string
+	AudioTrack       int
+	Chapters         []encoder.Chapter
+	Transcript       []encoder.TranscriptLine
+	SplitDuration    time.Duration
+	Preview          time.Duration
+	UI               UI
+	Encode           EncodeFunc
+	EncodeOnly       bool
+}
+
+// reportEncodePlan reports the pre-encode summary: the request metadata lines
+// and the encoder's resolved input-info line. enc must already be initialised,
+// since the input line reads enc.InputInfo().
+func reportEncodePlan(req encodeRequest, enc *encoder.Encoder) {
+	req.UI.Done("Ready to encode:", fmt.Sprintf("%s -> %s", req.AudioFile, enc.FormatLabel()))
+	req.UI.Detail("• Episode:", fmt.Sprintf("%s - %s", req.TagInfo.EpisodeNumber, req.TagInfo.Title))
+	if req.Mode == HugoMode {
+		req.UI.Detail("• Episode markdown:", req.EpisodeMD)
+	}
+	req.UI.Detail("• Output:", req.OutputPath)
+	if req.TagInfo.Explicit {
+		req.UI.Detail("• Explicit:", "yes")
+	}
+	if len(req.Chapters) > 0 {
+		req.UI.Detail("• Chapters:", strconv.Itoa(len(req.Chapters)))
+	}
+	if len(req.Transcript) > 0 {
+		req.UI.Detail("• Transcript:", fmt.Sprintf("%d lines", len(req.Transcript)))
+	}
+	if req.SplitDuration > 0 {
+		req.UI.Detail("• Split:", "parts of "+req.SplitDuration.String())
+	}
+	if req.Preview > 0 {
+		req.UI.Detail("• Preview:", "first "+req.Preview.String())
+	}
+	channelLabel := "Mono"
+	if req.Stereo {
+		channelLabel = "Stereo"
+	}
+	mode := fmt.Sprintf("%s %dkbps", channelLabel, enc.Bitrate())
+	if req.Dither {
+		mode += ", dithered"
+	}
+	if !req.Stereo && req.Downmix != "" && req.Downmix != encoder.DownmixAverage {
+		mode += ", " + req.Downmix + " channel only"
+	}
+	if req.Limit {
+		mode += fmt.Sprintf(", limited to %gdB", req.LimitCeiling)
+	}
+	req.UI.Detail("• Encoding mode:", mode)
+
+	req.UI.Detail("• Input:", describeInput(enc.InputInfo()))
+}
+
+// describeInput formats the plan's input line: codec, sample rate and
+// channels, then the bit depth and decoded sample format when known, so a
+// 24-bit or float source is visible before choosing settings.
+func describeInput(info encoder.InputInfo) string {
+	line := fmt.Sprintf("%s %d㎐ %s", info.Codec, info.SampleRate, encoder.FormatChannelMode(info.Channels))
+	switch {
+	case info.BitDepth > 0 && info.SampleFmt != "":
+		line += fmt.Sprintf(", %d-bit (%s)", info.BitDepth, info.SampleFmt)
+	case info.BitDepth > 0:
+		line += fmt.Sprintf(", %d-bit", info.BitDepth)
+	case info.SampleFmt != "":
+		line += ", " + info.SampleFmt
+	}
+	return line
+}
+
+// reportFFmpegLog reports any FFmpeg log lines the encoder captured in
+// verbose mode as warnings. There are none in the default quiet mode.
+func reportFFmpegLog(ui UI, enc *encoder.Encoder) {
+	for _, msg := range enc.LogMessages() {
+		ui.Warning("FFmpeg: " + msg)
+	}
+}
+
+// warnMultipleAudioTracks lists the input's audio tracks when there is more
+// than one and none was chosen, so the user can confirm the automatic pick or
+// rerun with --audio-track.
+func warnMultipleAudioTracks(ui UI, enc *encoder.Encoder) {
+	tracks := enc.AudioTracks()
+	if len(tracks) < 2 {
+		return
+	}
+
+	ui.Warning(fmt.Sprintf("Input has %d audio tracks; encoding track %d. Choose another with --audio-track:", len(tracks), enc.SelectedAudioTrack()))
+	for _, track := range tracks {
+		ui.Info("  " + track.String())
+	}
+}
+
+// warnUpsample warns when the input's sample rate is below the output's, as
+// upsampling makes a bigger file without adding any quality. With
+// --match-input-rate it fires only when the format cannot use the input rate.
+func warnUpsample(ui UI, enc *encoder.Encoder) {
+	inputRate, _, _ := enc.GetInputInfo()
+	outputRate := enc.OutputSampleRate()
+	if inputRate == 0 || inputRate >= outputRate {
+		return
+	}
+
+	msg := fmt.Sprintf("Input is %dHz; upsampling it to %dHz will not improve its quality", inputRate, outputRate)
+	if enc.AcceptsSampleRate(inputRate) {
+		msg += ". Pass --match-input-rate to encode at the input's rate instead"
+	} else {
+		msg += fmt.Sprintf(", but %s cannot encode at %dHz", enc.FormatLabel(), inputRate)
+	}
+	ui.Warning(msg)
+}
+
+// loadCoverArt downloads path first when it is a URL, then scales it to the
+// Apple Podcasts bounds and fits it to maxBytes. An empty path yields no
+// artwork and no error.
+func loadCoverArt(ui UI, path string, maxBytes int, scaler string) coverArtResult {
+	if path == "" {
+		return coverArtResult{}
+	}
+
+	if id3.IsCoverURL(path) {
+		ui.Info("Downloading cover art: " + path)
+		downloaded, err := id3.DownloadCoverArt(path)
+		if err != nil {
+			return coverArtResult{err: err}
+		}
+		defer os.Remove(downloaded)
+		path = downloaded
+	}
+
+	warnLowResCover(ui, path)
+	artwork, size, err := id3.FitCoverArt(path, maxBytes, scaler)
+	return coverArtResult{data: artwork, size: size, err: err}
+}
+
+// warnLowResCover warns when the cover at path is small enough that upscaling
+// it to the Apple Podcasts minimum will look soft. Scaling still goes ahead;
+// an unreadable cover is left for FitCoverArt to report.
+func warnLowResCover(ui UI, path string) {
+	width, height, err := id3.CoverSourceSize(path)
+	if err != nil || width >= id3.LowResCoverSize {
+		return
+	}
+	ui.Warning(fmt.Sprintf("Cover art is only %dx%d; upscaling to %dx%d may look soft, so consider a source of at least %dx%d",
+		width, height, id3.MinCoverSize, id3.MinCoverSize, id3.MinCoverSize, id3.MinCoverSize))
+}
+
+// reportCoverBudget reports the cover art size chosen to meet
+// --max-cover-bytes, or warns when even the smallest allowed artwork is over
+// the budget.
+func reportCoverBudget(ui UI, cover coverArtResult, maxBytes int) {
+	size := encoder.FormatBytes(int64(len(cover.data)))
+	if len(cover.data) > maxBytes {
+		ui.Warning(fmt.Sprintf("Cover art is %s at %dx%d, still over the --max-cover-bytes budget of %s",
+			size, cover.size, cover.size, encoder.FormatBytes(int64(maxBytes))))
+		return
+	}
+	ui.Cover(cover.size, size)
+}
+
+// detectChapters finds chapter marks at the pauses in the input and reports
+// them as chapter file lines, ready to refine and pass back with --chapters.
+func detectChapters(opts Options) ([]encoder.Chapter, error) {
+	threshold := opts.SilenceThreshold
+	if threshold == 0 {
+		threshold = encoder.DefaultSilenceThreshold
+	}
+	gap := opts.SilenceGap
+	if gap == 0 {
+		gap = encoder.DefaultSilenceGap
+	}
+
+	opts.UI.Info(fmt.Sprintf("Detecting chapters at pauses of %s below %gdB...", gap, threshold))
+	chapters, err := encoder.DetectChapters(opts.AudioFile, opts.AudioTrack, threshold, gap)
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect chapters: %w", err)
+	}
+	opts.UI.Info(fmt.Sprintf("Detected %d chapters:", len(chapters)))
+	for _, ch := range chapters {
+		opts.UI.Info("  " + ch.String())
+	}
+	return chapters, nil
+}
+
+// writeCoverOut saves the scaled cover art, the exact PNG embedded in the
+// output, for use as feed artwork. A failure is only a warning: the encoded
+// file is already complete.
+func writeCoverOut(ui UI, path string, artwork []byte) {
+	if len(artwork) == 0 {
+		ui.Warning("No cover art to write to " + path)
+		return
+	}
+	if err := os.WriteFile(path, artwork, 0o644); err != nil { //nolint:gosec // path is the user-provided --cover-out path
+		ui.Warning(fmt.Sprintf("Failed to write cover art: %v", err))
+		return
+	}
+	ui.Done("Cover art:", path)
+}
+
+// preserveModTime sets the output file's access and modification times to
+// modTime, the input's modification time. A failure is only a warning: the
+// encoded file is already complete.
+func preserveModTime(ui UI, path string, modTime time.Time) {
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		ui.Warning(fmt.Sprintf("Failed to preserve the modification time: %v", err))
+	}
+}
+
+// embedMetadata finishes the job after a successful encode: tags and cover art
+// are written by the encoder during Initialize/Encode, so this only extracts
+// file statistics. The returned partial flag is true when the output file was written
+// successfully but stats extraction failed; in that case stats is nil and only
+// the output path is reported, since there is no summary to show.
+func embedMetadata(req encodeRequest, result encoder.EncodeResult) (stats *encoder.FileStats, partial bool) {
+	// Extract file statistics using the duration Encode reported (avoids re-opening the file)
+	stats, err := encoder.GetFileStats(req.OutputPath, result.DurationSecs)
+	if err != nil {
+		req.UI.Done("Complete:", req.OutputPath)
+		req.UI.Warning(fmt.Sprintf("Could not extract file statistics: %v", err))
+		return nil, true
+	}
+
+	return stats, false
+}
+
+// reportCompletionSummary reports the finished episode as one summary: what
+// was encoded, where it went, and the podcast statistics the feed needs.
+// audio describes the output audio for the Bitrate row, e.g. "MP3 112kbps
+// CBR, mono".
+func reportCompletionSummary(req encodeRequest, audio string, stats *encoder.FileStats, cover coverArtResult, limited bool) {
+	bitrate := audio
+	if stats.AvgBitrateKbps > 0 {
+		bitrate += fmt.Sprintf(" (average %.1f kbps)", stats.AvgBitrateKbps)
+	}
+
+	coverInfo := "none"
+	if len(cover.data) > 0 {
+		coverInfo = fmt.Sprintf("%dx%d, %s", cover.size, cover.size, encoder.FormatBytes(int64(len(cover.data))))
+		if req.BackCoverPath != "" {
+			coverInfo += ", with back cover"
+		}
+	}
+
+	fields := []SummaryField{
+		{Label: "Episode", Value: fmt.Sprintf("%s - %s", req.TagInfo.EpisodeNumber, req.TagInfo.Title)},
+		{Label: "Output", Value: req.OutputPath},
+		{Label: "Duration", Value: stats.DurationString},
+		{Label: "Size", Value: fmt.Sprintf("%d bytes (%s)", stats.FileSizeBytes, encoder.FormatBytes(stats.FileSizeBytes))},
+	}
+	// The comparison is a nicety, so an input that can no longer be read just
+	// leaves it out.
+	if info, err := os.Stat(req.AudioFile); err == nil {
+		fields = append(fields, SummaryField{Label: "Compression", Value: sizeComparison(info.Size(), stats.FileSizeBytes)})
+	}
+	fields = append(fields,
+		SummaryField{Label: "Bitrate", Value: bitrate},
+		SummaryField{Label: "Cover", Value: coverInfo},
+	)
+	if req.Limit {
+		fields = append(fields, SummaryField{Label: "Limiter", Value: limiterSummary(req.LimitCeiling, limited)})
+	}
+
+	req.UI.Summary("Complete", fields)
+}
+
+// limiterSummary says whether the peak limiter engaged, i.e. whether any peak
+// rose above the ceiling and was reduced.
+func limiterSummary(ceiling float64, limited bool) string {
+	if limited {
+		return fmt.Sprintf("engaged, peaks above %gdB were reduced", ceiling)
+	}
+	return fmt.Sprintf("not engaged, no peaks above %gdB", ceiling)
+}
+
+// sizeComparison describes how the output size compares with the input, e.g.
+// "Input: 280.0 MiB → Output: 26.0 MiB (91% smaller)".
+func sizeComparison(inputBytes, outputBytes int64) string {
+	sizes := fmt.Sprintf("Input: %s → Output: %s", encoder.FormatBytes(inputBytes), encoder.FormatBytes(outputBytes))
+	if inputBytes <= 0 {
+		return sizes
+	}
+
+	change := math.Round(float64(outputBytes-inputBytes) / float64(inputBytes) * 100)
+	switch {
+	case change < 0:
+		return fmt.Sprintf("%s (%.0f%% smaller)", sizes, -change)
+	case change > 0:
+		return fmt.Sprintf("%s (%.0f%% larger)", sizes, change)
+	default:
+		return sizes + " (same size)"
+	}
+}
+
+// loadPictures loads the front cover and, when set, the back cover for
+// embedding. The front cover is returned on its own, as callers report its
+// size; the back cover is returned as an extra picture.
+func loadPictures(req encodeRequest) (coverArtResult, []encoder.Picture, error) {
+	// The encoder now embeds the cover as an attached-picture stream during
+	// Initialize/Encode, so the scaled bytes must exist before Initialize. Scale
+	// up front rather than overlapping the encode; this drops the old
+	// scale/encode concurrency, but scaling is fast so the cost is negligible.
+	coverArtChan := make(chan coverArtResult, 1)
+	go func() {
+		coverArtChan <- loadCoverArt(req.UI, req.CoverArtPath, req.MaxCoverBytes, req.CoverScaler)
+	}()
+
+	coverResult := <-coverArtChan
+	if coverResult.err != nil {
+		return coverArtResult{}, nil, fmt.Errorf("failed to process cover art: %w", coverResult.err)
+	}
+	if req.MaxCoverBytes > 0 && coverResult.data != nil {
+		reportCoverBudget(req.UI, coverResult, req.MaxCoverBytes)
+	}
+
+	// The back cover is scaled like the front cover but is not held to the
+	// --max-cover-bytes budget, which is about the cover players display.
+	var pictures []encoder.Picture
+	if req.BackCoverPath != "" {
+		back := loadCoverArt(req.UI, req.BackCoverPath, 0, req.CoverScaler)
+		if back.err != nil {
+			return coverArtResult{}, nil, fmt.Errorf("failed to process back cover art: %w", back.err)
+		}
+		pictures = append(pictures, encoder.Picture{Type: encoder.PictureBackCover, Data: back.data})
+	}
+
+	return coverResult, pictures, nil
+}
+
+// encoderConfig builds the encoder configuration for req, embedding the
+// loaded cover and extra pictures.
+func encoderConfig(req encodeRequest, cover coverArtResult, pictures []encoder.Picture) encoder.Config {
+	return encoder.Config{
+		InputPath:        req.AudioFile,
+		OutputPath:       req.OutputPath,
+		Format:           req.Format,
+		Stereo:           req.Stereo,
+		Dither:           req.Dither,
+		Downmix:          req.Downmix,
+		Limit:            req.Limit,
+		LimitCeiling:     req.LimitCeiling,
+		ID3Version:       req.ID3Version,
+		CommentLanguage:  req.CommentLang,
+		MatchInputRate:   req.MatchInputRate,
+		Preview:          req.Preview,
+		NoGapless:        req.NoGapless,
+		IORetries:        req.IORetries,
+		Verbose:          req.Verbose,
+		DebugLog:         req.DebugLog,
+		AudioTrack:       req.AudioTrack,
+		Chapters:         req.Chapters,
+		Transcript:       req.Transcript,
+		CoverArt:         cover.data,
+		Pictures:         pictures,
+		CoverDescription: req.CoverDescription,
+		Metadata: encoder.Metadata{
+			EpisodeNumber: req.TagInfo.EpisodeNumber,
+			Title:         req.TagInfo.Title,
+			Artist:        req.TagInfo.Artist,
+			Album:         req.TagInfo.Album,
+			AlbumArtist:   req.TagInfo.AlbumArtist,
+			Date:          req.TagInfo.Date,
+			Comments:      req.TagInfo.Comments,
+			TitleFormat:   req.TitleFormat,
+			Explicit:      req.TagInfo.Explicit,
+		},
+	}
+}
+
+// encode orchestrates the full encoding pipeline: load the cover art, create
+// and initialise the encoder, report the plan, run the encode through
+// req.Encode, then write the cover and extract statistics. A nil stats with a
+// nil error means the output file was written but its statistics could not be
+// read. With req.EncodeOnly it returns straight after the encode, with no
+// statistics or completion summary.
+func encode(req encodeRequest) (encoder.EncodeResult, *encoder.FileStats, error) {
+	coverResult, pictures, err := loadPictures(req)
+	if err != nil {
+		return encoder.EncodeResult{}, nil, err
+	}
+
+	cfg := encoderConfig(req, coverResult, pictures)
+	// Progress displays redraw at 60fps, so more frequent progress is dropped anyway.
+	cfg.ProgressInterval = time.Second / 60
+	enc, err := encoder.New(cfg)
+	if err != nil {
+		return encoder.EncodeResult{}, nil, fmt.Errorf("failed to create encoder: %w", err)
+	}
+	defer enc.Close()
+
+	err = enc.Initialize()
+	reportFFmpegLog(req.UI, enc)
+	if err != nil {
+		return encoder.EncodeResult{}, nil, fmt.Errorf("failed to initialize encoder: %w", err)
+	}
+
+	if req.AudioTrack == 0 {
+		warnMultipleAudioTracks(req.UI, enc)
+	}
+	warnUpsample(req.UI, enc)
+
+	reportEncodePlan(req, enc)
+
+	// Initialize has already created the output file, so remove it on refusal.
+	warning, err := checkFreeSpace(freeDiskSpace(filepath.Dir(req.OutputPath)), enc.EstimatedOutputBytes())
+	if err != nil {
+		os.Remove(req.OutputPath)
+		return encoder.EncodeResult{}, nil, err
+	}
+	if warning != "" {
+		req.UI.Warning(warning)
+	}
+
+	runEncode := req.Encode
+	if runEncode == nil {
+		runEncode = encodeQuietly
+	}
+	result, err := runEncode(enc)
+	// Messages captured during the encode are held until it returns so they
+	// do not tear through a progress display.
+	reportFFmpegLog(req.UI, enc)
+	if err != nil {
+		// Discard the truncated output file so a cancelled or failed run
+		// leaves no partial file.
+		os.Remove(req.OutputPath)
+		return encoder.EncodeResult{}, nil, err
+	}
+	if req.EncodeOnly {
+		return result, nil, nil
+	}
+
+	if req.CoverOut != "" {
+		writeCoverOut(req.UI, req.CoverOut, coverResult.data)
+	}
+
+	stats, partial := embedMetadata(req, result)
+	if !partial {
+		rateMode := "CBR"
+		if enc.VBR() {
+			rateMode = "VBR"
+		}
+		audio := fmt.Sprintf("%s %dkbps %s, %s", enc.FormatLabel(), enc.Bitrate(), rateMode, enc.ChannelMode())
+		reportCompletionSummary(req, audio, stats, coverResult, result.Limited)
+	}
+
+	if req.SplitDuration > 0 {
+		if err := writeParts(req, coverResult, pictures, result.AudioDuration); err != nil {
+			return encoder.EncodeResult{}, nil, err
+		}
+	}
+	return result, stats, nil
+}
+
+// encodeQuietly is the EncodeFunc used when Options.Encode is nil: it encodes
+// without reporting progress.
+func encodeQuietly(enc *encoder.Encoder) (encoder.EncodeResult, error) {
+	result, err := enc.Encode(nil)
+	if err != nil {
+		return encoder.EncodeResult{}, fmt.Errorf("encoding failed: %w", err)
+	}
+	return result, nil
+}
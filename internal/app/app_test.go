@@ -0,0 +1,1294 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/linuxmatters/jivedrop/internal/encoder"
+)
+
+// TestSanitiseForFilename tests filename sanitisation for dangerous and special characters
+func TestSanitiseForFilename(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		// Basic cases
+		{
+			name:     "simple alphanumeric",
+			input:    "Linux Matters",
+			expected: "linux-matters",
+		},
+		// Special characters that should be removed
+		{
+			name:     "forward slash",
+			input:    "AC/DC",
+			expected: "acdc",
+		},
+		{
+			name:     "backslash",
+			input:    "Guns\\Roses",
+			expected: "gunsroses",
+		},
+		{
+			name:     "apostrophe",
+			input:    "Guns N' Roses",
+			expected: "guns-n-roses",
+		},
+		{
+			name:     "ampersand",
+			input:    "Tom & Jerry",
+			expected: "tom--jerry",
+		},
+		{
+			name:     "asterisk",
+			input:    "The *Clash*",
+			expected: "the-clash",
+		},
+		{
+			name:     "question mark",
+			input:    "Who?",
+			expected: "who",
+		},
+		{
+			name:     "exclamation mark",
+			input:    "Bang!",
+			expected: "bang",
+		},
+		// Unicode and non-ASCII characters
+		{
+			name:     "unicode umlauts stripped",
+			input:    "Björk",
+			expected: "bjrk",
+		},
+		{
+			name:     "accented characters stripped",
+			input:    "Café du Monde",
+			expected: "caf-du-monde",
+		},
+		{
+			name:     "chinese characters stripped",
+			input:    "Podcast 中文 Show",
+			expected: "podcast--show",
+		},
+		// Multiple special characters
+		{
+			name:     "multiple consecutive special chars",
+			input:    "Episode!!!???",
+			expected: "episode",
+		},
+		// Dots and underscores (preserved)
+		{
+			name:     "dots preserved",
+			input:    "Hello...World",
+			expected: "hello...world",
+		},
+		{
+			name:     "underscores preserved",
+			input:    "Hello_World",
+			expected: "hello_world",
+		},
+		{
+			name:     "mixed dots underscores hyphens",
+			input:    "Hello.World_Test-Case",
+			expected: "hello.world_test-case",
+		},
+		// Whitespace handling
+		{
+			name:     "leading and trailing spaces",
+			input:    "  Podcast Show  ",
+			expected: "--podcast-show--",
+		},
+		{
+			name:     "multiple spaces between words",
+			input:    "The   Podcast   Show",
+			expected: "the---podcast---show",
+		},
+		{
+			name:     "tabs and mixed whitespace",
+			input:    "Hello\tWorld",
+			expected: "helloworld",
+		},
+		// Edge cases
+		{
+			name:     "empty string",
+			input:    "",
+			expected: "",
+		},
+		{
+			name:     "only spaces",
+			input:    "   ",
+			expected: "---",
+		},
+		{
+			name:     "only special characters",
+			input:    "!!!???&&&",
+			expected: "",
+		},
+		{
+			name:     "numbers preserved",
+			input:    "Episode 42",
+			expected: "episode-42",
+		},
+		{
+			name:     "mixed case with numbers",
+			input:    "PoDCaSt 99 ShOw",
+			expected: "podcast-99-show",
+		},
+		// Real-world examples
+		{
+			name:     "linux matters real example",
+			input:    "Linux Matters",
+			expected: "linux-matters",
+		},
+		{
+			name:     "spotify podcast example",
+			input:    "The Daily Show with Trevor Noah",
+			expected: "the-daily-show-with-trevor-noah",
+		},
+		{
+			name:     "special music artist",
+			input:    "U2 / Bono",
+			expected: "u2--bono",
+		},
+		{
+			name:     "symbol-heavy artist",
+			input:    "$$$ (Money) $$$",
+			expected: "-money-",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := sanitiseForFilename(tt.input)
+			if result != tt.expected {
+				t.Errorf("sanitiseForFilename(%q) = %q; want %q", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+// TestGenerateFilename tests filename generation for both Hugo and Standalone modes
+func TestGenerateFilename(t *testing.T) {
+	tests := []struct {
+		name      string
+		mode      WorkflowMode
+		num       string
+		artist    string
+		cliArtist string // Simulates CLI.Artist global
+		ext       string
+		expected  string
+	}{
+		// Hugo mode - default behaviour
+		{
+			name:      "hugo default simple",
+			mode:      HugoMode,
+			num:       "67",
+			artist:    "",
+			cliArtist: "",
+			ext:       ".mp3",
+			expected:  "LMP67.mp3",
+		},
+		// Per-format extension
+		{
+			name:      "hugo default opus",
+			mode:      HugoMode,
+			num:       "67",
+			artist:    "",
+			cliArtist: "",
+			ext:       ".opus",
+			expected:  "LMP67.opus",
+		},
+		{
+			name:      "hugo default aac",
+			mode:      HugoMode,
+			num:       "67",
+			artist:    "",
+			cliArtist: "",
+			ext:       ".m4a",
+			expected:  "LMP67.m4a",
+		},
+		{
+			name:      "standalone artist opus",
+			mode:      StandaloneMode,
+			num:       "1",
+			artist:    "My Show",
+			cliArtist: "My Show",
+			ext:       ".opus",
+			expected:  "my-show-1.opus",
+		},
+		{
+			name:      "standalone fallback m4a",
+			mode:      StandaloneMode,
+			num:       "1",
+			artist:    "",
+			cliArtist: "",
+			ext:       ".m4a",
+			expected:  "episode-1.m4a",
+		},
+		{
+			name:      "hugo episode 0",
+			mode:      HugoMode,
+			num:       "0",
+			artist:    "",
+			cliArtist: "",
+			ext:       ".mp3",
+			expected:  "LMP0.mp3",
+		},
+		{
+			name:      "hugo large episode number",
+			mode:      HugoMode,
+			num:       "999",
+			artist:    "",
+			cliArtist: "",
+			ext:       ".mp3",
+			expected:  "LMP999.mp3",
+		},
+		// Hugo mode - custom artist override
+		{
+			name:      "hugo with custom artist override",
+			mode:      HugoMode,
+			num:       "67",
+			artist:    "Custom Podcast",
+			cliArtist: "Custom Podcast",
+			ext:       ".mp3",
+			expected:  "custom-podcast-67.mp3",
+		},
+		{
+			name:      "hugo with special chars in artist",
+			mode:      HugoMode,
+			num:       "42",
+			artist:    "The (Real) Show",
+			cliArtist: "The (Real) Show",
+			ext:       ".mp3",
+			expected:  "the-real-show-42.mp3",
+		},
+		// Hugo mode - Linux Matters default not triggered by override
+		{
+			name:      "hugo with linux matters artist keeps default",
+			mode:      HugoMode,
+			num:       "50",
+			artist:    "Linux Matters",
+			cliArtist: "Linux Matters",
+			ext:       ".mp3",
+			expected:  "LMP50.mp3",
+		},
+		{
+			name:      "hugo empty cli artist keeps default",
+			mode:      HugoMode,
+			num:       "55",
+			artist:    "Other",
+			cliArtist: "",
+			ext:       ".mp3",
+			expected:  "LMP55.mp3",
+		},
+		// Standalone mode - with artist
+		{
+			name:      "standalone with artist",
+			mode:      StandaloneMode,
+			num:       "1",
+			artist:    "My Show",
+			cliArtist: "My Show",
+			ext:       ".mp3",
+			expected:  "my-show-1.mp3",
+		},
+		{
+			name:      "standalone with artist and special chars",
+			mode:      StandaloneMode,
+			num:       "42",
+			artist:    "The Daily Show (Late Night)",
+			cliArtist: "The Daily Show (Late Night)",
+			ext:       ".mp3",
+			expected:  "the-daily-show-late-night-42.mp3",
+		},
+		{
+			name:      "standalone with multiple words",
+			mode:      StandaloneMode,
+			num:       "99",
+			artist:    "This Is A Very Long Podcast Name",
+			cliArtist: "This Is A Very Long Podcast Name",
+			ext:       ".mp3",
+			expected:  "this-is-a-very-long-podcast-name-99.mp3",
+		},
+		// Standalone mode - without artist (fallback to episode)
+		{
+			name:      "standalone without artist",
+			mode:      StandaloneMode,
+			num:       "1",
+			artist:    "",
+			cliArtist: "",
+			ext:       ".mp3",
+			expected:  "episode-1.mp3",
+		},
+		{
+			name:      "standalone without artist large number",
+			mode:      StandaloneMode,
+			num:       "42",
+			artist:    "",
+			cliArtist: "",
+			ext:       ".mp3",
+			expected:  "episode-42.mp3",
+		},
+		// Edge cases with numbers
+		{
+			name:      "episode number with leading zeros",
+			mode:      StandaloneMode,
+			num:       "007",
+			artist:    "James Bond",
+			cliArtist: "James Bond",
+			ext:       ".mp3",
+			expected:  "james-bond-007.mp3",
+		},
+		{
+			name:      "numeric artist name",
+			mode:      StandaloneMode,
+			num:       "5",
+			artist:    "99 Luftballons",
+			cliArtist: "99 Luftballons",
+			ext:       ".mp3",
+			expected:  "99-luftballons-5.mp3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := generateFilename(tt.mode, tt.num, tt.artist, tt.cliArtist, tt.ext)
+			if result != tt.expected {
+				t.Errorf("generateFilename(%v, %q, %q, %q, %q) = %q; want %q",
+					tt.mode, tt.num, tt.artist, tt.cliArtist, tt.ext, result, tt.expected)
+			}
+		})
+	}
+}
+
+// TestResolveOutputPath tests output path resolution with directories and files
+func TestResolveOutputPath(t *testing.T) {
+	tests := []struct {
+		name       string
+		outputPath string
+		mode       WorkflowMode
+		num        string
+		artist     string
+		cliArtist  string
+		ext        string
+		wantErr    bool
+		wantPath   string // Substring check for path validation
+	}{
+		// Empty output path - use current directory with generated filename
+		{
+			name:       "empty path uses generated filename",
+			outputPath: "",
+			mode:       HugoMode,
+			num:        "67",
+			artist:     "",
+			cliArtist:  "",
+			ext:        ".mp3",
+			wantErr:    false,
+			wantPath:   "LMP67.mp3",
+		},
+		{
+			name:       "empty path standalone mode",
+			outputPath: "",
+			mode:       StandaloneMode,
+			num:        "42",
+			artist:     "Test Show",
+			cliArtist:  "Test Show",
+			ext:        ".mp3",
+			wantErr:    false,
+			wantPath:   "test-show-42.mp3",
+		},
+		{
+			name:       "empty path opus extension",
+			outputPath: "",
+			mode:       HugoMode,
+			num:        "67",
+			artist:     "",
+			cliArtist:  "",
+			ext:        ".opus",
+			wantErr:    false,
+			wantPath:   "LMP67.opus",
+		},
+		// Existing directory - generate filename within it
+		{
+			name:       "existing directory",
+			outputPath: "", // Will be set to temp dir in test
+			mode:       StandaloneMode,
+			num:        "1",
+			artist:     "Show",
+			cliArtist:  "Show",
+			ext:        ".mp3",
+			wantErr:    false,
+			wantPath:   "show-1.mp3",
+		},
+		// Explicit file path - use as-is
+		{
+			name:       "explicit filename in current dir",
+			outputPath: "custom-output.mp3",
+			mode:       StandaloneMode,
+			num:        "1",
+			artist:     "ignored",
+			cliArtist:  "ignored",
+			ext:        ".mp3",
+			wantErr:    false,
+			wantPath:   "custom-output.mp3",
+		},
+		// File path in existing directory
+		{
+			name:       "file path in existing temp directory",
+			outputPath: "", // Will be set in test
+			mode:       HugoMode,
+			num:        "99",
+			artist:     "",
+			cliArtist:  "",
+			ext:        ".m4a",
+			wantErr:    false,
+			wantPath:   "LMP99.m4a",
+		},
+		// Error cases: non-existent directory
+		{
+			name:       "trailing slash non-existent directory",
+			outputPath: "/nonexistent/dir/",
+			mode:       StandaloneMode,
+			num:        "1",
+			artist:     "test",
+			cliArtist:  "test",
+			ext:        ".mp3",
+			wantErr:    true,
+			wantPath:   "",
+		},
+		{
+			name:       "file in non-existent directory",
+			outputPath: "/nonexistent/deeply/nested/path/file.mp3",
+			mode:       StandaloneMode,
+			num:        "1",
+			artist:     "test",
+			cliArtist:  "test",
+			ext:        ".mp3",
+			wantErr:    true,
+			wantPath:   "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Handle dynamic temp directory paths
+			testOutputPath := tt.outputPath
+			if tt.name == "existing directory" || tt.name == "file path in existing temp directory" {
+				tmpDir := t.TempDir()
+				testOutputPath = tmpDir
+				if tt.name == "existing directory" {
+					tt.wantPath = filepath.Join(testOutputPath, tt.wantPath)
+				}
+			}
+
+			result, err := resolveOutputPath(quietUI{}, generateFilename(tt.mode, tt.num, tt.artist, tt.cliArtist, tt.ext), testOutputPath, false)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("resolveOutputPath() expected error, got nil")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("resolveOutputPath() unexpected error: %v", err)
+				return
+			}
+
+			if tt.wantPath != "" && !isPathMatch(result, tt.wantPath) {
+				t.Errorf("resolveOutputPath() = %q; want path containing %q", result, tt.wantPath)
+			}
+		})
+	}
+}
+
+// TestResolveOutputPath_FileOverwrite tests file overwrite scenario
+func TestResolveOutputPath_FileOverwrite(t *testing.T) {
+	tmpDir := t.TempDir()
+	existingFile := filepath.Join(tmpDir, "existing.mp3")
+
+	if err := os.WriteFile(existingFile, []byte("dummy"), 0o644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	result, err := resolveOutputPath(quietUI{}, generateFilename(HugoMode, "1", "", "", ".mp3"), existingFile, false)
+	if err != nil {
+		t.Errorf("resolveOutputPath() with existing file: got unexpected error: %v", err)
+	}
+
+	if result != existingFile {
+		t.Errorf("resolveOutputPath() = %q; want %q", result, existingFile)
+	}
+}
+
+// TestResolveOutputPath_GeneratedFilenameInTempDir tests generated filename placed in temp directory
+func TestResolveOutputPath_GeneratedFilenameInTempDir(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	result, err := resolveOutputPath(quietUI{}, generateFilename(StandaloneMode, "42", "Test Show", "Test Show", ".mp3"), tmpDir, false)
+	if err != nil {
+		t.Errorf("resolveOutputPath() unexpected error: %v", err)
+	}
+
+	rel, relErr := filepath.Rel(tmpDir, result)
+	if relErr != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(os.PathSeparator)) {
+		t.Errorf("resolveOutputPath() = %q; not in temp directory %q", result, tmpDir)
+	}
+
+	if !isPathMatch(result, "test-show-42.mp3") {
+		t.Errorf("resolveOutputPath() = %q; want path containing 'test-show-42.mp3'", result)
+	}
+}
+
+// isPathMatch checks if a path contains the expected component
+// Handles both absolute and relative path matching
+func isPathMatch(fullPath, expected string) bool {
+	// Check if expected is at the end of the path (filename)
+	if filepath.Base(fullPath) == expected {
+		return true
+	}
+	// Check if expected is part of the path
+	return strings.Contains(fullPath, expected)
+}
+
+// TestDetectMode tests the CLI mode detection logic for Hugo vs Standalone workflows
+func TestDetectMode(t *testing.T) {
+	tests := []struct {
+		name      string
+		audioFile string
+		episodeMD string
+		expected  WorkflowMode
+	}{
+		// Empty audio file - no arguments provided
+		{
+			name:      "empty audio file",
+			audioFile: "",
+			episodeMD: "",
+			expected:  HugoMode, // Return value doesn't matter, exit will handle it
+		},
+
+		// Hugo mode: second argument is .md file
+		{
+			name:      "hugo mode with lowercase .md",
+			audioFile: "podcast.flac",
+			episodeMD: "episode.md",
+			expected:  HugoMode,
+		},
+		{
+			name:      "hugo mode with uppercase .MD",
+			audioFile: "podcast.flac",
+			episodeMD: "episode.MD",
+			expected:  HugoMode,
+		},
+		{
+			name:      "hugo mode with mixed case .Md",
+			audioFile: "podcast.flac",
+			episodeMD: "episode.Md",
+			expected:  HugoMode,
+		},
+		{
+			name:      "hugo mode with path containing .md",
+			audioFile: "podcast.flac",
+			episodeMD: "content/episodes/67.md",
+			expected:  HugoMode,
+		},
+		{
+			name:      "hugo mode with nested path and uppercase .MD",
+			audioFile: "audio.wav",
+			episodeMD: "posts/episode/post.MD",
+			expected:  HugoMode,
+		},
+		{
+			name:      "hugo mode with only filename .md",
+			audioFile: "LMP67.flac",
+			episodeMD: "67.md",
+			expected:  HugoMode,
+		},
+
+		// Standalone mode: second argument is NOT a .md file
+		{
+			name:      "standalone mode with .txt file",
+			audioFile: "podcast.flac",
+			episodeMD: "readme.txt",
+			expected:  StandaloneMode,
+		},
+		{
+			name:      "standalone mode with .md in middle of filename",
+			audioFile: "podcast.flac",
+			episodeMD: "markdown_file.mp3",
+			expected:  StandaloneMode,
+		},
+		{
+			name:      "standalone mode with .md not at end",
+			audioFile: "podcast.flac",
+			episodeMD: "file.md.txt",
+			expected:  StandaloneMode,
+		},
+		{
+			name:      "standalone mode with empty episodeMD string",
+			audioFile: "podcast.flac",
+			episodeMD: "",
+			expected:  StandaloneMode,
+		},
+		{
+			name:      "standalone mode with audio file only",
+			audioFile: "LMP67.flac",
+			episodeMD: "",
+			expected:  StandaloneMode,
+		},
+		{
+			name:      "standalone mode with non-md extension",
+			audioFile: "audio.wav",
+			episodeMD: "episode.yaml",
+			expected:  StandaloneMode,
+		},
+
+		// Edge cases
+		{
+			name:      "just .md (no filename before extension)",
+			audioFile: "podcast.flac",
+			episodeMD: ".md",
+			expected:  HugoMode,
+		},
+		{
+			name:      "filename with multiple dots ending in .md",
+			audioFile: "podcast.flac",
+			episodeMD: "my.episode.v2.md",
+			expected:  HugoMode,
+		},
+		{
+			name:      "filename with multiple dots not ending in .md",
+			audioFile: "podcast.flac",
+			episodeMD: "my.episode.v2.md.backup",
+			expected:  StandaloneMode,
+		},
+		{
+			name:      "markdown file with spaces",
+			audioFile: "podcast.flac",
+			episodeMD: "my episode file.md",
+			expected:  HugoMode,
+		},
+		{
+			name:      "episode md with special characters",
+			audioFile: "podcast.flac",
+			episodeMD: "episode-67_final.md",
+			expected:  HugoMode,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := detectMode(tt.audioFile, tt.episodeMD, autoMode)
+
+			if result != tt.expected {
+				t.Errorf("detectMode() = %v; want %v (AudioFile=%q, EpisodeMD=%q)",
+					result, tt.expected, tt.audioFile, tt.episodeMD)
+			}
+		})
+	}
+}
+
+// TestDetectMode_Integration tests detectMode in realistic scenarios
+func TestDetectMode_Integration(t *testing.T) {
+	tests := []struct {
+		name        string
+		audioFile   string
+		episodeMD   string
+		expected    WorkflowMode
+		description string
+	}{
+		{
+			name:        "real hugo workflow",
+			audioFile:   "LMP67.flac",
+			episodeMD:   "content/episodes/67.md",
+			expected:    HugoMode,
+			description: "User runs: jivedrop LMP67.flac content/episodes/67.md",
+		},
+		{
+			name:        "real standalone workflow",
+			audioFile:   "podcast.wav",
+			episodeMD:   "",
+			expected:    StandaloneMode,
+			description: "User runs: jivedrop podcast.wav --title 'Ep 1' --num 1 --cover art.png",
+		},
+		{
+			name:        "common mistake: user passes non-md file in hugo mode",
+			audioFile:   "episode.flac",
+			episodeMD:   "episode.txt",
+			expected:    StandaloneMode,
+			description: "User runs: jivedrop episode.flac episode.txt (should be .md not .txt)",
+		},
+		{
+			name:        "edge: .md file with uppercase extension",
+			audioFile:   "LMP99.flac",
+			episodeMD:   "99.MD",
+			expected:    HugoMode,
+			description: "Handles .MD (uppercase) correctly for cross-platform",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := detectMode(tt.audioFile, tt.episodeMD, autoMode)
+
+			if result != tt.expected {
+				t.Errorf("detectMode() = %v; want %v\n  Description: %s\n  AudioFile=%q, EpisodeMD=%q",
+					result, tt.expected, tt.description, tt.audioFile, tt.episodeMD)
+			}
+		})
+	}
+}
+
+// TestResolveModeFlags tests that --episode-md, --hugo and --standalone make
+// the mode explicit, and that conflicting combinations are rejected
+func TestResolveModeFlags(t *testing.T) {
+	tests := []struct {
+		name         string
+		positionalMD string
+		flagMD       string
+		hugo         bool
+		standalone   bool
+		wantMD       string
+		wantMode     WorkflowMode
+		wantErr      bool
+	}{
+		{name: "nothing explicit", positionalMD: "67.md", wantMD: "67.md", wantMode: autoMode},
+		{name: "episode-md flag", flagMD: "67.md", wantMD: "67.md", wantMode: HugoMode},
+		{name: "hugo override", positionalMD: "notes.txt", hugo: true, wantMD: "notes.txt", wantMode: HugoMode},
+		{name: "standalone override", positionalMD: "show-notes.md", standalone: true, wantMD: "show-notes.md", wantMode: StandaloneMode},
+		{name: "markdown given twice", positionalMD: "67.md", flagMD: "68.md", wantErr: true},
+		{name: "episode-md with standalone", flagMD: "67.md", standalone: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			md, mode, err := resolveModeFlags(tt.positionalMD, tt.flagMD, tt.hugo, tt.standalone)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("resolveModeFlags() expected error, got %q, %v", md, mode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveModeFlags() unexpected error: %v", err)
+			}
+			if md != tt.wantMD || mode != tt.wantMode {
+				t.Errorf("resolveModeFlags() = %q, %v; want %q, %v", md, mode, tt.wantMD, tt.wantMode)
+			}
+		})
+	}
+}
+
+// TestDetectMode_Explicit tests that an explicit mode beats the .md heuristic
+func TestDetectMode_Explicit(t *testing.T) {
+	if got := detectMode("podcast.flac", "show-notes.md", StandaloneMode); got != StandaloneMode {
+		t.Errorf("detectMode() with --standalone = %v; want StandaloneMode", got)
+	}
+	if got := detectMode("podcast.flac", "", HugoMode); got != HugoMode {
+		t.Errorf("detectMode() with --hugo = %v; want HugoMode", got)
+	}
+}
+
+// BenchmarkSanitiseForFilename benchmarks the sanitisation function
+func BenchmarkSanitiseForFilename(b *testing.B) {
+	testStrings := []string{
+		"Linux Matters",
+		"AC/DC",
+		"The (Real) Show",
+		"Podcast!!!???&&&",
+		"Very Long Podcast Name With Many Words",
+	}
+
+	b.ResetTimer()
+	for b.Loop() {
+		for _, s := range testStrings {
+			sanitiseForFilename(s)
+		}
+	}
+}
+
+// BenchmarkGenerateFilename benchmarks the filename generation
+func BenchmarkGenerateFilename(b *testing.B) {
+	b.ResetTimer()
+	for b.Loop() {
+		generateFilename(HugoMode, "67", "", "Linux Matters", ".mp3")
+		generateFilename(StandaloneMode, "42", "My Podcast", "My Podcast", ".mp3")
+		generateFilename(StandaloneMode, "1", "", "", ".mp3")
+	}
+}
+
+// TestResolveOutputPath_Mkdir verifies that --mkdir creates a missing output
+// directory, both for a trailing-slash directory path and for the parent of a
+// file path, and that a path through an existing file still errors.
+func TestResolveOutputPath_Mkdir(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	dirPath := filepath.Join(tmpDir, "new-show", "season-1") + "/"
+	result, err := resolveOutputPath(quietUI{}, generateFilename(StandaloneMode, "1", "Show", "Show", ".mp3"), dirPath, true)
+	if err != nil {
+		t.Fatalf("resolveOutputPath() with --mkdir directory: %v", err)
+	}
+	if want := filepath.Join(dirPath, "show-1.mp3"); result != want {
+		t.Errorf("resolveOutputPath() = %q; want %q", result, want)
+	}
+	if stat, err := os.Stat(dirPath); err != nil || !stat.IsDir() {
+		t.Errorf("directory %s was not created", dirPath)
+	}
+
+	filePath := filepath.Join(tmpDir, "other", "episode.mp3")
+	result, err = resolveOutputPath(quietUI{}, generateFilename(StandaloneMode, "1", "", "", ".mp3"), filePath, true)
+	if err != nil {
+		t.Fatalf("resolveOutputPath() with --mkdir file: %v", err)
+	}
+	if result != filePath {
+		t.Errorf("resolveOutputPath() = %q; want %q", result, filePath)
+	}
+	if stat, err := os.Stat(filepath.Dir(filePath)); err != nil || !stat.IsDir() {
+		t.Errorf("parent directory of %s was not created", filePath)
+	}
+
+	blocker := filepath.Join(tmpDir, "blocker")
+	if err := os.WriteFile(blocker, nil, 0o644); err != nil {
+		t.Fatalf("failed to create blocker file: %v", err)
+	}
+	if _, err := resolveOutputPath(quietUI{}, generateFilename(StandaloneMode, "1", "", "", ".mp3"), filepath.Join(blocker, "episode.mp3"), true); err == nil {
+		t.Error("resolveOutputPath() through a file: expected error, got nil")
+	}
+}
+
+// TestResolveOutputDir verifies --output-dir always names a directory: the
+// generated filename goes inside it, a missing one is created only with
+// --mkdir, and a file in its place is an error.
+func TestResolveOutputDir(t *testing.T) {
+	tmpDir := t.TempDir()
+	filename := generateFilename(StandaloneMode, "1", "Show", "Show", ".mp3")
+
+	// A path that looks like a file name is still taken as a directory.
+	dir := filepath.Join(tmpDir, "episode.mp3")
+	if _, err := resolveOutputDir(quietUI{}, filename, dir, false); err == nil {
+		t.Error("resolveOutputDir() with a missing directory: expected error, got nil")
+	}
+	result, err := resolveOutputDir(quietUI{}, filename, dir, true)
+	if err != nil {
+		t.Fatalf("resolveOutputDir() with --mkdir: %v", err)
+	}
+	if want := filepath.Join(dir, "show-1.mp3"); result != want {
+		t.Errorf("resolveOutputDir() = %q; want %q", result, want)
+	}
+	if stat, err := os.Stat(dir); err != nil || !stat.IsDir() {
+		t.Errorf("directory %s was not created", dir)
+	}
+
+	file := filepath.Join(tmpDir, "notes.txt")
+	if err := os.WriteFile(file, nil, 0o644); err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+	if _, err := resolveOutputDir(quietUI{}, filename, file, true); err == nil {
+		t.Error("resolveOutputDir() on a file: expected error, got nil")
+	}
+}
+
+// TestCheckOutputWritable verifies the pre-flight probe passes for a writable
+// directory without leaving anything behind, and fails for a read-only
+// directory or output file.
+func TestCheckOutputWritable(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	if err := checkOutputWritable(filepath.Join(tmpDir, "episode.mp3")); err != nil {
+		t.Fatalf("checkOutputWritable() on a writable directory: %v", err)
+	}
+	if entries, _ := os.ReadDir(tmpDir); len(entries) != 0 {
+		t.Errorf("probe left %d files behind", len(entries))
+	}
+
+	if os.Geteuid() == 0 {
+		t.Skip("permission checks do not apply to root")
+	}
+
+	readOnlyFile := filepath.Join(tmpDir, "locked.mp3")
+	if err := os.WriteFile(readOnlyFile, []byte("old"), 0o444); err != nil {
+		t.Fatalf("failed to create read-only file: %v", err)
+	}
+	if err := checkOutputWritable(readOnlyFile); err == nil || !strings.Contains(err.Error(), "output file is not writable") {
+		t.Errorf("checkOutputWritable() on a read-only file: got %v", err)
+	}
+
+	readOnlyDir := filepath.Join(tmpDir, "readonly")
+	if err := os.Mkdir(readOnlyDir, 0o555); err != nil {
+		t.Fatalf("failed to create read-only directory: %v", err)
+	}
+	if err := checkOutputWritable(filepath.Join(readOnlyDir, "episode.mp3")); err == nil || !strings.Contains(err.Error(), "output directory is not writable") {
+		t.Errorf("checkOutputWritable() in a read-only directory: got %v", err)
+	}
+}
+
+// TestWriteCoverOut verifies --cover-out writes the scaled cover bytes as-is
+// and writes nothing when there is no cover.
+func TestWriteCoverOut(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	path := filepath.Join(tmpDir, "cover.png")
+	artwork := []byte("\x89PNG\r\n\x1a\nscaled")
+	writeCoverOut(quietUI{}, path, artwork)
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("cover art not written: %v", err)
+	}
+	if string(got) != string(artwork) {
+		t.Errorf("cover art = %q; want %q", got, artwork)
+	}
+
+	empty := filepath.Join(tmpDir, "empty.png")
+	writeCoverOut(quietUI{}, empty, nil)
+	if _, err := os.Stat(empty); !os.IsNotExist(err) {
+		t.Errorf("expected no file without cover art, got stat error %v", err)
+	}
+}
+
+// TestPreserveModTime verifies --preserve-mtime gives the output the input's
+// modification time.
+func TestPreserveModTime(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "episode.mp3")
+	if err := os.WriteFile(path, []byte("audio"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	recorded := time.Date(2024, 3, 14, 19, 30, 0, 0, time.UTC)
+	preserveModTime(quietUI{}, path, recorded)
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.ModTime().Equal(recorded) {
+		t.Errorf("modification time = %v, want %v", info.ModTime(), recorded)
+	}
+}
+
+func TestSizeComparison(t *testing.T) {
+	const mib = 1 << 20
+
+	tests := []struct {
+		name   string
+		input  int64
+		output int64
+		want   string
+	}{
+		{name: "smaller", input: 280 * mib, output: 26 * mib, want: "Input: 280.0 MiB → Output: 26.0 MiB (91% smaller)"},
+		{name: "larger", input: 10 * mib, output: 12 * mib, want: "Input: 10.0 MiB → Output: 12.0 MiB (20% larger)"},
+		{name: "same", input: 10 * mib, output: 10 * mib, want: "Input: 10.0 MiB → Output: 10.0 MiB (same size)"},
+		{name: "empty input", input: 0, output: 512, want: "Input: 0 B → Output: 512 B"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sizeComparison(tt.input, tt.output); got != tt.want {
+				t.Errorf("sizeComparison(%d, %d) = %q, want %q", tt.input, tt.output, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDescribeInput(t *testing.T) {
+	tests := []struct {
+		name string
+		info encoder.InputInfo
+		want string
+	}{
+		{name: "24-bit FLAC", info: encoder.InputInfo{Codec: "flac", SampleRate: 48000, Channels: 2, BitDepth: 24, SampleFmt: "s32"}, want: "flac 48000㎐ stereo, 24-bit (s32)"},
+		{name: "float without depth", info: encoder.InputInfo{Codec: "mp3float", SampleRate: 44100, Channels: 1, SampleFmt: "fltp"}, want: "mp3float 44100㎐ mono, fltp"},
+		{name: "depth only", info: encoder.InputInfo{Codec: "pcm_s16le", SampleRate: 44100, Channels: 2, BitDepth: 16}, want: "pcm_s16le 44100㎐ stereo, 16-bit"},
+		{name: "neither", info: encoder.InputInfo{Codec: "aac", SampleRate: 44100, Channels: 2}, want: "aac 44100㎐ stereo"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := describeInput(tt.info); got != tt.want {
+				t.Errorf("describeInput() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestReadTranscript verifies a transcript is read, needs timed lines, and
+// has its SYLT frame held to the size cap.
+func TestReadTranscript(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	cues, err := readTranscript(write("ok.txt", "[00:00] Hello\n[00:04] World\n"))
+	if err != nil || len(cues) != 2 {
+		t.Fatalf("readTranscript() = %v, %v; want two cues", cues, err)
+	}
+	if lines, err := transcriptLines(quietUI{}, cues, 0); err != nil || len(lines) != 2 {
+		t.Errorf("transcriptLines() = %v, %v; want two lines", lines, err)
+	}
+
+	if _, err := readTranscript(write("empty.vtt", "WEBVTT\n\nNOTE nothing yet\n")); err == nil || !strings.Contains(err.Error(), "no timed lines") {
+		t.Errorf("readTranscript() without cues error = %v, want no timed lines", err)
+	}
+
+	huge, err := readTranscript(write("huge.txt", strings.Repeat("[00:00] "+strings.Repeat("x", 1000)+"\n", encoder.MaxTranscriptBytes/1000)))
+	if err != nil {
+		t.Fatalf("readTranscript() of a long transcript: %v", err)
+	}
+	if _, err := transcriptLines(quietUI{}, huge, 0); err == nil || !strings.Contains(err.Error(), "too large") {
+		t.Errorf("transcriptLines() over the cap error = %v, want too large", err)
+	}
+}
+
+func TestLimiterSummary(t *testing.T) {
+	if got, want := limiterSummary(-1, true), "engaged, peaks above -1dB were reduced"; got != want {
+		t.Errorf("limiterSummary(-1, true) = %q, want %q", got, want)
+	}
+	if got, want := limiterSummary(-1.5, false), "not engaged, no peaks above -1.5dB"; got != want {
+		t.Errorf("limiterSummary(-1.5, false) = %q, want %q", got, want)
+	}
+}
+
+func TestFailFor(t *testing.T) {
+	_, statErr := os.Stat("/nonexistent/episode.md")
+
+	tests := []struct {
+		name     string
+		err      error
+		fallback ErrorKind
+		want     ErrorKind
+	}{
+		{name: "missing file", err: statErr, fallback: KindUsage, want: KindInputNotFound},
+		{name: "wrapped missing file", err: fmt.Errorf("cover art not accessible: %w", statErr), fallback: KindEncode, want: KindInputNotFound},
+		{name: "disk full", err: fmt.Errorf("%w: the output needs about 1.0 GiB", ErrInsufficientSpace), fallback: KindEncode, want: KindIO},
+		{name: "other error", err: errors.New("standalone mode requires --num flag"), fallback: KindUsage, want: KindUsage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var runErr *Error
+			if !errors.As(failFor(tt.err, tt.fallback), &runErr) || runErr.Kind != tt.want {
+				t.Errorf("failFor() kind = %v, want %v", runErr, tt.want)
+			}
+		})
+	}
+}
+
+// TestRunErrors checks that Run fails before any encode, with the right kind,
+// for problems it can see up front.
+func TestRunErrors(t *testing.T) {
+	dir := t.TempDir()
+	audio := filepath.Join(dir, "episode.flac")
+	if err := os.WriteFile(audio, []byte("not audio"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		opts Options
+		want ErrorKind
+	}{
+		{name: "missing audio", opts: Options{AudioFile: filepath.Join(dir, "missing.flac")}, want: KindInputNotFound},
+		{name: "conflicting markdown", opts: Options{AudioFile: audio, EpisodeMD: "a.md", MarkdownFile: "b.md"}, want: KindUsage},
+		{name: "retag to opus", opts: Options{AudioFile: audio, Format: "opus", MetadataOnly: true}, want: KindUsage},
+		{name: "retag with limiter", opts: Options{AudioFile: audio, MetadataOnly: true, Limit: true}, want: KindUsage},
+		{name: "two chapter sources", opts: Options{AudioFile: audio, Chapters: "chapters.txt", AutoChapters: true}, want: KindUsage},
+		{name: "cue with chapters", opts: Options{AudioFile: audio, Chapters: "chapters.txt", Cue: "episode.cue"}, want: KindUsage},
+		{name: "chapters JSON without chapters", opts: Options{AudioFile: audio, ChaptersJSON: true}, want: KindUsage},
+		{name: "transcript chapters without transcript", opts: Options{AudioFile: audio, TranscriptChapters: 5 * time.Minute}, want: KindUsage},
+		{name: "transcript chapters with chapters", opts: Options{AudioFile: audio, Transcript: "episode.vtt", Chapters: "chapters.txt", TranscriptChapters: 5 * time.Minute}, want: KindUsage},
+		{name: "transcript outside MP3", opts: Options{AudioFile: audio, Format: "opus", Transcript: "episode.vtt"}, want: KindUsage},
+		{name: "bad comment language", opts: Options{AudioFile: audio, CommentLang: "english"}, want: KindUsage},
+		{name: "output path and directory", opts: Options{AudioFile: audio, OutputPath: "episode.mp3", OutputDir: "out"}, want: KindUsage},
+		{name: "rss without base URL", opts: Options{AudioFile: audio, RSS: "-"}, want: KindUsage},
+		{name: "negative preview", opts: Options{AudioFile: audio, Preview: -time.Second}, want: KindUsage},
+		{name: "sub-second preview", opts: Options{AudioFile: audio, Preview: 500 * time.Millisecond}, want: KindUsage},
+		{name: "preview with retag", opts: Options{AudioFile: audio, MetadataOnly: true, Preview: 30 * time.Second}, want: KindUsage},
+		{name: "preview with parts", opts: Options{AudioFile: audio, Format: "mp3", SplitDuration: time.Hour, Preview: 30 * time.Second}, want: KindUsage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Run(tt.opts)
+			var runErr *Error
+			if !errors.As(err, &runErr) {
+				t.Fatalf("Run() error = %v, want an *Error", err)
+			}
+			if runErr.Kind != tt.want {
+				t.Errorf("Run() kind = %d (%v), want %d", runErr.Kind, err, tt.want)
+			}
+		})
+	}
+}
+
+// TestCheckFlags_Preview verifies a --preview shorter than the encoder's
+// minimum input is refused up front, rather than failing the encode as
+// too short once decoding stops at the preview length.
+func TestCheckFlags_Preview(t *testing.T) {
+	tests := []struct {
+		name    string
+		preview time.Duration
+		wantErr string
+	}{
+		{name: "no preview", preview: 0},
+		{name: "half a second", preview: 500 * time.Millisecond, wantErr: "invalid --preview 500ms: must be at least 1s"},
+		{name: "exactly the minimum", preview: encoder.MinInputDuration},
+		{name: "thirty seconds", preview: 30 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkFlags(Options{Format: "mp3", Preview: tt.preview})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("checkFlags() unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("checkFlags() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestRunHugo_Integration runs the whole Hugo workflow on a Hugo-like site in
+// a temporary directory: frontmatter metadata, a cover under static/, an MP3
+// encode of the test FLAC, and the frontmatter update.
+func TestRunHugo_Integration(t *testing.T) {
+	audioPath := "../../testdata/LMP0.flac"
+	if _, err := os.Stat(audioPath); os.IsNotExist(err) {
+		t.Skipf("Test file not found: %s", audioPath)
+	}
+	cover, err := os.ReadFile("../../testdata/linuxmatters-3000x3000.png")
+	if err != nil {
+		t.Skipf("Test cover not found: %v", err)
+	}
+
+	site := t.TempDir()
+	for _, dir := range []string{"static/img", "content/episodes", "public"} {
+		if err := os.MkdirAll(filepath.Join(site, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(site, "static/img/cover.png"), cover, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mdPath := filepath.Join(site, "content/episodes/67.md")
+	markdown := `---
+title: "Terminal Velocity"
+Date: 2025-11-04T10:00:00Z
+episode: "67"
+episode_image: "/img/cover.png"
+---
+
+Show notes.
+`
+	if err := os.WriteFile(mdPath, []byte(markdown), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	outputDir := filepath.Join(site, "public")
+	result, err := Run(Options{
+		AudioFile:  audioPath,
+		EpisodeMD:  mdPath,
+		OutputPath: outputDir,
+		Episode:    EpisodeOptions{Yes: true},
+	})
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+
+	wantOutput := filepath.Join(outputDir, "LMP67.mp3")
+	if result.Mode != HugoMode || result.OutputPath != wantOutput {
+		t.Fatalf("Run() = mode %v, output %q; want Hugo mode, %q", result.Mode, result.OutputPath, wantOutput)
+	}
+	if result.Stats == nil {
+		t.Fatal("Run() returned no file statistics")
+	}
+
+	// The muxer writes ID3v2.4 frames, and the comment is rewritten from the
+	// muxer's TXXX frame to COMM once the trailer is written.
+	frames := id3FrameIDs(t, wantOutput)
+	for _, frame := range []string{"TIT2", "TPE1", "TPE2", "TALB", "TRCK", "TDRC", "COMM", "APIC"} {
+		if !slices.Contains(frames, frame) {
+			t.Errorf("ID3 tag has no %s frame (frames %q)", frame, frames)
+		}
+	}
+
+	tags, err := encoder.ProbeInputMetadata(wantOutput)
+	if err != nil {
+		t.Fatalf("ProbeInputMetadata() error: %v", err)
+	}
+	wantTags := map[string]string{
+		"title":  "67: Terminal Velocity",
+		"artist": HugoDefaultArtist,
+		"album":  HugoDefaultArtist,
+		"track":  "67",
+		"date":   "2025-11",
+	}
+	for key, want := range wantTags {
+		if got := tags[key]; got != want {
+			t.Errorf("%s tag = %q, want %q", key, got, want)
+		}
+	}
+
+	updated, err := os.ReadFile(mdPath)
+	if err != nil {
+		t.Fatalf("failed to read markdown: %v", err)
+	}
+	// The duration follows the quoting style of the other frontmatter values.
+	for _, pattern := range []string{
+		`(?m)^podcast_duration: "?` + regexp.QuoteMeta(result.Stats.DurationString) + `"?$`,
+		fmt.Sprintf(`(?m)^podcast_bytes: %d$`, result.Stats.FileSizeBytes),
+	} {
+		if !regexp.MustCompile(pattern).Match(updated) {
+			t.Errorf("frontmatter does not match %s:\n%s", pattern, updated)
+		}
+	}
+	if !strings.Contains(string(updated), "Show notes.") {
+		t.Error("frontmatter update lost the markdown body")
+	}
+}
+
+// id3FrameIDs returns the IDs of the frames in the ID3v2.4 tag at the start
+// of the file at path, walking them by their sizes so that nothing in a frame
+// body, such as the cover art, is taken for a frame.
+func id3FrameIDs(t *testing.T, path string) []string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+	if len(data) < 10 || string(data[:3]) != "ID3" || data[3] != 4 {
+		t.Fatal("output does not start with an ID3v2.4 tag")
+	}
+	synchsafe := func(b []byte) int { return int(b[0])<<21 | int(b[1])<<14 | int(b[2])<<7 | int(b[3]) }
+	tagSize := synchsafe(data[6:10])
+	if 10+tagSize > len(data) {
+		t.Fatalf("ID3 tag size %d overruns the file", tagSize)
+	}
+	tag := data[10 : 10+tagSize]
+
+	var ids []string
+	for pos := 0; pos+10 <= len(tag) && tag[pos] != 0; {
+		size := synchsafe(tag[pos+4 : pos+8])
+		if pos+10+size > len(tag) {
+			t.Fatalf("ID3 frame %s overruns the tag", tag[pos:pos+4])
+		}
+		ids = append(ids, string(tag[pos:pos+4]))
+		pos += 10 + size
+	}
+	return ids
+}
@@ -0,0 +1,33 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/linuxmatters/jivedrop/internal/encoder"
+)
+
+// ErrInsufficientSpace marks a refusal to encode because the output
+// filesystem cannot hold the estimated output.
+var ErrInsufficientSpace = errors.New("not enough free disk space")
+
+// checkFreeSpace compares the free space on the output filesystem with the
+// estimated output size. Less than the estimate is an error, since the encode
+// would fail near the end; less than the estimate plus a quarter returns a
+// warning, as the estimate is approximate. A zero estimate or free value,
+// meaning unknown, passes.
+func checkFreeSpace(free, estimate int64) (warning string, err error) {
+	if free <= 0 || estimate <= 0 {
+		return "", nil
+	}
+
+	switch {
+	case free < estimate:
+		return "", fmt.Errorf("%w: the output needs about %s but only %s is free",
+			ErrInsufficientSpace, encoder.FormatBytes(estimate), encoder.FormatBytes(free))
+	case free < estimate+estimate/4:
+		return fmt.Sprintf("Low disk space: the output needs about %s and only %s is free",
+			encoder.FormatBytes(estimate), encoder.FormatBytes(free)), nil
+	}
+	return "", nil
+}
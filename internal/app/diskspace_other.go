@@ -0,0 +1,8 @@
+//go:build !unix
+
+package app
+
+// freeDiskSpace returns 0, meaning unknown, where statfs is unavailable.
+func freeDiskSpace(string) int64 {
+	return 0
+}
@@ -0,0 +1,53 @@
+package app
+
+import (
+	"errors"
+	"runtime"
+	"testing"
+)
+
+func TestCheckFreeSpace(t *testing.T) {
+	const mib = 1 << 20
+
+	tests := []struct {
+		name     string
+		free     int64
+		estimate int64
+		wantWarn bool
+		wantErr  bool
+	}{
+		{name: "plenty", free: 1000 * mib, estimate: 50 * mib},
+		{name: "within margin warns", free: 55 * mib, estimate: 50 * mib, wantWarn: true},
+		{name: "exactly enough", free: 50 * mib, estimate: 50 * mib, wantWarn: true},
+		{name: "too little", free: 49 * mib, estimate: 50 * mib, wantErr: true},
+		{name: "unknown free", free: 0, estimate: 50 * mib},
+		{name: "unknown estimate", free: 1 * mib, estimate: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warning, err := checkFreeSpace(tt.free, tt.estimate)
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("checkFreeSpace(%d, %d) error = %v, wantErr %v", tt.free, tt.estimate, err, tt.wantErr)
+			}
+			if tt.wantWarn != (warning != "") {
+				t.Errorf("checkFreeSpace(%d, %d) warning = %q, want warning %v", tt.free, tt.estimate, warning, tt.wantWarn)
+			}
+			if err != nil && !errors.Is(err, ErrInsufficientSpace) {
+				t.Errorf("error %v does not wrap ErrInsufficientSpace", err)
+			}
+		})
+	}
+}
+
+func TestFreeDiskSpace(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("free space is not queried on Windows")
+	}
+	if free := freeDiskSpace(t.TempDir()); free <= 0 {
+		t.Errorf("freeDiskSpace(TempDir) = %d, want > 0", free)
+	}
+	if free := freeDiskSpace("/nonexistent/dir"); free != 0 {
+		t.Errorf("freeDiskSpace(missing) = %d, want 0", free)
+	}
+}
@@ -0,0 +1,15 @@
+//go:build unix
+
+package app
+
+import "syscall"
+
+// freeDiskSpace returns the bytes available to this user on the filesystem
+// holding dir, or 0 when it cannot be queried.
+func freeDiskSpace(dir string) int64 {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(dir, &st); err != nil {
+		return 0
+	}
+	return int64(st.Bavail) * int64(st.Bsize) //nolint:gosec,unconvert // field types differ between platforms
+}
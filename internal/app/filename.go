@@ -0,0 +1,123 @@
+package app
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/linuxmatters/jivedrop/internal/encoder"
+)
+
+// filenameFields are the values a filename template's placeholders expand to.
+type filenameFields struct {
+	Num    string
+	Title  string
+	Artist string
+	Date   string
+}
+
+// filenamePlaceholder matches any {name} in a filename template, so unknown
+// placeholders can be reported rather than left in the filename.
+var filenamePlaceholder = regexp.MustCompile(`\{[^{}]*\}`)
+
+// audioExtensions are the extensions a template may end with; they are
+// replaced by the extension of the chosen format.
+var audioExtensions = []string{
+	encoder.ExtensionFor("mp3"),
+	encoder.ExtensionFor("aac"),
+	encoder.ExtensionFor("m4b"),
+	encoder.ExtensionFor("opus"),
+}
+
+// defaultFilenameTemplate returns the template behind the default output
+// filename: LMP{num} in Hugo mode unless --artist overrides the show,
+// {artist}-{num} in standalone mode, or episode-{num} without an artist.
+func defaultFilenameTemplate(mode WorkflowMode, artist, cliArtist string) string {
+	if mode == HugoMode {
+		if cliArtist != "" && cliArtist != HugoDefaultArtist {
+			return "{artist}-{num}"
+		}
+		return "{prefix}{num}"
+	}
+	if artist != "" {
+		return "{artist}-{num}"
+	}
+	return "episode-{num}"
+}
+
+// expandFilenameTemplate fills in a --filename-template and appends ext. The
+// placeholders are {num}, {title}, {artist}, {date}, {slug} (the title with
+// runs of punctuation collapsed to single hyphens) and {prefix} (the Hugo
+// show prefix, LMP). Metadata values pass through sanitiseForFilename; the
+// template's own text is kept as written. A trailing audio extension in the
+// template is replaced with ext, so one template serves every format.
+func expandFilenameTemplate(template string, fields filenameFields, ext string) (string, error) {
+	if strings.ContainsAny(template, `/\`) {
+		return "", fmt.Errorf("invalid filename template %q: must not contain a path separator (use --output-dir for the directory)", template)
+	}
+
+	for _, audioExt := range audioExtensions {
+		if strings.HasSuffix(strings.ToLower(template), audioExt) {
+			template = template[:len(template)-len(audioExt)]
+			break
+		}
+	}
+
+	values := map[string]string{
+		"{num}":    sanitiseForFilename(fields.Num),
+		"{title}":  sanitiseForFilename(fields.Title),
+		"{artist}": sanitiseForFilename(fields.Artist),
+		"{date}":   sanitiseForFilename(fields.Date),
+		"{slug}":   slugify(fields.Title),
+		"{prefix}": HugoDefaultPrefix,
+	}
+	var unknown string
+	stem := filenamePlaceholder.ReplaceAllStringFunc(template, func(placeholder string) string {
+		value, ok := values[placeholder]
+		if !ok && unknown == "" {
+			unknown = placeholder
+		}
+		return value
+	})
+	if unknown != "" {
+		return "", fmt.Errorf("invalid filename template %q: unknown placeholder %s (use {num}, {title}, {artist}, {date}, {slug} or {prefix})", template, unknown)
+	}
+
+	if strings.Trim(stem, ".-_ ") == "" {
+		return "", fmt.Errorf("filename template %q gives an empty filename for this episode", template)
+	}
+	return stem + ext, nil
+}
+
+// slugify turns a title into a URL-style slug: lowercase letters and digits
+// joined by single hyphens.
+func slugify(s string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(s) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if hyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			hyphen = false
+			continue
+		}
+		hyphen = true
+	}
+	return b.String()
+}
+
+// previewPath names a --preview clip after the output it stands in for, with
+// -preview before the extension, so it never overwrites a finished episode.
+func previewPath(outputPath string) string {
+	ext := filepath.Ext(outputPath)
+	return strings.TrimSuffix(outputPath, ext) + "-preview" + ext
+}
+
+// chaptersJSONPath names the --chapters-json sidecar after the output, with
+// .chapters.json in place of the audio extension.
+func chaptersJSONPath(outputPath string) string {
+	return strings.TrimSuffix(outputPath, filepath.Ext(outputPath)) + ".chapters.json"
+}
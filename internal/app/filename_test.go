@@ -0,0 +1,82 @@
+package app
+
+import "testing"
+
+func TestExpandFilenameTemplate(t *testing.T) {
+	fields := filenameFields{Num: "67", Title: "Terminal Velocity: The Return!", Artist: "Linux Matters", Date: "2025-11"}
+
+	tests := []struct {
+		name     string
+		template string
+		ext      string
+		want     string
+		wantErr  bool
+	}{
+		{name: "date slug and number", template: "{date}-{slug}-ep{num}", ext: ".mp3", want: "2025-11-terminal-velocity-the-return-ep67.mp3"},
+		{name: "template extension replaced", template: "{date}-{slug}-ep{num}.mp3", ext: ".opus", want: "2025-11-terminal-velocity-the-return-ep67.opus"},
+		{name: "prefix kept as written", template: "{prefix}{num}", ext: ".m4a", want: "LMP67.m4a"},
+		{name: "artist and title sanitised", template: "{artist}_{title}", ext: ".mp3", want: "linux-matters_terminal-velocity-the-return.mp3"},
+		{name: "literal text kept", template: "Show {num}", ext: ".mp3", want: "Show 67.mp3"},
+		{name: "unknown placeholder", template: "{num}-{genre}", ext: ".mp3", wantErr: true},
+		{name: "path separator", template: "episodes/{num}", ext: ".mp3", wantErr: true},
+		{name: "empty stem", template: "-_-", ext: ".mp3", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expandFilenameTemplate(tt.template, fields, tt.ext)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expandFilenameTemplate(%q) = %q, want error", tt.template, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expandFilenameTemplate(%q) unexpected error: %v", tt.template, err)
+			}
+			if got != tt.want {
+				t.Errorf("expandFilenameTemplate(%q) = %q, want %q", tt.template, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSlugify(t *testing.T) {
+	tests := map[string]string{
+		"Terminal Velocity":       "terminal-velocity",
+		"  What's new in 6.18?  ": "what-s-new-in-6-18",
+		"--Already--slugged--":    "already-slugged",
+		"":                        "",
+	}
+	for in, want := range tests {
+		if got := slugify(in); got != want {
+			t.Errorf("slugify(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestPreviewPath(t *testing.T) {
+	tests := map[string]string{
+		"LMP67.mp3":                "LMP67-preview.mp3",
+		"/srv/episodes/LMP67.opus": "/srv/episodes/LMP67-preview.opus",
+		"episode.v2.m4a":           "episode.v2-preview.m4a",
+	}
+	for in, want := range tests {
+		if got := previewPath(in); got != want {
+			t.Errorf("previewPath(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestChaptersJSONPath(t *testing.T) {
+	tests := map[string]string{
+		"LMP67.mp3":                "LMP67.chapters.json",
+		"/srv/episodes/LMP67.opus": "/srv/episodes/LMP67.chapters.json",
+		"episode.v2.m4a":           "episode.v2.chapters.json",
+	}
+	for in, want := range tests {
+		if got := chaptersJSONPath(in); got != want {
+			t.Errorf("chaptersJSONPath(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
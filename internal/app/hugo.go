@@ -0,0 +1,232 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/linuxmatters/jivedrop/internal/encoder"
+	"github.com/linuxmatters/jivedrop/internal/id3"
+)
+
+// Hugo mode metadata defaults for the Linux Matters podcast.
+const (
+	HugoDefaultArtist  = "Linux Matters"
+	HugoDefaultComment = "https://linuxmatters.sh"
+	HugoDefaultPrefix  = "LMP"
+)
+
+// HugoWorkflow implements the Workflow interface for Hugo mode.
+// It reads metadata from Hugo frontmatter and supports frontmatter updates after encoding.
+type HugoWorkflow struct {
+	// opts carries the parsed CLI fields, populated at construction.
+	opts EpisodeOptions
+	// ui reports the warnings and asks the frontmatter question
+	ui UI
+	// hugoMetadata is set during CollectMetadata and read during PostEncode
+	hugoMetadata *encoder.EpisodeMetadata
+	// releaseTime is set during CollectMetadata from --date or the frontmatter Date
+	releaseTime time.Time
+}
+
+// Validate checks Hugo-specific arguments and file existence.
+func (h *HugoWorkflow) Validate() error {
+	if h.opts.EpisodeMD == "" {
+		return fmt.Errorf("hugo mode requires episode markdown file as second argument or --episode-md")
+	}
+
+	if !strings.HasSuffix(strings.ToLower(h.opts.EpisodeMD), ".md") {
+		return fmt.Errorf("episode markdown file must have .md extension: %s", h.opts.EpisodeMD)
+	}
+
+	if _, err := os.Stat(h.opts.EpisodeMD); err != nil {
+		return fmt.Errorf("episode file not accessible: %w", err)
+	}
+
+	// A cover URL is downloaded at encode time, so only local paths are checked.
+	if h.opts.Cover != "" && !h.opts.NoCover && !id3.IsCoverURL(h.opts.Cover) {
+		if _, err := os.Stat(h.opts.Cover); err != nil {
+			return fmt.Errorf("cover art not accessible: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// CollectMetadata parses Hugo frontmatter, applies defaults and flag overrides,
+// and resolves the cover art path.
+func (h *HugoWorkflow) CollectMetadata() (id3.TagInfo, string, error) {
+	metadata, err := encoder.ParseEpisodeMetadata(h.opts.EpisodeMD)
+	if err != nil {
+		return id3.TagInfo{}, "", fmt.Errorf("failed to parse episode metadata: %w", err)
+	}
+	h.hugoMetadata = metadata
+
+	// Seed with frontmatter values and Hugo defaults; flags override below.
+	episodeNum := metadata.Episode
+	episodeTitle := metadata.Title
+	artist := HugoDefaultArtist
+	comments := []string{HugoDefaultComment}
+
+	if h.opts.Artist != "" {
+		artist = h.opts.Artist
+	}
+	album := resolveAlbum(h.opts.Album, artist)
+	albumArtist := h.opts.AlbumArtist
+	if albumArtist == "" {
+		albumArtist = artist
+	}
+	if len(h.opts.Comments) > 0 {
+		comments = h.opts.Comments
+	}
+	if h.opts.Title != "" {
+		episodeTitle = h.opts.Title
+	}
+	if h.opts.Num != "" {
+		episodeNum = h.opts.Num
+	}
+	if _, err := encoder.ParseEpisodeNumber(episodeNum); err != nil {
+		return id3.TagInfo{}, "", fmt.Errorf("invalid episode number: %w", err)
+	}
+	if warning := episodeNumberMismatch(episodeNum, h.opts.EpisodeMD); warning != "" {
+		h.ui.Warning(warning)
+	}
+	date, err := resolveReleaseDate(h.opts.Date, h.opts.FullDate, metadata.Date)
+	if err != nil {
+		return id3.TagInfo{}, "", err
+	}
+	h.releaseTime = resolveReleaseTime(h.opts.Date, metadata.Date)
+
+	var coverArtPath string
+	switch {
+	case h.opts.NoCover:
+		// No artwork is wanted, so episode_image is not even resolved.
+	case h.opts.Cover != "":
+		coverArtPath = h.opts.Cover
+	case metadata.EpisodeImage == "":
+		return id3.TagInfo{}, "", fmt.Errorf("no cover art: set episode_image in the frontmatter, pass --cover, or pass --no-cover")
+	default:
+		coverArtPath, err = encoder.ResolveCoverArtPath(h.opts.EpisodeMD, metadata.EpisodeImage)
+		if err != nil {
+			return id3.TagInfo{}, "", fmt.Errorf("failed to resolve cover art: %w", err)
+		}
+	}
+
+	tagInfo := id3.TagInfo{
+		EpisodeNumber: episodeNum,
+		Title:         episodeTitle,
+		Artist:        artist,
+		Album:         album,
+		AlbumArtist:   albumArtist,
+		Date:          date,
+		Comments:      comments,
+		Explicit:      h.opts.Explicit || metadata.IsExplicit(),
+	}
+
+	return tagInfo, coverArtPath, nil
+}
+
+// PostEncode handles frontmatter comparison and update prompting against the
+// podcast statistics already shown in the completion summary.
+// stats describe the single encoded file regardless of format (duration seconds + byte size),
+// so write-back applies unchanged for mp3, opus, or aac. The prompt-on-change guard shows the
+// new podcast_duration/podcast_bytes and waits for confirmation before writing, so a non-mp3
+// encode cannot silently overwrite values for a different enclosure; --yes and --no-update
+// answer it in advance for unattended builds.
+func (h *HugoWorkflow) PostEncode(stats *encoder.FileStats) error {
+	needsUpdate := false
+	if h.hugoMetadata.PodcastDuration != "" && h.hugoMetadata.PodcastDuration != stats.DurationString {
+		h.ui.Warning(fmt.Sprintf("Duration mismatch: frontmatter has %s, calculated %s",
+			h.hugoMetadata.PodcastDuration, stats.DurationString))
+		needsUpdate = true
+	}
+	if h.hugoMetadata.PodcastBytes > 0 && h.hugoMetadata.PodcastBytes != stats.FileSizeBytes {
+		h.ui.Warning(fmt.Sprintf("File size mismatch: frontmatter has %d, calculated %d",
+			h.hugoMetadata.PodcastBytes, stats.FileSizeBytes))
+		needsUpdate = true
+	}
+
+	// Prompt user to update frontmatter if values differ or are missing
+	if needsUpdate {
+		h.updateFrontmatter("\nUpdate frontmatter with new values?", stats.DurationString, stats.FileSizeBytes)
+	} else if h.hugoMetadata.PodcastDuration == "" || h.hugoMetadata.PodcastBytes == 0 {
+		h.updateFrontmatter("\nAdd podcast_duration and podcast_bytes to frontmatter?", stats.DurationString, stats.FileSizeBytes)
+	}
+
+	return nil
+}
+
+// updateFrontmatter writes the podcast stats to the episode markdown. --yes
+// writes without asking and --no-update skips the write, so unattended builds
+// never block on the prompt; --diff reports what would change and writes
+// nothing; otherwise the user is asked with promptMsg.
+func (h *HugoWorkflow) updateFrontmatter(promptMsg, duration string, bytes int64) {
+	if h.opts.Diff {
+		diff, err := encoder.FrontmatterDiff(h.opts.EpisodeMD, duration, bytes)
+		if err != nil {
+			h.ui.Error(fmt.Sprintf("Failed to diff frontmatter: %v", err))
+			return
+		}
+		if diff == "" {
+			h.ui.Info("Frontmatter already up to date")
+			return
+		}
+		h.ui.Info("Frontmatter not updated; the update would be:")
+		h.ui.Diff(diff)
+		return
+	}
+	if h.opts.NoUpdate || (!h.opts.Yes && !h.ui.Confirm(promptMsg)) {
+		h.ui.Info("Frontmatter not updated")
+		return
+	}
+
+	if err := encoder.UpdateFrontmatter(h.opts.EpisodeMD, duration, bytes); err != nil {
+		h.ui.Error(fmt.Sprintf("Failed to update frontmatter: %v", err))
+	} else {
+		h.ui.Success("Frontmatter updated successfully")
+	}
+}
+
+// ReleaseTime returns the release time from --date or the frontmatter Date.
+func (h *HugoWorkflow) ReleaseTime() time.Time {
+	return h.releaseTime
+}
+
+// Ensure HugoWorkflow implements Workflow at compile time.
+var _ Workflow = (*HugoWorkflow)(nil)
+
+// markdownEpisodeNumber returns the episode number in an episode markdown
+// filename: the trailing digits of its name, as in "67.md" or "lmp67.md", or
+// of its directory for a page bundle's "index.md". It reports false when the
+// name carries no number.
+func markdownEpisodeNumber(path string) (int, bool) {
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	if name == "index" || name == "_index" {
+		name = filepath.Base(filepath.Dir(path))
+	}
+
+	digits := strings.TrimRightFunc(name, func(r rune) bool { return r >= '0' && r <= '9' })
+	n, err := strconv.Atoi(name[len(digits):])
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+// episodeNumberMismatch returns a warning when the resolved episode number
+// differs from the one in the markdown filename, which almost always means a
+// mistake, most often a --num override left over from another episode. It
+// returns "" when they agree or the filename has no number.
+func episodeNumberMismatch(episodeNum, mdPath string) string {
+	fileNum, ok := markdownEpisodeNumber(mdPath)
+	if !ok {
+		return ""
+	}
+	if n, err := strconv.Atoi(episodeNum); err != nil || n == fileNum {
+		return ""
+	}
+	return fmt.Sprintf("Episode number %s does not match the markdown filename %s: check --num and the frontmatter episode", episodeNum, filepath.Base(mdPath))
+}
@@ -0,0 +1,465 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/linuxmatters/jivedrop/internal/encoder"
+)
+
+// TestHugoWorkflowValidate tests Hugo mode validation of episode markdown arguments
+func TestHugoWorkflowValidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		episodeMD string
+		wantErr   bool
+		errMatch  string // Substring to match in error message
+	}{
+		// Valid cases
+		{
+			name:      "valid markdown file lowercase .md",
+			episodeMD: "episode.md",
+			wantErr:   false,
+		},
+		{
+			name:      "valid markdown file uppercase .MD",
+			episodeMD: "episode.MD",
+			wantErr:   false,
+		},
+		{
+			name:      "valid markdown file mixed case .Md",
+			episodeMD: "episode.Md",
+			wantErr:   false,
+		},
+		{
+			name:      "valid markdown with nested path",
+			episodeMD: "content/episodes/67.md",
+			wantErr:   false,
+		},
+		{
+			name:      "valid markdown deeply nested",
+			episodeMD: "posts/blog/2025/11/article.md",
+			wantErr:   false,
+		},
+		{
+			name:      "valid markdown with spaces in filename",
+			episodeMD: "my episode file.md",
+			wantErr:   false,
+		},
+		{
+			name:      "valid markdown with special characters",
+			episodeMD: "episode-67_final.md",
+			wantErr:   false,
+		},
+		{
+			name:      "valid markdown with multiple dots",
+			episodeMD: "my.episode.v2.md",
+			wantErr:   false,
+		},
+
+		// Invalid cases: missing EpisodeMD
+		{
+			name:      "empty episode markdown string",
+			episodeMD: "",
+			wantErr:   true,
+			errMatch:  "requires episode markdown file",
+		},
+
+		// Invalid cases: wrong file extensions
+		{
+			name:      "wrong extension .txt",
+			episodeMD: "episode.txt",
+			wantErr:   true,
+			errMatch:  "must have .md extension",
+		},
+		{
+			name:      "wrong extension .yaml",
+			episodeMD: "episode.yaml",
+			wantErr:   true,
+			errMatch:  "must have .md extension",
+		},
+		{
+			name:      "wrong extension .json",
+			episodeMD: "episode.json",
+			wantErr:   true,
+			errMatch:  "must have .md extension",
+		},
+		{
+			name:      "wrong extension .mp3",
+			episodeMD: "episode.mp3",
+			wantErr:   true,
+			errMatch:  "must have .md extension",
+		},
+
+		// Invalid cases: .md not at end
+		{
+			name:      ".md in middle of filename",
+			episodeMD: "markdown_file.mp3",
+			wantErr:   true,
+			errMatch:  "must have .md extension",
+		},
+		{
+			name:      ".md with backup suffix",
+			episodeMD: "episode.md.backup",
+			wantErr:   true,
+			errMatch:  "must have .md extension",
+		},
+		{
+			name:      ".md with bak extension",
+			episodeMD: "episode.md.bak",
+			wantErr:   true,
+			errMatch:  "must have .md extension",
+		},
+		{
+			name:      ".md with old extension",
+			episodeMD: "episode.md.old",
+			wantErr:   true,
+			errMatch:  "must have .md extension",
+		},
+
+		// Edge cases
+		{
+			name:      "just .md filename",
+			episodeMD: ".md",
+			wantErr:   false,
+		},
+		{
+			name:      "no extension",
+			episodeMD: "episode",
+			wantErr:   true,
+			errMatch:  "must have .md extension",
+		},
+		{
+			name:      "uppercase .MD only",
+			episodeMD: ".MD",
+			wantErr:   false,
+		},
+		{
+			name:      "path with uppercase .MD",
+			episodeMD: "content/episodes/POST.MD",
+			wantErr:   false,
+		},
+		{
+			name:      "relative path with ./",
+			episodeMD: "./episode.md",
+			wantErr:   false,
+		},
+		{
+			name:      "relative path with ../",
+			episodeMD: "../episodes/episode.md",
+			wantErr:   false,
+		},
+		{
+			name:      "absolute path (unix)",
+			episodeMD: "/home/user/episodes/67.md",
+			wantErr:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Construct the workflow with the test inputs threaded through
+			// EpisodeOptions. A dummy audio file keeps file-existence checks from
+			// masking the argument validation errors we are testing for.
+			wf := &HugoWorkflow{opts: EpisodeOptions{
+				EpisodeMD: tt.episodeMD,
+			}}
+			err := wf.Validate()
+
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("HugoWorkflow.Validate() expected error, got nil (EpisodeMD=%q)", tt.episodeMD)
+					return
+				}
+				if tt.errMatch != "" && !strings.Contains(err.Error(), tt.errMatch) {
+					t.Errorf("HugoWorkflow.Validate() error %q does not contain %q", err.Error(), tt.errMatch)
+				}
+				return
+			}
+
+			// For valid cases we only check argument validation, not file existence.
+			// File-not-found errors are acceptable here since the files do not exist on disk.
+			if err != nil && !strings.Contains(err.Error(), "not found") && !strings.Contains(err.Error(), "not accessible") {
+				t.Errorf("HugoWorkflow.Validate() unexpected error: %v (EpisodeMD=%q)", err, tt.episodeMD)
+			}
+		})
+	}
+}
+
+// TestHugoWorkflowValidate_Integration tests HugoWorkflow.Validate with realistic scenarios
+func TestHugoWorkflowValidate_Integration(t *testing.T) {
+	tests := []struct {
+		name        string
+		episodeMD   string
+		wantErr     bool
+		description string
+	}{
+		{
+			name:        "real hugo workflow file",
+			episodeMD:   "content/episodes/67.md",
+			wantErr:     false,
+			description: "Typical Linux Matters episode markdown path",
+		},
+		{
+			name:        "common user mistake: .txt instead of .md",
+			episodeMD:   "episode.txt",
+			wantErr:     true,
+			description: "User accidentally passes wrong file type",
+		},
+		{
+			name:        "common user mistake: no extension",
+			episodeMD:   "episode",
+			wantErr:     true,
+			description: "User passes file without extension",
+		},
+		{
+			name:        "common user mistake: .md.bak backup file",
+			episodeMD:   "episode.md.bak",
+			wantErr:     true,
+			description: "User passes backup file instead of original",
+		},
+		{
+			name:        "windows path with backslashes",
+			episodeMD:   "content\\episodes\\67.md",
+			wantErr:     false,
+			description: "Cross-platform support for Windows paths",
+		},
+		{
+			name:        "uppercase .MD extension",
+			episodeMD:   "EPISODE.MD",
+			wantErr:     false,
+			description: "Case-insensitive extension matching",
+		},
+		{
+			name:        "missing argument",
+			episodeMD:   "",
+			wantErr:     true,
+			description: "User forgot to provide episode markdown file",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Construct the workflow with the test inputs threaded through
+			// EpisodeOptions. A dummy audio file keeps file-existence checks from
+			// masking the argument validation errors we are testing for.
+			wf := &HugoWorkflow{opts: EpisodeOptions{
+				EpisodeMD: tt.episodeMD,
+			}}
+			err := wf.Validate()
+
+			if tt.wantErr && err == nil {
+				t.Errorf("HugoWorkflow.Validate() expected error but got nil\n  Description: %s\n  EpisodeMD=%q",
+					tt.description, tt.episodeMD)
+			}
+			if !tt.wantErr && err != nil && !strings.Contains(err.Error(), "not found") && !strings.Contains(err.Error(), "not accessible") {
+				t.Errorf("HugoWorkflow.Validate() unexpected error: %v\n  Description: %s\n  EpisodeMD=%q",
+					err, tt.description, tt.episodeMD)
+			}
+		})
+	}
+}
+
+// TestHugoCollectMetadata_FullDate verifies the frontmatter Date is truncated
+// to YYYY-MM by default and keeps its day and time with --full-date.
+func TestHugoCollectMetadata_FullDate(t *testing.T) {
+	tests := []struct {
+		name     string
+		fullDate bool
+		date     string
+		want     string
+	}{
+		{name: "truncated by default", want: "2023-04"},
+		{name: "full date keeps day and time", fullDate: true, want: "2023-04-11T23:40:39"},
+		{name: "flag overrides frontmatter", fullDate: true, date: "2024-01-02", want: "2024-01-02"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wf := &HugoWorkflow{opts: EpisodeOptions{
+				EpisodeMD: "../../testdata/0.md",
+				Date:      tt.date,
+				FullDate:  tt.fullDate,
+			}, ui: quietUI{}}
+			tagInfo, _, err := wf.CollectMetadata()
+			if err != nil {
+				t.Fatalf("CollectMetadata() unexpected error: %v", err)
+			}
+			if tagInfo.Date != tt.want {
+				t.Errorf("Date = %q, want %q", tagInfo.Date, tt.want)
+			}
+		})
+	}
+}
+
+// TestHugoCollectMetadata_AlbumArtist verifies the album artist follows the
+// artist unless --album-artist names another.
+func TestHugoCollectMetadata_AlbumArtist(t *testing.T) {
+	tests := []struct {
+		name        string
+		artist      string
+		albumArtist string
+		want        string
+	}{
+		{name: "default artist", want: HugoDefaultArtist},
+		{name: "artist flag", artist: "Guest Show", want: "Guest Show"},
+		{name: "album artist flag", artist: "Guest Host", albumArtist: "Linux Matters", want: "Linux Matters"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wf := &HugoWorkflow{opts: EpisodeOptions{
+				EpisodeMD:   "../../testdata/0.md",
+				Artist:      tt.artist,
+				AlbumArtist: tt.albumArtist,
+			}, ui: quietUI{}}
+			tagInfo, _, err := wf.CollectMetadata()
+			if err != nil {
+				t.Fatalf("CollectMetadata() unexpected error: %v", err)
+			}
+			if tagInfo.AlbumArtist != tt.want {
+				t.Errorf("AlbumArtist = %q, want %q", tagInfo.AlbumArtist, tt.want)
+			}
+		})
+	}
+}
+
+// TestHugoCollectMetadata_NoEpisodeImage verifies that frontmatter without
+// episode_image is accepted when --cover supplies the artwork, and rejected
+// when there is no cover source at all.
+func TestHugoCollectMetadata_NoEpisodeImage(t *testing.T) {
+	md := filepath.Join(t.TempDir(), "67.md")
+	if err := os.WriteFile(md, []byte("---\nepisode: \"67\"\ntitle: Test\n---\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		cover     string
+		noCover   bool
+		wantCover string
+		wantErr   bool
+	}{
+		{name: "cover flag", cover: "logo.png", wantCover: "logo.png"},
+		{name: "no cover source", wantErr: true},
+		{name: "no-cover", noCover: true},
+		{name: "no-cover overrides cover flag", cover: "logo.png", noCover: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wf := &HugoWorkflow{opts: EpisodeOptions{EpisodeMD: md, Cover: tt.cover, NoCover: tt.noCover}, ui: quietUI{}}
+			_, coverArtPath, err := wf.CollectMetadata()
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("CollectMetadata() expected error, got cover %q", coverArtPath)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CollectMetadata() unexpected error: %v", err)
+			}
+			if coverArtPath != tt.wantCover {
+				t.Errorf("cover = %q, want %q", coverArtPath, tt.wantCover)
+			}
+		})
+	}
+}
+
+// recordingUI is a UI that keeps the warnings and diffs reported to it and
+// gives confirm as the answer to every yes or no question.
+type recordingUI struct {
+	quietUI
+	confirm  bool
+	warnings []string
+	diffs    []string
+}
+
+func (u *recordingUI) Warning(msg string)  { u.warnings = append(u.warnings, msg) }
+func (u *recordingUI) Diff(diff string)    { u.diffs = append(u.diffs, diff) }
+func (u *recordingUI) Confirm(string) bool { return u.confirm }
+
+// TestHugoPostEncode_UpdateFlags verifies --yes writes the podcast stats
+// without prompting, --no-update leaves the frontmatter untouched, --diff
+// reports the update without making it, and otherwise the answer to the
+// prompt decides.
+func TestHugoPostEncode_UpdateFlags(t *testing.T) {
+	const original = "---\ntitle: Test\nepisode: \"1\"\npodcast_duration: 0:00:27\npodcast_bytes: 620544\n---\n\nBody\n"
+
+	tests := []struct {
+		name        string
+		opts        EpisodeOptions
+		confirm     bool
+		wantUpdated bool
+		wantDiff    bool
+	}{
+		{name: "yes writes without prompting", opts: EpisodeOptions{Yes: true}, wantUpdated: true},
+		{name: "no-update skips the write", opts: EpisodeOptions{NoUpdate: true}, wantUpdated: false},
+		{name: "diff skips the write", opts: EpisodeOptions{Diff: true}, wantUpdated: false, wantDiff: true},
+		{name: "confirmed prompt writes", confirm: true, wantUpdated: true},
+		{name: "declined prompt skips the write", wantUpdated: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mdPath := filepath.Join(t.TempDir(), "1.md")
+			if err := os.WriteFile(mdPath, []byte(original), 0o644); err != nil {
+				t.Fatalf("failed to write markdown: %v", err)
+			}
+
+			tt.opts.EpisodeMD = mdPath
+			ui := &recordingUI{confirm: tt.confirm}
+			wf := &HugoWorkflow{
+				opts:         tt.opts,
+				ui:           ui,
+				hugoMetadata: &encoder.EpisodeMetadata{PodcastDuration: "0:00:27", PodcastBytes: 620544},
+			}
+			if err := wf.PostEncode(&encoder.FileStats{DurationString: "0:01:00", FileSizeBytes: 1000}); err != nil {
+				t.Fatalf("PostEncode() unexpected error: %v", err)
+			}
+
+			content, err := os.ReadFile(mdPath)
+			if err != nil {
+				t.Fatalf("failed to read markdown: %v", err)
+			}
+			updated := strings.Contains(string(content), "podcast_bytes: 1000")
+			if updated != tt.wantUpdated {
+				t.Errorf("frontmatter updated = %v, want %v:\n%s", updated, tt.wantUpdated, content)
+			}
+			if len(ui.warnings) != 2 {
+				t.Errorf("warnings = %q, want the duration and file size mismatches", ui.warnings)
+			}
+			if (len(ui.diffs) > 0) != tt.wantDiff {
+				t.Errorf("diffs = %q, want a diff %v", ui.diffs, tt.wantDiff)
+			}
+		})
+	}
+}
+
+func TestEpisodeNumberMismatch(t *testing.T) {
+	tests := []struct {
+		name       string
+		episodeNum string
+		mdPath     string
+		wantWarn   bool
+	}{
+		{name: "match", episodeNum: "67", mdPath: "content/episode/67.md"},
+		{name: "leading zeros match", episodeNum: "067", mdPath: "67.md"},
+		{name: "prefixed filename", episodeNum: "67", mdPath: "lmp67.md"},
+		{name: "override disagrees", episodeNum: "68", mdPath: "content/episode/67.md", wantWarn: true},
+		{name: "page bundle disagrees", episodeNum: "68", mdPath: "content/episode/67/index.md", wantWarn: true},
+		{name: "page bundle matches", episodeNum: "67", mdPath: "content/episode/67/index.md"},
+		{name: "no number in filename", episodeNum: "68", mdPath: "latest.md"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := episodeNumberMismatch(tt.episodeNum, tt.mdPath)
+			if (got != "") != tt.wantWarn {
+				t.Errorf("episodeNumberMismatch(%q, %q) = %q, want warning %v", tt.episodeNum, tt.mdPath, got, tt.wantWarn)
+			}
+		})
+	}
+}
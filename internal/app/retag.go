@@ -0,0 +1,98 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/linuxmatters/jivedrop/internal/encoder"
+)
+
+// retag is the --metadata-only counterpart of encode: it copies the audio of
+// an existing MP3 unchanged and writes the new tags, cover art and chapters
+// around it. Re-tagging the input itself is done through a temporary file in
+// the same directory with the input's permissions, renamed over the input
+// once it is complete. The results match encode.
+func retag(req encodeRequest) (encoder.EncodeResult, *encoder.FileStats, error) {
+	coverResult, pictures, err := loadPictures(req)
+	if err != nil {
+		return encoder.EncodeResult{}, nil, err
+	}
+
+	target := req.OutputPath
+	inPlace := sameFile(req.AudioFile, req.OutputPath)
+	if inPlace {
+		// The .mp3 suffix lets FFmpeg pick the muxer from the name.
+		tmp, err := encoder.CreateReplacement(req.OutputPath, ".jivedrop-retag-*.mp3")
+		if err != nil {
+			return encoder.EncodeResult{}, nil, err
+		}
+		tmp.Close()
+		target = tmp.Name()
+		defer os.Remove(target)
+	}
+
+	cfg := encoderConfig(req, coverResult, pictures)
+	cfg.OutputPath = target
+	enc, err := encoder.New(cfg)
+	if err != nil {
+		return encoder.EncodeResult{}, nil, fmt.Errorf("failed to create encoder: %w", err)
+	}
+	defer enc.Close()
+
+	req.UI.Done("Ready to re-tag:", fmt.Sprintf("%s (audio is copied, not re-encoded)", req.AudioFile))
+	req.UI.Detail("• Episode:", fmt.Sprintf("%s - %s", req.TagInfo.EpisodeNumber, req.TagInfo.Title))
+	req.UI.Detail("• Output:", req.OutputPath)
+
+	result, err := enc.Retag()
+	reportFFmpegLog(req.UI, enc)
+	if err != nil {
+		if !inPlace {
+			os.Remove(target)
+		}
+		return encoder.EncodeResult{}, nil, fmt.Errorf("re-tagging failed: %w", err)
+	}
+
+	audio := "MP3 copied from input"
+	if kbps := enc.SourceBitrate(); kbps > 0 {
+		audio = fmt.Sprintf("MP3 %dkbps, copied from input", kbps)
+	}
+
+	// Close flushes and releases the output before it replaces the input.
+	enc.Close()
+	if inPlace {
+		if err := encoder.RetryIO(req.IORetries, func() error { return os.Rename(target, req.OutputPath) }); err != nil {
+			return encoder.EncodeResult{}, nil, fmt.Errorf("failed to replace %s: %w", req.OutputPath, err)
+		}
+		result.OutputPath = req.OutputPath
+	}
+
+	if req.CoverOut != "" {
+		writeCoverOut(req.UI, req.CoverOut, coverResult.data)
+	}
+
+	stats, partial := embedMetadata(req, result)
+	if !partial {
+		reportCompletionSummary(req, audio, stats, coverResult, false)
+	}
+
+	if req.SplitDuration > 0 {
+		if err := writeParts(req, coverResult, pictures, time.Duration(result.DurationSecs)*time.Second); err != nil {
+			return encoder.EncodeResult{}, nil, err
+		}
+	}
+	return result, stats, nil
+}
+
+// sameFile reports whether a and b name the same existing file.
+func sameFile(a, b string) bool {
+	aInfo, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	bInfo, err := os.Stat(b)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(aInfo, bInfo)
+}
@@ -0,0 +1,77 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSameFile(t *testing.T) {
+	dir := t.TempDir()
+	episode := filepath.Join(dir, "LMP67.mp3")
+	other := filepath.Join(dir, "LMP68.mp3")
+	for _, path := range []string{episode, other} {
+		if err := os.WriteFile(path, []byte("mp3"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		a, b string
+		want bool
+	}{
+		{name: "same path", a: episode, b: episode, want: true},
+		{name: "same file via dot segment", a: episode, b: filepath.Join(dir, ".", "LMP67.mp3"), want: true},
+		{name: "different files", a: episode, b: other, want: false},
+		{name: "missing output", a: episode, b: filepath.Join(dir, "new.mp3"), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sameFile(tt.a, tt.b); got != tt.want {
+				t.Errorf("sameFile(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestRunRetagInPlace_Integration encodes an MP3, then re-tags it over itself:
+// the file renamed over the input must keep the input's permissions.
+func TestRunRetagInPlace_Integration(t *testing.T) {
+	audioPath := "../../testdata/LMP0.flac"
+	if _, err := os.Stat(audioPath); os.IsNotExist(err) {
+		t.Skipf("Test file not found: %s", audioPath)
+	}
+
+	episode := filepath.Join(t.TempDir(), "LMP67.mp3")
+	if _, err := Run(Options{
+		AudioFile:  audioPath,
+		Standalone: true,
+		OutputPath: episode,
+		Episode:    EpisodeOptions{Num: "67", Title: "Wrong title", NoCover: true},
+	}); err != nil {
+		t.Fatalf("Run() encode error: %v", err)
+	}
+	if err := os.Chmod(episode, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Run(Options{
+		AudioFile:    episode,
+		Standalone:   true,
+		OutputPath:   episode,
+		MetadataOnly: true,
+		Episode:      EpisodeOptions{Num: "67", Title: "Terminal Velocity", NoCover: true},
+	}); err != nil {
+		t.Fatalf("Run() re-tag error: %v", err)
+	}
+
+	info, err := os.Stat(episode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0o644 {
+		t.Errorf("re-tagged file mode = %o, want 644", mode)
+	}
+}
@@ -0,0 +1,86 @@
+package app
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/linuxmatters/jivedrop/internal/encoder"
+)
+
+// rssItem is a podcast feed <item> for one episode. encoding/xml writes the
+// itunes: prefix literally, so the snippet relies on the feed's <rss> element
+// declaring the iTunes namespace, as every podcast feed does.
+type rssItem struct {
+	XMLName   xml.Name     `xml:"item"`
+	Title     string       `xml:"title"`
+	Enclosure rssEnclosure `xml:"enclosure"`
+	GUID      rssGUID      `xml:"guid"`
+	PubDate   string       `xml:"pubDate"`
+	Duration  string       `xml:"itunes:duration"`
+}
+
+// rssEnclosure is the <enclosure> pointing at the published audio file.
+type rssEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Length int64  `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+// rssGUID is the item's <guid>. The enclosure URL is used, which is unique but
+// not a web page, so isPermaLink is false.
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// parseBaseURL checks the --base-url flag: it must be an absolute http or
+// https URL, since the enclosure link ends up in a public feed.
+func parseBaseURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid --base-url %q: must be an absolute http or https URL", raw)
+	}
+	return u, nil
+}
+
+// enclosureURL joins the output filename onto the base URL, escaping it as a
+// path segment.
+func enclosureURL(base *url.URL, outputPath string) string {
+	return base.JoinPath(filepath.Base(outputPath)).String()
+}
+
+// buildRSSItem renders the <item> snippet for the encoded episode. The
+// duration is written in whole seconds, the form Apple Podcasts recommends.
+func buildRSSItem(title, href, mimeType string, stats *encoder.FileStats, pubDate time.Time) ([]byte, error) {
+	item := rssItem{
+		Title: title,
+		Enclosure: rssEnclosure{
+			URL:    href,
+			Length: stats.FileSizeBytes,
+			Type:   mimeType,
+		},
+		GUID:     rssGUID{IsPermaLink: false, Value: href},
+		PubDate:  pubDate.Format(time.RFC1123Z),
+		Duration: strconv.FormatInt(stats.DurationSecs, 10),
+	}
+
+	out, err := xml.MarshalIndent(item, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to render RSS item: %w", err)
+	}
+	return append(out, '\n'), nil
+}
+
+// writeRSSItem writes the snippet to dest, the --rss path. Run leaves "-" to
+// its caller, through Result.RSSItem.
+func writeRSSItem(dest string, item []byte) error {
+	if err := os.WriteFile(dest, item, 0o644); err != nil { //nolint:gosec // dest is the user-provided --rss path
+		return fmt.Errorf("failed to write RSS item: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/linuxmatters/jivedrop/internal/encoder"
+)
+
+func TestParseBaseURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		baseURL string
+		output  string
+		want    string
+		wantErr bool
+	}{
+		{name: "host only", baseURL: "https://audio.linuxmatters.net", output: "LMP67.mp3", want: "https://audio.linuxmatters.net/LMP67.mp3"},
+		{name: "trailing slash", baseURL: "https://audio.linuxmatters.net/", output: "LMP67.mp3", want: "https://audio.linuxmatters.net/LMP67.mp3"},
+		{name: "path prefix", baseURL: "https://example.com/media/2025", output: "out/ep-1.opus", want: "https://example.com/media/2025/ep-1.opus"},
+		{name: "filename escaped", baseURL: "http://example.com", output: "my show #1.mp3", want: "http://example.com/my%20show%20%231.mp3"},
+		{name: "no scheme", baseURL: "audio.linuxmatters.net", wantErr: true},
+		{name: "unsupported scheme", baseURL: "ftp://example.com", wantErr: true},
+		{name: "empty", baseURL: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base, err := parseBaseURL(tt.baseURL)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseBaseURL(%q) expected error, got %v", tt.baseURL, base)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseBaseURL(%q) unexpected error: %v", tt.baseURL, err)
+			}
+			if got := enclosureURL(base, tt.output); got != tt.want {
+				t.Errorf("enclosureURL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildRSSItem(t *testing.T) {
+	stats := &encoder.FileStats{DurationString: "00:54:09", DurationSecs: 3249, FileSizeBytes: 27357184}
+	pubDate := time.Date(2025, 11, 9, 9, 30, 0, 0, time.UTC)
+
+	got, err := buildRSSItem("67: Mirrors & Makefiles", "https://audio.linuxmatters.net/LMP67.mp3", "audio/mpeg", stats, pubDate)
+	if err != nil {
+		t.Fatalf("buildRSSItem() unexpected error: %v", err)
+	}
+
+	want := `<item>
+  <title>67: Mirrors &amp; Makefiles</title>
+  <enclosure url="https://audio.linuxmatters.net/LMP67.mp3" length="27357184" type="audio/mpeg"></enclosure>
+  <guid isPermaLink="false">https://audio.linuxmatters.net/LMP67.mp3</guid>
+  <pubDate>Sun, 09 Nov 2025 09:30:00 +0000</pubDate>
+  <itunes:duration>3249</itunes:duration>
+</item>
+`
+	if string(got) != want {
+		t.Errorf("buildRSSItem():\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
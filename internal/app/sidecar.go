@@ -0,0 +1,94 @@
+package app
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/linuxmatters/jivedrop/internal/encoder"
+	"github.com/linuxmatters/jivedrop/internal/id3"
+	"gopkg.in/yaml.v3"
+)
+
+// sidecarMetadata is an episode metadata file given with --meta: the
+// standalone flags as YAML or JSON keys, for shows without a Hugo site.
+type sidecarMetadata struct {
+	Episode  string     `yaml:"episode"`
+	Title    string     `yaml:"title"`
+	Artist   string     `yaml:"artist"`
+	Album    string     `yaml:"album"`
+	Date     string     `yaml:"date"`
+	Comments stringList `yaml:"comment"`
+	// Cover is relative to the metadata file's directory, not the working
+	// directory, so the file and its artwork can move together.
+	Cover string `yaml:"cover"`
+	// Explicit takes the same values as the frontmatter explicit key.
+	Explicit string `yaml:"explicit"`
+}
+
+// stringList decodes a YAML sequence of strings, or a single string as a list
+// of one, so a lone comment need not be written as a list.
+type stringList []string
+
+func (l *stringList) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*l = []string{value.Value}
+		return nil
+	}
+	var list []string
+	if err := value.Decode(&list); err != nil {
+		return fmt.Errorf("failed to decode comment list: %w", err)
+	}
+	*l = list
+	return nil
+}
+
+// readSidecar parses the metadata file at path. JSON is read as the YAML it
+// is a subset of. Unknown keys are an error, so a misspelt key is not
+// silently ignored.
+func readSidecar(path string) (sidecarMetadata, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return sidecarMetadata{}, fmt.Errorf("failed to read metadata file: %w", err)
+	}
+
+	var meta sidecarMetadata
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&meta); err != nil && !errors.Is(err, io.EOF) {
+		return sidecarMetadata{}, fmt.Errorf("failed to parse metadata file %s: %w", path, err)
+	}
+
+	if meta.Cover != "" && !id3.IsCoverURL(meta.Cover) && !filepath.IsAbs(meta.Cover) {
+		meta.Cover = filepath.Join(filepath.Dir(path), meta.Cover)
+	}
+	return meta, nil
+}
+
+// withSidecar fills metadata fields left empty on the command line from a
+// --meta file. Flags always win.
+func (o EpisodeOptions) withSidecar(meta sidecarMetadata) EpisodeOptions {
+	adopt := func(field *string, value string) {
+		if *field == "" {
+			*field = strings.TrimSpace(value)
+		}
+	}
+
+	adopt(&o.Num, meta.Episode)
+	adopt(&o.Title, meta.Title)
+	adopt(&o.Artist, meta.Artist)
+	adopt(&o.Album, meta.Album)
+	adopt(&o.Date, meta.Date)
+	adopt(&o.Cover, meta.Cover)
+	if len(o.Comments) == 0 {
+		o.Comments = meta.Comments
+	}
+	if !o.Explicit {
+		o.Explicit = (&encoder.EpisodeMetadata{Explicit: meta.Explicit}).IsExplicit()
+	}
+	return o
+}
@@ -0,0 +1,133 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestReadSidecar(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name     string
+		file     string
+		content  string
+		want     sidecarMetadata
+		errMatch string
+	}{
+		{
+			name: "yaml",
+			file: "meta.yaml",
+			content: "episode: 67\ntitle: Terminal Full of Sparkles\nartist: Linux Matters\ndate: 2025-11-02\n" +
+				"comment:\n  - https://linuxmatters.sh\n  - Thanks for listening\ncover: art/cover.png\nexplicit: yes\n",
+			want: sidecarMetadata{
+				Episode:  "67",
+				Title:    "Terminal Full of Sparkles",
+				Artist:   "Linux Matters",
+				Date:     "2025-11-02",
+				Comments: stringList{"https://linuxmatters.sh", "Thanks for listening"},
+				Cover:    filepath.Join(dir, "art/cover.png"),
+				Explicit: "yes",
+			},
+		},
+		{
+			name:    "json with single comment and URL cover",
+			file:    "meta.json",
+			content: `{"episode": "68", "title": "Next", "comment": "Show notes", "cover": "https://example.com/cover.png"}`,
+			want: sidecarMetadata{
+				Episode:  "68",
+				Title:    "Next",
+				Comments: stringList{"Show notes"},
+				Cover:    "https://example.com/cover.png",
+			},
+		},
+		{
+			name:    "empty",
+			file:    "empty.yaml",
+			content: "",
+		},
+		{
+			name:     "unknown key",
+			file:     "typo.yaml",
+			content:  "episode: 67\ntitel: Oops\n",
+			errMatch: "field titel not found",
+		},
+		{
+			name:     "missing",
+			file:     "",
+			errMatch: "failed to read metadata file",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, "missing.yaml")
+			if tt.file != "" {
+				path = filepath.Join(dir, tt.file)
+				if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := readSidecar(path)
+			if tt.errMatch != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errMatch) {
+					t.Errorf("readSidecar() error = %v, want containing %q", err, tt.errMatch)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readSidecar() error: %v", err)
+			}
+			if !slices.Equal(got.Comments, tt.want.Comments) {
+				t.Errorf("Comments = %q, want %q", got.Comments, tt.want.Comments)
+			}
+			// The lists are checked above; compare the rest field by field.
+			got.Comments, tt.want.Comments = nil, nil
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readSidecar() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestEpisodeOptionsWithSidecar verifies the metadata file fills only the
+// fields the flags left empty.
+func TestEpisodeOptionsWithSidecar(t *testing.T) {
+	meta := sidecarMetadata{
+		Episode:  "67",
+		Title:    " From the file ",
+		Artist:   "File Artist",
+		Date:     "2025-11",
+		Comments: stringList{"From the file"},
+		Cover:    "/art/cover.png",
+		Explicit: "explicit",
+	}
+	opts := EpisodeOptions{Title: "From the flag", Comments: []string{"Flag comment"}}.withSidecar(meta)
+
+	want := EpisodeOptions{
+		Num:      "67",
+		Title:    "From the flag",
+		Artist:   "File Artist",
+		Date:     "2025-11",
+		Comments: []string{"Flag comment"},
+		Cover:    "/art/cover.png",
+		Explicit: true,
+	}
+	if !slices.Equal(opts.Comments, want.Comments) {
+		t.Errorf("Comments = %q, want %q", opts.Comments, want.Comments)
+	}
+	opts.Comments, want.Comments = nil, nil
+	if opts.Num != want.Num || opts.Title != want.Title || opts.Artist != want.Artist ||
+		opts.Date != want.Date || opts.Cover != want.Cover || opts.Explicit != want.Explicit || opts.Album != "" {
+		t.Errorf("withSidecar() = %+v, want %+v", opts, want)
+	}
+
+	if got := (EpisodeOptions{}).withSidecar(meta); got.Title != "From the file" || !slices.Equal(got.Comments, []string{"From the file"}) {
+		t.Errorf("withSidecar() with no flags = %+v, want the file's title and comment", got)
+	}
+}
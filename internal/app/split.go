@@ -0,0 +1,128 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/linuxmatters/jivedrop/internal/encoder"
+)
+
+// splitRange is the stretch of the episode one part covers. A zero end runs
+// to the end of the audio.
+type splitRange struct {
+	start, end time.Duration
+}
+
+// splitRanges divides total into parts of every, the last taking whatever
+// remains. It returns nil when the episode fits in a single part.
+func splitRanges(total, every time.Duration) []splitRange {
+	if every <= 0 || total <= every {
+		return nil
+	}
+	var ranges []splitRange
+	for start := time.Duration(0); start < total; start += every {
+		ranges = append(ranges, splitRange{start: start, end: start + every})
+	}
+	ranges[len(ranges)-1].end = 0
+	return ranges
+}
+
+// partChapters returns the chapters that fall in r, timed from the start of
+// the part. The chapter already running when the part starts is carried in
+// at zero, so every part opens on a chapter.
+func partChapters(chapters []encoder.Chapter, r splitRange) []encoder.Chapter {
+	var part []encoder.Chapter
+	for i, ch := range chapters {
+		if r.end > 0 && ch.Start >= r.end {
+			break
+		}
+		if ch.Start < r.start {
+			if i+1 < len(chapters) && chapters[i+1].Start <= r.start {
+				continue
+			}
+			ch.Start = r.start
+		}
+		ch.Start -= r.start
+		part = append(part, ch)
+	}
+	return part
+}
+
+// partTranscript returns the transcript lines spoken in r, timed from the
+// start of the part.
+func partTranscript(transcript []encoder.TranscriptLine, r splitRange) []encoder.TranscriptLine {
+	var part []encoder.TranscriptLine
+	for _, line := range transcript {
+		if r.end > 0 && line.Start >= r.end {
+			break
+		}
+		if line.Start < r.start {
+			continue
+		}
+		line.Start -= r.start
+		part = append(part, line)
+	}
+	return part
+}
+
+// partPath returns the path of part n (from 1) of the output at path:
+// LMP67.mp3 becomes LMP67-part1.mp3.
+func partPath(path string, n int) string {
+	ext := filepath.Ext(path)
+	return fmt.Sprintf("%s-part%d%s", strings.TrimSuffix(path, ext), n, ext)
+}
+
+// writeParts copies the finished MP3 at req.OutputPath out in parts of
+// req.SplitDuration, each a complete file with its own tags, cover art,
+// chapters and transcript, and the title suffixed "(Part 1/2)". The audio is copied, not
+// re-encoded. The complete file is kept. On failure any parts already written
+// are removed.
+func writeParts(req encodeRequest, cover coverArtResult, pictures []encoder.Picture, total time.Duration) error {
+	ranges := splitRanges(total, req.SplitDuration)
+	if ranges == nil {
+		req.UI.Info(fmt.Sprintf("Not split: the episode is no longer than --split-duration %s", req.SplitDuration))
+		return nil
+	}
+
+	var written []string
+	for i, r := range ranges {
+		path := partPath(req.OutputPath, i+1)
+		cfg := encoderConfig(req, cover, pictures)
+		cfg.InputPath = req.OutputPath
+		cfg.OutputPath = path
+		cfg.Metadata.Title = fmt.Sprintf("%s (Part %d/%d)", req.TagInfo.Title, i+1, len(ranges))
+		cfg.Chapters = partChapters(req.Chapters, r)
+		cfg.Transcript = partTranscript(req.Transcript, r)
+		cfg.RetagStart, cfg.RetagEnd = r.start, r.end
+
+		if err := writePart(req.UI, cfg); err != nil {
+			os.Remove(path)
+			for _, done := range written {
+				os.Remove(done)
+			}
+			return fmt.Errorf("failed to write part %d of %d: %w", i+1, len(ranges), err)
+		}
+		written = append(written, path)
+	}
+
+	for i, path := range written {
+		req.UI.Done(fmt.Sprintf("Part %d/%d:", i+1, len(written)), path)
+	}
+	return nil
+}
+
+// writePart copies one part of the episode with the re-tag path.
+func writePart(ui UI, cfg encoder.Config) error {
+	enc, err := encoder.New(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create encoder: %w", err)
+	}
+	defer enc.Close()
+
+	_, err = enc.Retag()
+	reportFFmpegLog(ui, enc)
+	return err
+}
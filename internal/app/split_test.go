@@ -0,0 +1,124 @@
+package app
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/linuxmatters/jivedrop/internal/encoder"
+)
+
+func TestSplitRanges(t *testing.T) {
+	tests := []struct {
+		name  string
+		total time.Duration
+		every time.Duration
+		want  []splitRange
+	}{
+		{name: "shorter than a part", total: 45 * time.Minute, every: time.Hour},
+		{name: "exactly one part", total: time.Hour, every: time.Hour},
+		{name: "disabled", total: 2 * time.Hour, every: 0},
+		{
+			name:  "two parts",
+			total: 90 * time.Minute,
+			every: time.Hour,
+			want:  []splitRange{{0, time.Hour}, {time.Hour, 0}},
+		},
+		{
+			name:  "three even parts",
+			total: 3 * time.Hour,
+			every: time.Hour,
+			want:  []splitRange{{0, time.Hour}, {time.Hour, 2 * time.Hour}, {2 * time.Hour, 0}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitRanges(tt.total, tt.every); !slices.Equal(got, tt.want) {
+				t.Errorf("splitRanges(%s, %s) = %v, want %v", tt.total, tt.every, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPartChapters(t *testing.T) {
+	chapters := []encoder.Chapter{
+		{Start: 0, Title: "Introduction"},
+		{Start: 10 * time.Minute, Title: "News"},
+		{Start: 50 * time.Minute, Title: "Feedback"},
+		{Start: 80 * time.Minute, Title: "Goodbye"},
+	}
+
+	tests := []struct {
+		name string
+		r    splitRange
+		want []encoder.Chapter
+	}{
+		{
+			name: "first part",
+			r:    splitRange{0, 40 * time.Minute},
+			want: []encoder.Chapter{{Start: 0, Title: "Introduction"}, {Start: 10 * time.Minute, Title: "News"}},
+		},
+		{
+			name: "running chapter carried in",
+			r:    splitRange{40 * time.Minute, 80 * time.Minute},
+			want: []encoder.Chapter{{Start: 0, Title: "News"}, {Start: 10 * time.Minute, Title: "Feedback"}},
+		},
+		{
+			name: "chapter on the boundary",
+			r:    splitRange{80 * time.Minute, 0},
+			want: []encoder.Chapter{{Start: 0, Title: "Goodbye"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := partChapters(chapters, tt.r); !slices.Equal(got, tt.want) {
+				t.Errorf("partChapters() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if got := partChapters(nil, splitRange{time.Hour, 0}); got != nil {
+		t.Errorf("partChapters() without chapters = %v, want none", got)
+	}
+}
+
+func TestPartTranscript(t *testing.T) {
+	transcript := []encoder.TranscriptLine{
+		{Start: 0, Text: "Hello"},
+		{Start: 39 * time.Minute, Text: "Before the break"},
+		{Start: 40 * time.Minute, Text: "After the break"},
+		{Start: 41 * time.Minute, Text: "Goodbye"},
+	}
+
+	got := partTranscript(transcript, splitRange{0, 40 * time.Minute})
+	want := transcript[:2]
+	if !slices.Equal(got, want) {
+		t.Errorf("partTranscript() first part = %v, want %v", got, want)
+	}
+
+	got = partTranscript(transcript, splitRange{40 * time.Minute, 0})
+	want = []encoder.TranscriptLine{{Start: 0, Text: "After the break"}, {Start: time.Minute, Text: "Goodbye"}}
+	if !slices.Equal(got, want) {
+		t.Errorf("partTranscript() last part = %v, want %v", got, want)
+	}
+}
+
+func TestPartPath(t *testing.T) {
+	tests := []struct {
+		path string
+		n    int
+		want string
+	}{
+		{path: "LMP67.mp3", n: 1, want: "LMP67-part1.mp3"},
+		{path: "out/linux-matters-67.mp3", n: 12, want: "out/linux-matters-67-part12.mp3"},
+		{path: "episode", n: 2, want: "episode-part2"},
+	}
+
+	for _, tt := range tests {
+		if got := partPath(tt.path, tt.n); got != tt.want {
+			t.Errorf("partPath(%q, %d) = %q, want %q", tt.path, tt.n, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/linuxmatters/jivedrop/internal/encoder"
+	"github.com/linuxmatters/jivedrop/internal/id3"
+)
+
+// StandaloneWorkflow implements the Workflow interface for standalone mode.
+// Metadata comes entirely from CLI flags.
+type StandaloneWorkflow struct {
+	// opts carries the parsed CLI fields, populated at construction.
+	opts EpisodeOptions
+	// releaseTime is set during CollectMetadata from --date, or today
+	releaseTime time.Time
+}
+
+// Validate checks standalone-specific arguments and file existence. A title that
+// is empty after trimming whitespace counts as missing.
+func (s *StandaloneWorkflow) Validate() error {
+	if strings.TrimSpace(s.opts.Title) == "" {
+		return fmt.Errorf("standalone mode requires --title flag")
+	}
+
+	if s.opts.Num == "" {
+		return fmt.Errorf("standalone mode requires --num flag (episode number)")
+	}
+
+	if _, err := encoder.ParseEpisodeNumber(s.opts.Num); err != nil {
+		return fmt.Errorf("invalid --num flag: %w", err)
+	}
+
+	if s.opts.Date != "" {
+		if _, err := encoder.NormaliseReleaseDate(s.opts.Date, s.opts.FullDate); err != nil {
+			return fmt.Errorf("invalid --date flag: %w", err)
+		}
+	}
+
+	if s.opts.NoCover {
+		return nil
+	}
+
+	if s.opts.Cover == "" {
+		return fmt.Errorf("standalone mode requires --cover flag (cover art path), or --no-cover")
+	}
+
+	// A cover URL is downloaded at encode time, so only local paths are checked.
+	if !id3.IsCoverURL(s.opts.Cover) {
+		if _, err := os.Stat(s.opts.Cover); err != nil {
+			return fmt.Errorf("cover art not accessible: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// askFunc asks for one value, re-asking until validate accepts it, and
+// reports false when no answer came; UI.Prompt is one.
+type askFunc func(question string, validate func(string) error) (string, bool)
+
+// promptMissing asks for the standalone fields Validate requires and opts
+// leaves unset: the title, the episode number and the cover art. An empty
+// cover answer means no artwork. Fields that get no answer stay unset, so
+// Validate reports them as usual.
+func promptMissing(opts EpisodeOptions, ask askFunc) EpisodeOptions {
+	if strings.TrimSpace(opts.Title) == "" {
+		opts.Title, _ = ask("Episode title", func(s string) error {
+			if s == "" {
+				return fmt.Errorf("the title must not be empty")
+			}
+			return nil
+		})
+	}
+
+	if opts.Num == "" {
+		opts.Num, _ = ask("Episode number", func(s string) error {
+			_, err := encoder.ParseEpisodeNumber(s)
+			return err
+		})
+	}
+
+	if opts.Cover == "" && !opts.NoCover {
+		cover, ok := ask("Cover art path or URL (empty for none)", func(s string) error {
+			if s == "" || id3.IsCoverURL(s) {
+				return nil
+			}
+			return id3.ValidateCoverArt(s)
+		})
+		opts.Cover = cover
+		opts.NoCover = ok && cover == ""
+	}
+	return opts
+}
+
+// CollectMetadata builds TagInfo from CLI flags. The release date defaults to
+// today when --date is omitted.
+func (s *StandaloneWorkflow) CollectMetadata() (id3.TagInfo, string, error) {
+	album := resolveAlbum(s.opts.Album, s.opts.Artist)
+
+	// Default to midnight today so --full-date writes the day, not the
+	// current time of day.
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	date, err := resolveReleaseDate(s.opts.Date, s.opts.FullDate, today)
+	if err != nil {
+		return id3.TagInfo{}, "", err
+	}
+	s.releaseTime = resolveReleaseTime(s.opts.Date, today)
+
+	tagInfo := id3.TagInfo{
+		EpisodeNumber: s.opts.Num,
+		Title:         s.opts.Title,
+		Artist:        s.opts.Artist,
+		Album:         album,
+		AlbumArtist:   s.opts.AlbumArtist,
+		Date:          date,
+		Comments:      s.opts.Comments,
+		Explicit:      s.opts.Explicit,
+	}
+
+	if s.opts.NoCover {
+		return tagInfo, "", nil
+	}
+	return tagInfo, s.opts.Cover, nil
+}
+
+// PostEncode does nothing: the completion summary already shows the podcast
+// statistics and standalone mode has no frontmatter to update.
+func (s *StandaloneWorkflow) PostEncode(_ *encoder.FileStats) error {
+	return nil
+}
+
+// ReleaseTime returns the release time from --date, or midnight today.
+func (s *StandaloneWorkflow) ReleaseTime() time.Time {
+	return s.releaseTime
+}
+
+// Ensure StandaloneWorkflow implements Workflow at compile time.
+var _ Workflow = (*StandaloneWorkflow)(nil)
@@ -1,8 +1,9 @@
-package main
+package app
 
 import (
 	"strings"
 	"testing"
+	"time"
 )
 
 // TestStandaloneWorkflowValidate tests standalone mode validation of required flags
@@ -140,13 +141,14 @@ func TestStandaloneWorkflowValidate(t *testing.T) {
 			errMatch: "requires --title flag", // First validation fails
 		},
 
-		// Edge cases: whitespace-only values (still invalid if they parse as empty)
+		// Edge cases: whitespace-only values (invalid once trimmed)
 		{
-			name:    "whitespace-only title (not trimmed by validator)",
-			title:   "   ",
-			num:     "1",
-			cover:   "cover.png",
-			wantErr: false, // Not empty string, so passes validation
+			name:     "whitespace-only title (rejected after trimming)",
+			title:    "   ",
+			num:      "1",
+			cover:    "cover.png",
+			wantErr:  true,
+			errMatch: "requires --title flag",
 		},
 		{
 			name:     "whitespace-only num (rejected as non-numeric)",
@@ -216,9 +218,9 @@ func TestStandaloneWorkflowValidate(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// Construct the workflow with the test inputs threaded through
-			// CLIOptions. A dummy audio file keeps file-existence checks from
+			// EpisodeOptions. A dummy audio file keeps file-existence checks from
 			// masking the argument validation errors we are testing for.
-			wf := &StandaloneWorkflow{opts: CLIOptions{
+			wf := &StandaloneWorkflow{opts: EpisodeOptions{
 				Title: tt.title,
 				Num:   tt.num,
 				Cover: tt.cover,
@@ -247,6 +249,24 @@ func TestStandaloneWorkflowValidate(t *testing.T) {
 	}
 }
 
+// TestStandaloneWorkflow_NoCover tests that --no-cover stands in for --cover
+// and discards any cover that was given, even one that does not exist.
+func TestStandaloneWorkflow_NoCover(t *testing.T) {
+	for _, cover := range []string{"", "missing.png"} {
+		wf := &StandaloneWorkflow{opts: EpisodeOptions{Title: "Episode", Num: "1", Cover: cover, NoCover: true}}
+		if err := wf.Validate(); err != nil {
+			t.Fatalf("Validate() with cover %q: unexpected error: %v", cover, err)
+		}
+		_, coverArtPath, err := wf.CollectMetadata()
+		if err != nil {
+			t.Fatalf("CollectMetadata() with cover %q: unexpected error: %v", cover, err)
+		}
+		if coverArtPath != "" {
+			t.Errorf("cover = %q, want none", coverArtPath)
+		}
+	}
+}
+
 // TestStandaloneWorkflowValidate_Integration tests StandaloneWorkflow.Validate with realistic scenarios
 func TestStandaloneWorkflowValidate_Integration(t *testing.T) {
 	tests := []struct {
@@ -326,9 +346,9 @@ func TestStandaloneWorkflowValidate_Integration(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// Construct the workflow with the test inputs threaded through
-			// CLIOptions. A dummy audio file keeps file-existence checks from
+			// EpisodeOptions. A dummy audio file keeps file-existence checks from
 			// masking the argument validation errors we are testing for.
-			wf := &StandaloneWorkflow{opts: CLIOptions{
+			wf := &StandaloneWorkflow{opts: EpisodeOptions{
 				Title: tt.title,
 				Num:   tt.num,
 				Cover: tt.cover,
@@ -346,3 +366,138 @@ func TestStandaloneWorkflowValidate_Integration(t *testing.T) {
 		})
 	}
 }
+
+// TestEpisodeOptionsTrimmed verifies that surrounding whitespace is stripped from
+// the metadata fields, leaving an all-whitespace title empty so Validate
+// rejects it.
+func TestEpisodeOptionsTrimmed(t *testing.T) {
+	opts := EpisodeOptions{
+		Num:      " 42 ",
+		Title:    "\tEpisode Title  ",
+		Artist:   "  Linux Matters",
+		Album:    "Album ",
+		Comments: []string{" https://example.com\n", "   ", "Show notes "},
+		Cover:    " cover.png",
+	}.trimmed()
+
+	if opts.Num != "42" {
+		t.Errorf("Num = %q, want %q", opts.Num, "42")
+	}
+	if opts.Title != "Episode Title" {
+		t.Errorf("Title = %q, want %q", opts.Title, "Episode Title")
+	}
+	if opts.Artist != "Linux Matters" {
+		t.Errorf("Artist = %q, want %q", opts.Artist, "Linux Matters")
+	}
+	if opts.Album != "Album" {
+		t.Errorf("Album = %q, want %q", opts.Album, "Album")
+	}
+	if len(opts.Comments) != 2 || opts.Comments[0] != "https://example.com" || opts.Comments[1] != "Show notes" {
+		t.Errorf("Comments = %q, want blank entries dropped and the rest trimmed", opts.Comments)
+	}
+	// Paths are not metadata and keep their exact value.
+	if opts.Cover != " cover.png" {
+		t.Errorf("Cover = %q, want it untouched", opts.Cover)
+	}
+
+	wf := &StandaloneWorkflow{opts: EpisodeOptions{Title: "   ", Num: "1", Cover: "cover.png"}.trimmed()}
+	if err := wf.Validate(); err == nil || !strings.Contains(err.Error(), "requires --title flag") {
+		t.Errorf("expected whitespace-only title to be rejected, got %v", err)
+	}
+}
+
+// TestStandaloneCollectMetadata_Date verifies the release date defaults to
+// today when --date is omitted and is normalised when given.
+func TestStandaloneCollectMetadata_Date(t *testing.T) {
+	tests := []struct {
+		name     string
+		date     string
+		fullDate bool
+		want     string
+	}{
+		{name: "defaults to current month", want: time.Now().Format("2006-01")},
+		{name: "defaults to today with full date", fullDate: true, want: time.Now().Format("2006-01-02")},
+		{name: "full date truncated", date: "2025-10-14", want: "2025-10"},
+		{name: "RFC3339 keeps the time", date: "2025-10-14T09:30:00Z", fullDate: true, want: "2025-10-14T09:30:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wf := &StandaloneWorkflow{opts: EpisodeOptions{
+				Title:    "Episode",
+				Num:      "1",
+				Cover:    "cover.png",
+				Date:     tt.date,
+				FullDate: tt.fullDate,
+			}}
+			tagInfo, _, err := wf.CollectMetadata()
+			if err != nil {
+				t.Fatalf("CollectMetadata() unexpected error: %v", err)
+			}
+			if tagInfo.Date != tt.want {
+				t.Errorf("Date = %q, want %q", tagInfo.Date, tt.want)
+			}
+		})
+	}
+
+	wf := &StandaloneWorkflow{opts: EpisodeOptions{Title: "Episode", Num: "1", Cover: "cover.png", Date: "yesterday"}}
+	if err := wf.Validate(); err == nil || !strings.Contains(err.Error(), "invalid --date flag") {
+		t.Errorf("expected unparseable --date to be rejected, got %v", err)
+	}
+}
+
+// TestPromptMissing verifies only missing fields are asked for, answers are
+// validated, and an empty cover answer means no artwork.
+func TestPromptMissing(t *testing.T) {
+	// scripted answers each question from answers in turn, trying every
+	// answer for a question until one validates.
+	scripted := func(answers map[string][]string, asked *[]string) askFunc {
+		return func(question string, validate func(string) error) (string, bool) {
+			*asked = append(*asked, question)
+			for _, answer := range answers[question] {
+				if validate(answer) == nil {
+					return answer, true
+				}
+			}
+			return "", false
+		}
+	}
+
+	t.Run("asks for missing fields", func(t *testing.T) {
+		var asked []string
+		got := promptMissing(EpisodeOptions{Artist: "Linux Matters"}, scripted(map[string][]string{
+			"Episode title":                          {"", "Terminal Velocity"},
+			"Episode number":                         {"sixty-seven", "67"},
+			"Cover art path or URL (empty for none)": {"/no/such/cover.png", ""},
+		}, &asked))
+
+		if got.Title != "Terminal Velocity" || got.Num != "67" || got.Cover != "" || !got.NoCover {
+			t.Errorf("promptMissing() = %+v", got)
+		}
+		if len(asked) != 3 {
+			t.Errorf("asked %q, want three questions", asked)
+		}
+	})
+
+	t.Run("keeps given fields", func(t *testing.T) {
+		var asked []string
+		opts := EpisodeOptions{Title: "Terminal Velocity", Num: "67", Cover: "https://example.com/cover.png"}
+		if got := promptMissing(opts, scripted(nil, &asked)); got.Title != opts.Title || got.Num != opts.Num || got.Cover != opts.Cover {
+			t.Errorf("promptMissing() = %+v, want the options unchanged", got)
+		}
+		if len(asked) != 0 {
+			t.Errorf("asked %q, want nothing", asked)
+		}
+	})
+
+	t.Run("no answer leaves the field for Validate", func(t *testing.T) {
+		var asked []string
+		got := promptMissing(EpisodeOptions{Title: "Terminal Velocity", Num: "67"}, scripted(nil, &asked))
+		if got.Cover != "" || got.NoCover {
+			t.Errorf("promptMissing() = %+v, want no cover decision", got)
+		}
+		if err := (&StandaloneWorkflow{opts: got}).Validate(); err == nil || !strings.Contains(err.Error(), "--cover") {
+			t.Errorf("Validate() = %v, want the missing --cover error", err)
+		}
+	})
+}
@@ -0,0 +1,59 @@
+package app
+
+// UI is how Run talks to the user while it works. Run prints nothing itself:
+// its progress, warnings, encode plan and completion summary are reported
+// here as they happen, and its questions are asked here, so the caller
+// decides how they look. The jivedrop command prints them through the cli
+// package.
+type UI interface {
+	// Info, Success and Warning report one line of progress. Error reports a
+	// failure Run carries on past, such as a frontmatter update that could
+	// not be written.
+	Info(msg string)
+	Success(msg string)
+	Warning(msg string)
+	Error(msg string)
+
+	// Detail reports one line of the encode plan, such as "• Output:" and
+	// the output path.
+	Detail(label, value string)
+	// Done reports a finished step, such as "Cover art:" and the path the
+	// artwork was written to.
+	Done(label, value string)
+	// Cover reports the cover art chosen to meet --max-cover-bytes: its edge
+	// length in pixels and its formatted size.
+	Cover(edge int, size string)
+	// Diff reports the unified diff of a frontmatter update that was not
+	// made.
+	Diff(diff string)
+	// Summary reports the finished episode.
+	Summary(title string, fields []SummaryField)
+
+	// Confirm asks a yes or no question. Prompt asks for one value,
+	// re-asking until validate accepts it, and reports false when no answer
+	// came.
+	Confirm(question string) bool
+	Prompt(question string, validate func(string) error) (string, bool)
+}
+
+// SummaryField is one labelled row of the completion summary.
+type SummaryField struct {
+	Label string
+	Value string
+}
+
+// quietUI is the UI Run uses when Options.UI is nil: it reports nothing and
+// gets no answer to any question.
+type quietUI struct{}
+
+func (quietUI) Info(string)                                      {}
+func (quietUI) Success(string)                                   {}
+func (quietUI) Warning(string)                                   {}
+func (quietUI) Error(string)                                     {}
+func (quietUI) Detail(string, string)                            {}
+func (quietUI) Done(string, string)                              {}
+func (quietUI) Cover(int, string)                                {}
+func (quietUI) Diff(string)                                      {}
+func (quietUI) Summary(string, []SummaryField)                   {}
+func (quietUI) Confirm(string) bool                              { return false }
+func (quietUI) Prompt(string, func(string) error) (string, bool) { return "", false }
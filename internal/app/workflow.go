@@ -0,0 +1,199 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/linuxmatters/jivedrop/internal/encoder"
+	"github.com/linuxmatters/jivedrop/internal/id3"
+)
+
+// Workflow defines the mode-specific operations for Hugo and Standalone workflows.
+// resolveOutputPath remains a shared package-level helper called from Run.
+type Workflow interface {
+	// Validate checks mode-specific arguments and file existence.
+	Validate() error
+
+	// CollectMetadata gathers ID3 tag info and cover art path for the current mode.
+	// The cover art path is returned separately because it feeds the concurrent
+	// cover art goroutine, not TagInfo directly.
+	CollectMetadata() (id3.TagInfo, string, error)
+
+	// PostEncode handles post-encoding operations once the completion summary
+	// is reported: in Hugo mode, frontmatter comparison and update prompting.
+	PostEncode(stats *encoder.FileStats) error
+
+	// ReleaseTime returns the release date at full precision, untruncated by
+	// the date tag format, for the RSS pubDate. It is set by CollectMetadata.
+	ReleaseTime() time.Time
+}
+
+// resolveAlbum returns album, falling back to artist when album is empty so the
+// album tag inherits the artist value.
+func resolveAlbum(album, artist string) string {
+	if album == "" {
+		return artist
+	}
+	return album
+}
+
+// resolveReleaseDate normalises the --date flag value to the tag form, or
+// formats fallback when the flag is empty. full keeps the day (and any time of
+// day) instead of truncating to "YYYY-MM".
+func resolveReleaseDate(flagDate string, full bool, fallback time.Time) (string, error) {
+	if flagDate == "" {
+		return encoder.FormatReleaseDate(fallback, full), nil
+	}
+
+	date, err := encoder.NormaliseReleaseDate(flagDate, full)
+	if err != nil {
+		return "", fmt.Errorf("invalid --date flag: %w", err)
+	}
+	return date, nil
+}
+
+// resolveReleaseTime returns the --date flag value as a time, or fallback when
+// the flag is empty. The flag has already passed validation by then, so a parse
+// failure cannot occur in practice and also falls back.
+func resolveReleaseTime(flagDate string, fallback time.Time) time.Time {
+	if flagDate == "" {
+		return fallback
+	}
+	t, err := encoder.ParseReleaseDate(flagDate)
+	if err != nil {
+		return fallback
+	}
+	return t
+}
+
+// resolveTitleFormat returns the title tag template from the --title-format and
+// --no-number-prefix flags. The flags are mutually exclusive; with neither set
+// the encoder default "{num}: {title}" applies.
+func resolveTitleFormat(format string, noNumberPrefix bool) (string, error) {
+	if noNumberPrefix {
+		if format != "" {
+			return "", fmt.Errorf("--title-format and --no-number-prefix cannot be used together")
+		}
+		return "{title}", nil
+	}
+	if format == "" {
+		return encoder.DefaultTitleFormat, nil
+	}
+	if err := encoder.ValidateTitleFormat(format); err != nil {
+		return "", fmt.Errorf("invalid --title-format flag: %w", err)
+	}
+	return format, nil
+}
+
+// EpisodeOptions holds the episode metadata and cover fields a workflow needs,
+// as given on the command line. Run completes EpisodeMD and trims the text
+// fields before handing a copy to the workflow, so workflow methods read their
+// inputs from receiver data.
+type EpisodeOptions struct {
+	EpisodeMD string
+	Num       string
+	Title     string
+	Artist    string
+	Album     string
+	Date      string
+	FullDate  bool
+	Comments  []string
+	Cover     string
+	NoCover   bool // embed no artwork; Cover and the frontmatter image are ignored
+	Explicit  bool
+	// AlbumArtist is written to the album artist tag (TPE2 in MP3); Hugo
+	// mode defaults it to the artist.
+	AlbumArtist string
+	// Yes and NoUpdate answer the Hugo frontmatter update prompt in advance;
+	// Diff reports the update as a unified diff instead of writing it.
+	Yes      bool
+	NoUpdate bool
+	Diff     bool
+}
+
+// trimmed returns a copy of the options with leading and trailing whitespace
+// removed from the free-text metadata fields, so values pasted from a CMS do
+// not carry stray spaces into the tags. Comments that are empty once trimmed
+// are dropped. Paths are left untouched.
+func (o EpisodeOptions) trimmed() EpisodeOptions {
+	o.Num = strings.TrimSpace(o.Num)
+	o.Title = strings.TrimSpace(o.Title)
+	o.Artist = strings.TrimSpace(o.Artist)
+	o.Album = strings.TrimSpace(o.Album)
+	o.AlbumArtist = strings.TrimSpace(o.AlbumArtist)
+	o.Date = strings.TrimSpace(o.Date)
+	var comments []string
+	for _, comment := range o.Comments {
+		if comment = strings.TrimSpace(comment); comment != "" {
+			comments = append(comments, comment)
+		}
+	}
+	o.Comments = comments
+	return o
+}
+
+// withInputTags fills metadata fields left empty on the command line from the
+// input file's own tags, so a tagged WAV or FLAC need not be re-typed. Flags
+// always win. A date or track number that would not pass validation is ignored
+// rather than turned into a confusing flag error. It returns the updated
+// options and the names of the fields taken from the input.
+func (o EpisodeOptions) withInputTags(tags map[string]string) (EpisodeOptions, []string) {
+	var used []string
+	adopt := func(field *string, name string, keys ...string) {
+		if *field != "" {
+			return
+		}
+		for _, key := range keys {
+			if value := strings.TrimSpace(tags[key]); value != "" {
+				*field = value
+				used = append(used, name)
+				return
+			}
+		}
+	}
+
+	adopt(&o.Title, "title", "title")
+	adopt(&o.Artist, "artist", "artist", "album_artist")
+	adopt(&o.Album, "album", "album")
+
+	if o.Date == "" {
+		if date := strings.TrimSpace(tags["date"]); date != "" {
+			if _, err := encoder.NormaliseReleaseDate(date, o.FullDate); err == nil {
+				o.Date = date
+				used = append(used, "date")
+			}
+		}
+	}
+
+	if o.Num == "" {
+		// Track tags are often "N/total"; only the episode part is wanted.
+		track, _, _ := strings.Cut(strings.TrimSpace(tags["track"]), "/")
+		if num, err := encoder.ParseEpisodeNumber(track); err == nil {
+			o.Num = num
+			used = append(used, "num")
+		}
+	}
+
+	if len(o.Comments) == 0 {
+		if comment := strings.TrimSpace(tags["comment"]); comment != "" {
+			o.Comments = []string{comment}
+			used = append(used, "comment")
+		}
+	}
+
+	return o, used
+}
+
+// newWorkflow returns the Workflow implementation for the given mode, populated
+// with the parsed CLI options.
+func newWorkflow(mode WorkflowMode, opts EpisodeOptions, ui UI) Workflow {
+	switch mode {
+	case HugoMode:
+		return &HugoWorkflow{opts: opts, ui: ui}
+	case StandaloneMode:
+		return &StandaloneWorkflow{opts: opts}
+	default:
+		panic("unknown workflow mode")
+	}
+}
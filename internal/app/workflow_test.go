@@ -0,0 +1,100 @@
+package app
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestResolveTitleFormat tests the --title-format and --no-number-prefix flag
+// combinations.
+func TestResolveTitleFormat(t *testing.T) {
+	tests := []struct {
+		name           string
+		format         string
+		noNumberPrefix bool
+		want           string
+		errMatch       string
+	}{
+		{name: "default", want: "{num}: {title}"},
+		{name: "custom template", format: "{title} (Ep {num})", want: "{title} (Ep {num})"},
+		{name: "no number prefix", noNumberPrefix: true, want: "{title}"},
+		{name: "template without title", format: "Episode {num}", errMatch: "must contain {title}"},
+		{name: "both flags", format: "{title}", noNumberPrefix: true, errMatch: "cannot be used together"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveTitleFormat(tt.format, tt.noNumberPrefix)
+			if tt.errMatch != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errMatch) {
+					t.Errorf("resolveTitleFormat() error = %v, want it to contain %q", err, tt.errMatch)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveTitleFormat() unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("resolveTitleFormat() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestEpisodeOptionsWithInputTags verifies that input file tags fill only empty
+// fields, that invalid dates and track numbers are skipped, and that the
+// adopted field names are reported.
+func TestEpisodeOptionsWithInputTags(t *testing.T) {
+	tags := map[string]string{
+		"title":        " Tagged Title ",
+		"album_artist": "Tagged Artist",
+		"album":        "Tagged Album",
+		"date":         "2025-10-14",
+		"track":        "67/100",
+		"comment":      "https://example.com",
+	}
+
+	t.Run("fills empty fields", func(t *testing.T) {
+		opts, used := EpisodeOptions{}.withInputTags(tags)
+
+		want := EpisodeOptions{
+			Num:      "67",
+			Title:    "Tagged Title",
+			Artist:   "Tagged Artist",
+			Album:    "Tagged Album",
+			Date:     "2025-10-14",
+			Comments: []string{"https://example.com"},
+		}
+		if opts.Num != want.Num || opts.Title != want.Title || opts.Artist != want.Artist ||
+			opts.Album != want.Album || opts.Date != want.Date || !slices.Equal(opts.Comments, want.Comments) {
+			t.Errorf("withInputTags = %+v, want %+v", opts, want)
+		}
+		if wantUsed := []string{"title", "artist", "album", "date", "num", "comment"}; !slices.Equal(used, wantUsed) {
+			t.Errorf("used = %v, want %v", used, wantUsed)
+		}
+	})
+
+	t.Run("flags win", func(t *testing.T) {
+		opts, used := EpisodeOptions{Title: "Flag Title", Num: "1"}.withInputTags(tags)
+		if opts.Title != "Flag Title" || opts.Num != "1" {
+			t.Errorf("flags overridden: Title = %q, Num = %q", opts.Title, opts.Num)
+		}
+		if slices.Contains(used, "title") || slices.Contains(used, "num") {
+			t.Errorf("used = %v, should not list flag-set fields", used)
+		}
+	})
+
+	t.Run("skips invalid date and track", func(t *testing.T) {
+		opts, used := EpisodeOptions{}.withInputTags(map[string]string{
+			"date":  "last Tuesday",
+			"track": "A1",
+		})
+		if opts.Date != "" || opts.Num != "" {
+			t.Errorf("invalid tags adopted: Date = %q, Num = %q", opts.Date, opts.Num)
+		}
+		if len(used) != 0 {
+			t.Errorf("used = %v, want none", used)
+		}
+	})
+}
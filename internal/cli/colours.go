@@ -1,21 +1,75 @@
 package cli
 
-import "charm.land/lipgloss/v2"
+import (
+	"fmt"
+	"image/color"
+	"os"
+	"regexp"
+	"strconv"
+
+	"charm.land/lipgloss/v2"
+)
 
 // Disco ball colour palette 🪩
 // Cool blues, cyans, purples and silvers - like light reflecting off a glitter ball
+//
+// Each colour can be overridden with a JIVEDROP_<NAME> environment variable,
+// e.g. JIVEDROP_PRIMARY=#ff0000. The overrides are read here, as the package
+// initialises, so every style in cli and ui is built from the final palette.
 var (
-	PrimaryColor   = lipgloss.Color("#00BFFF") // Deep sky blue - core disco reflection
-	AccentColor    = lipgloss.Color("#00FFFF") // Electric cyan - sparkling highlights
-	SuccessColor   = lipgloss.Color("#00CED1") // Dark turquoise - cool success
-	MutedColor     = lipgloss.Color("#778899") // Light slate gray
-	HighlightColor = lipgloss.Color("#E0E0E0") // Silver/white - mirror reflection
-	TextColor      = lipgloss.Color("#FFFFFF") // White
-	ErrorColor     = lipgloss.Color("#DA70D6") // Orchid - distinct but cool
-	SecondaryColor = lipgloss.Color("#9370DB") // Medium purple - disco purple tones
-	BorderColor    = lipgloss.Color("#00BFFF") // Deep sky blue - glittery border
+	PrimaryColor   = themeColour("PRIMARY", "#00BFFF")   // Deep sky blue - core disco reflection
+	AccentColor    = themeColour("ACCENT", "#00FFFF")    // Electric cyan - sparkling highlights
+	SuccessColor   = themeColour("SUCCESS", "#00CED1")   // Dark turquoise - cool success
+	MutedColor     = themeColour("MUTED", "#778899")     // Light slate gray
+	HighlightColor = themeColour("HIGHLIGHT", "#E0E0E0") // Silver/white - mirror reflection
+	TextColor      = themeColour("TEXT", "#FFFFFF")      // White
+	ErrorColor     = themeColour("ERROR", "#DA70D6")     // Orchid - distinct but cool
+	SecondaryColor = themeColour("SECONDARY", "#9370DB") // Medium purple - disco purple tones
+	BorderColor    = themeColour("BORDER", "#00BFFF")    // Deep sky blue - glittery border
 
 	// Disco ball gradient colours (indigo → white)
-	GradientIndigo = lipgloss.Color("#4B0082") // Deep indigo
-	GradientWhite  = lipgloss.Color("#E0E0E0") // Silver/white
+	GradientIndigo = themeColour("GRADIENT_START", "#4B0082") // Deep indigo
+	GradientWhite  = themeColour("GRADIENT_END", "#E0E0E0")   // Silver/white
 )
+
+// themeEnvPrefix prefixes the palette override environment variables.
+const themeEnvPrefix = "JIVEDROP_"
+
+// hexColour matches the #RGB and #RRGGBB forms a palette override may use.
+var hexColour = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// themeWarnings collects the overrides that were ignored, for ThemeWarnings.
+var themeWarnings []string
+
+// themeColour returns the colour set in JIVEDROP_<name>, or def when the
+// variable is unset or not a valid colour.
+func themeColour(name, def string) color.Color {
+	key := themeEnvPrefix + name
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return lipgloss.Color(def)
+	}
+	if !validThemeColour(value) {
+		themeWarnings = append(themeWarnings,
+			fmt.Sprintf("Ignoring %s=%q: expected a #RRGGBB or #RGB hex colour, or an ANSI colour number 0-255", key, value))
+		return lipgloss.Color(def)
+	}
+	return lipgloss.Color(value)
+}
+
+// validThemeColour reports whether value is a hex colour or an ANSI 256
+// colour number, the forms lipgloss.Color understands.
+func validThemeColour(value string) bool {
+	if hexColour.MatchString(value) {
+		return true
+	}
+	n, err := strconv.Atoi(value)
+	return err == nil && n >= 0 && n <= 255
+}
+
+// ThemeWarnings returns a message for each palette override that was ignored
+// because its value is not a colour, so the caller can report them once the
+// CLI is up.
+func ThemeWarnings() []string {
+	return themeWarnings
+}
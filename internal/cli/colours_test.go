@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"charm.land/lipgloss/v2"
+)
+
+// TestThemeColour verifies an override replaces the default, and that an
+// unset, empty or invalid override keeps it, with a warning for the invalid
+// one.
+func TestThemeColour(t *testing.T) {
+	orig := themeWarnings
+	t.Cleanup(func() { themeWarnings = orig })
+
+	tests := []struct {
+		name    string
+		value   string
+		set     bool
+		want    string
+		warning bool
+	}{
+		{name: "unset", want: "#00BFFF"},
+		{name: "empty", value: "", set: true, want: "#00BFFF"},
+		{name: "hex", value: "#ff0000", set: true, want: "#ff0000"},
+		{name: "short hex", value: "#f00", set: true, want: "#f00"},
+		{name: "ansi number", value: "201", set: true, want: "201"},
+		{name: "name", value: "red", set: true, want: "#00BFFF", warning: true},
+		{name: "ansi out of range", value: "256", set: true, want: "#00BFFF", warning: true},
+		{name: "hex without hash", value: "ff0000", set: true, want: "#00BFFF", warning: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			themeWarnings = nil
+			if tt.set {
+				t.Setenv("JIVEDROP_TEST", tt.value)
+			}
+
+			got := themeColour("TEST", "#00BFFF")
+			if got != lipgloss.Color(tt.want) {
+				t.Errorf("themeColour() = %v, want %v", got, lipgloss.Color(tt.want))
+			}
+			if tt.warning != (len(ThemeWarnings()) == 1) {
+				t.Errorf("ThemeWarnings() = %q, want warning %v", ThemeWarnings(), tt.warning)
+			}
+			if tt.warning && !strings.Contains(ThemeWarnings()[0], "JIVEDROP_TEST") {
+				t.Errorf("warning %q does not name the variable", ThemeWarnings()[0])
+			}
+		})
+	}
+}
@@ -94,12 +94,19 @@ func StyledHelpPrinter(options kong.HelpOptions) kong.HelpPrinter {
 					sb.WriteString(" ")
 					sb.WriteString(helpDefaultStyle.Render("(default: " + flag.defaultVal + ")"))
 				}
+				if flag.env != "" {
+					sb.WriteString(" ")
+					sb.WriteString(helpDefaultStyle.Render("[$" + flag.env + "]"))
+				}
 				sb.WriteString("\n")
 			}
+			sb.WriteString("\n")
+			sb.WriteString(helpDefaultStyle.Render("Flags can also be set with the [$VARIABLE] shown. A flag on the command line wins over the environment, which wins over the default."))
+			sb.WriteString("\n")
 		}
 
 		sb.WriteString("\n")
-		fmt.Fprint(ctx.Stdout, sb.String())
+		fmt.Fprint(newColourWriter(ctx.Stdout), sb.String())
 		return nil
 	})
 }
@@ -115,6 +122,7 @@ type flag struct {
 	flags      string
 	help       string
 	defaultVal string
+	env        string // environment variable that sets the flag, if any
 }
 
 // getArguments extracts arguments from Kong context
@@ -145,21 +153,33 @@ func getFlags(ctx *kong.Context) []flag {
 			continue // Already added above.
 		}
 
+		// Negatable flags use kong's default --no-<flag> form.
+		name := f.Name
+		if f.IsBool() && f.Tag.Negatable != "" {
+			name = "[no-]" + name
+		}
+
 		var flagStr string
 		if f.Short != 0 {
-			flagStr = fmt.Sprintf("-%c, --%s", f.Short, f.Name)
+			flagStr = fmt.Sprintf("-%c, --%s", f.Short, name)
 		} else {
-			flagStr = fmt.Sprintf("--%s", f.Name)
+			flagStr = fmt.Sprintf("--%s", name)
 		}
 
 		if !f.IsBool() && f.PlaceHolder != "" {
 			flagStr += "=" + strings.ToUpper(f.PlaceHolder)
 		}
 
+		var env string
+		if len(f.Envs) > 0 {
+			env = f.Envs[0]
+		}
+
 		flags = append(flags, flag{
 			flags:      flagStr,
 			help:       f.Help,
 			defaultVal: f.FormatPlaceHolder(),
+			env:        env,
 		})
 	}
 
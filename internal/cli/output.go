@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/colorprofile"
+)
+
+// noColour is set when colour is off for the whole run: by a non-empty
+// NO_COLOR (https://no-color.org/) at startup, or by DisableColour.
+var noColour = os.Getenv("NO_COLOR") != ""
+
+// Styled output goes through colour profile writers, which downsample the
+// lipgloss escape codes to what the stream supports: none at all when it is
+// not a terminal, such as a pipe into a log file, and no colour when noColour
+// is set.
+var (
+	stdout = newColourWriter(os.Stdout)
+	stderr = newColourWriter(os.Stderr)
+)
+
+// newColourWriter wraps w in a writer that renders for its detected colour
+// profile, capped at plain ASCII styling when colour is off.
+func newColourWriter(w io.Writer) *colorprofile.Writer {
+	cw := colorprofile.NewWriter(w, os.Environ())
+	if noColour {
+		cw.Profile = min(cw.Profile, colorprofile.ASCII)
+	}
+	return cw
+}
+
+// DisableColour turns colour off for all later output, for --no-color. Bold
+// and other text decoration remain on a terminal.
+func DisableColour() {
+	noColour = true
+	stdout.Profile = min(stdout.Profile, colorprofile.ASCII)
+	stderr.Profile = min(stderr.Profile, colorprofile.ASCII)
+}
+
+// MessagesToStderr sends all later Print* output to stderr, leaving stdout
+// free for a single machine-readable result.
+func MessagesToStderr() {
+	stdout = stderr
+}
+
+// ColourDisabled reports whether colour is off for the run, through NO_COLOR
+// or --no-color. Callers use it to hold back other decorative escape codes too.
+func ColourDisabled() bool {
+	return noColour
+}
+
+// ColourProfile returns the profile stdout output is rendered with, so the
+// Bubbletea UI, which writes to the terminal itself, matches the Print*
+// helpers.
+func ColourProfile() colorprofile.Profile {
+	return stdout.Profile
+}
+
+// runLog receives a plain-text copy of everything the Print* helpers write
+// while a --log-file is open; nil otherwise.
+var runLog io.Writer
+
+// printf writes styled output to w and, when a run log is open, the same
+// line with its styling stripped to the log.
+func printf(w io.Writer, format string, a ...any) {
+	fmt.Fprintf(w, format, a...)
+	if runLog != nil {
+		fmt.Fprintf(runLog, format, a...)
+	}
+}
+
+// StartRunLog opens path for appending and copies all later Print* output to
+// it as plain text, under a header naming the time and command line of the
+// run. Close the returned file at the end of the run to stop logging.
+func StartRunLog(path string, args []string) (io.Closer, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644) //nolint:gosec // path is the user-provided --log-file path
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log file: %w", err)
+	}
+
+	if _, err := fmt.Fprintf(f, "\n=== %s jivedrop %s\n", time.Now().Format(time.RFC3339), strings.Join(args, " ")); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to write log file: %w", err)
+	}
+
+	// NoTTY strips every escape code, so the log reads as plain text.
+	runLog = &colorprofile.Writer{Forward: f, Profile: colorprofile.NoTTY}
+	return closerFunc(func() error {
+		runLog = nil
+		return f.Close()
+	}), nil
+}
+
+// closerFunc adapts a function to io.Closer.
+type closerFunc func() error
+
+func (c closerFunc) Close() error { return c() }
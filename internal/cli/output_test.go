@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/colorprofile"
+)
+
+// TestDisableColour verifies --no-color strips colour codes from a terminal
+// that supports them while keeping the text.
+func TestDisableColour(t *testing.T) {
+	origStdout, origStderr, origNoColour := stdout, stderr, noColour
+	t.Cleanup(func() { stdout, stderr, noColour = origStdout, origStderr, origNoColour })
+
+	var buf bytes.Buffer
+	stdout = &colorprofile.Writer{Forward: &buf, Profile: colorprofile.TrueColor}
+	stderr = &colorprofile.Writer{Forward: &buf, Profile: colorprofile.TrueColor}
+
+	PrintWarning("disk nearly full")
+	if !strings.Contains(buf.String(), "\x1b[") || !strings.Contains(buf.String(), "38;") {
+		t.Fatalf("TrueColor output has no colour codes: %q", buf.String())
+	}
+
+	buf.Reset()
+	DisableColour()
+	PrintWarning("disk nearly full")
+	if strings.Contains(buf.String(), "38;") {
+		t.Errorf("output still coloured after DisableColour: %q", buf.String())
+	}
+	if !strings.Contains(buf.String(), "disk nearly full") {
+		t.Errorf("output lost its text: %q", buf.String())
+	}
+	if ColourProfile() != colorprofile.ASCII {
+		t.Errorf("ColourProfile() = %v, want ASCII", ColourProfile())
+	}
+}
+
+// TestNewColourWriter_NotTerminal verifies output that is not a terminal, such
+// as a log file, gets no escape codes at all.
+func TestNewColourWriter_NotTerminal(t *testing.T) {
+	var buf bytes.Buffer
+	w := newColourWriter(&buf)
+	if _, err := w.Write([]byte(WarningStyle.Render("Warning:"))); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if got := buf.String(); got != "Warning:" {
+		t.Errorf("output = %q, want plain %q", got, "Warning:")
+	}
+}
+
+// TestStartRunLog verifies the log file gets a header per run and a plain copy
+// of the styled output, appending rather than truncating, and that logging
+// stops on Close.
+func TestStartRunLog(t *testing.T) {
+	origStdout, origStderr := stdout, stderr
+	t.Cleanup(func() { stdout, stderr = origStdout, origStderr })
+	stdout = &colorprofile.Writer{Forward: &bytes.Buffer{}, Profile: colorprofile.TrueColor}
+	stderr = &colorprofile.Writer{Forward: &bytes.Buffer{}, Profile: colorprofile.TrueColor}
+
+	path := filepath.Join(t.TempDir(), "runs.log")
+	for _, episode := range []string{"66", "67"} {
+		log, err := StartRunLog(path, []string{"ep.flac", "--num", episode})
+		if err != nil {
+			t.Fatalf("StartRunLog: %v", err)
+		}
+		PrintLabelValue("Episode:", episode)
+		PrintError("cover art not accessible")
+		if err := log.Close(); err != nil {
+			t.Fatalf("Close: %v", err)
+		}
+	}
+	PrintInfo("after close")
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read log: %v", err)
+	}
+	got := string(data)
+
+	if strings.Contains(got, "\x1b[") {
+		t.Errorf("log contains escape codes: %q", got)
+	}
+	if n := strings.Count(got, "jivedrop ep.flac --num"); n != 2 {
+		t.Errorf("log has %d run headers, want 2:\n%s", n, got)
+	}
+	for _, want := range []string{"Episode: 66\n", "Episode: 67\n", "Error: cover art not accessible\n"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("log missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "after close") {
+		t.Errorf("log written after Close:\n%s", got)
+	}
+}
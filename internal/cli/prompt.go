@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// stdin buffers standard input for Confirm and Prompt. It is shared so that
+// input read ahead while answering one question, such as several pasted
+// lines, is still there for the next.
+var stdin = bufio.NewReader(os.Stdin)
+
+// Confirm asks a yes/no question on stdout and reads the answer from stdin.
+// It accepts y, yes, n and no in any case, re-prompts on anything else, and
+// returns false on an empty answer or EOF, so the default is always No.
+func Confirm(prompt string) bool {
+	return confirm(stdin, os.Stdout, prompt)
+}
+
+// confirm implements Confirm against any reader and writer.
+func confirm(reader *bufio.Reader, w io.Writer, prompt string) bool {
+	for {
+		fmt.Fprintf(w, "%s [y/N]: ", prompt)
+
+		line, err := reader.ReadString('\n')
+		switch strings.ToLower(strings.TrimSpace(line)) {
+		case "y", "yes":
+			return true
+		case "", "n", "no":
+			return false
+		}
+
+		// An unrecognised answer with no more input cannot be retried.
+		if err != nil {
+			fmt.Fprintln(w)
+			return false
+		}
+		fmt.Fprintln(w, "Please answer y or n.")
+	}
+}
+
+// Prompt asks for a line of text on stdout and reads the answer from stdin,
+// trimmed of surrounding space. When validate rejects an answer its error is
+// printed and the question asked again. It reports false at EOF, leaving the
+// caller to treat the value as not given.
+func Prompt(question string, validate func(string) error) (string, bool) {
+	return prompt(stdin, os.Stdout, question, validate)
+}
+
+// prompt implements Prompt against any reader and writer.
+func prompt(reader *bufio.Reader, w io.Writer, question string, validate func(string) error) (string, bool) {
+	for {
+		fmt.Fprintf(w, "%s: ", question)
+
+		line, err := reader.ReadString('\n')
+		answer := strings.TrimSpace(line)
+		if err != nil && answer == "" {
+			fmt.Fprintln(w)
+			return "", false
+		}
+
+		verr := validate(answer)
+		if verr == nil {
+			return answer, true
+		}
+		fmt.Fprintf(w, "%s %s\n", WarningStyle.Render("Invalid:"), verr)
+		if err != nil {
+			return "", false
+		}
+	}
+}
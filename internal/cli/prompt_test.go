@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestConfirm(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    bool
+		prompts int
+	}{
+		{name: "y", input: "y\n", want: true, prompts: 1},
+		{name: "yes mixed case with spaces", input: "  YeS \n", want: true, prompts: 1},
+		{name: "n", input: "n\n", want: false, prompts: 1},
+		{name: "no", input: "No\n", want: false, prompts: 1},
+		{name: "empty defaults to no", input: "\n", want: false, prompts: 1},
+		{name: "EOF defaults to no", input: "", want: false, prompts: 1},
+		{name: "answer without newline before EOF", input: "yes", want: true, prompts: 1},
+		{name: "re-prompts on invalid input", input: "maybe later\nyes\n", want: true, prompts: 2},
+		{name: "invalid then EOF is no", input: "sure", want: false, prompts: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if got := confirm(bufio.NewReader(strings.NewReader(tt.input)), &out, "Update?"); got != tt.want {
+				t.Errorf("confirm(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+			if got := strings.Count(out.String(), "Update? [y/N]: "); got != tt.prompts {
+				t.Errorf("prompted %d times, want %d (output %q)", got, tt.prompts, out.String())
+			}
+		})
+	}
+}
+
+func TestPrompt(t *testing.T) {
+	notEmpty := func(s string) error {
+		if s == "" {
+			return errors.New("must not be empty")
+		}
+		return nil
+	}
+
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantOK  bool
+		prompts int
+	}{
+		{name: "answer", input: "  Terminal Velocity \n", want: "Terminal Velocity", wantOK: true, prompts: 1},
+		{name: "answer without newline before EOF", input: "67", want: "67", wantOK: true, prompts: 1},
+		{name: "re-prompts on invalid input", input: "\nTerminal Velocity\n", want: "Terminal Velocity", wantOK: true, prompts: 2},
+		{name: "EOF is no answer", input: "", wantOK: false, prompts: 1},
+		{name: "invalid then EOF is no answer", input: "\n", wantOK: false, prompts: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			got, ok := prompt(bufio.NewReader(strings.NewReader(tt.input)), &out, "Title", notEmpty)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("prompt(%q) = %q, %v, want %q, %v", tt.input, got, ok, tt.want, tt.wantOK)
+			}
+			if got := strings.Count(out.String(), "Title: "); got != tt.prompts {
+				t.Errorf("prompted %d times, want %d (output %q)", got, tt.prompts, out.String())
+			}
+		})
+	}
+
+	// Pasted answers arrive together; the second question still gets its own.
+	reader := bufio.NewReader(strings.NewReader("Terminal Velocity\n67\n"))
+	for _, want := range []string{"Terminal Velocity", "67"} {
+		if got, ok := prompt(reader, io.Discard, "Answer", notEmpty); got != want || !ok {
+			t.Errorf("prompt() = %q, %v, want %q, true", got, ok, want)
+		}
+	}
+}
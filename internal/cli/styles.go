@@ -1,10 +1,10 @@
 package cli
 
 import (
-	"fmt"
-	"os"
+	"strings"
 
 	"charm.land/lipgloss/v2"
+	"charm.land/lipgloss/v2/table"
 )
 
 var (
@@ -42,6 +42,10 @@ var (
 			Bold(true).
 			Foreground(TextColor)
 
+	// Unified diff lines
+	diffRemovedStyle = lipgloss.NewStyle().Foreground(ErrorColor)
+	diffAddedStyle   = lipgloss.NewStyle().Foreground(SuccessColor)
+
 	// Box style for framed content
 	BoxStyle = lipgloss.NewStyle().
 			Border(lipgloss.RoundedBorder()).
@@ -51,40 +55,115 @@ var (
 			MarginBottom(1)
 )
 
-// PrintVersion prints version information
-func PrintVersion(version string) {
-	fmt.Println(TitleStyle.Render("Jivedrop 🪩"))
-	fmt.Printf("%s %s\n", KeyStyle.Render("Version:"), ValueStyle.Render(version))
-	fmt.Println()
+// PrintVersion prints version information, including the linked FFmpeg and
+// MP3 encoder so bug reports say which libraries did the encoding. Empty
+// library values print as "unknown".
+func PrintVersion(version, ffmpegVersion, avcodecVersion, mp3Encoder string) {
+	printf(stdout, "%s\n", TitleStyle.Render("Jivedrop 🪩"))
+	printf(stdout, "%s %s\n", KeyStyle.Render("Version:"), ValueStyle.Render(version))
+
+	libs := orUnknown(ffmpegVersion)
+	if avcodecVersion != "" {
+		libs += " (libavcodec " + avcodecVersion + ")"
+	}
+	printf(stdout, "%s %s\n", KeyStyle.Render("FFmpeg:"), ValueStyle.Render(libs))
+	printf(stdout, "%s %s\n", KeyStyle.Render("MP3 encoder:"), ValueStyle.Render(orUnknown(mp3Encoder)))
+	printf(stdout, "\n")
+}
+
+// orUnknown returns s, or "unknown" when s is empty.
+func orUnknown(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return s
+}
+
+// SummaryField is one labelled row of a summary box.
+type SummaryField struct {
+	Label string
+	Value string
+}
+
+// PrintSummaryBox prints fields as a bordered two-column table under a title,
+// with labels and values each aligned in their own column. Fields with an
+// empty value are left out.
+func PrintSummaryBox(title string, fields []SummaryField) {
+	printf(stdout, "%s\n", renderSummaryBox(title, fields))
+}
+
+// renderSummaryBox builds the PrintSummaryBox output.
+func renderSummaryBox(title string, fields []SummaryField) string {
+	t := table.New().
+		Border(lipgloss.RoundedBorder()).
+		BorderStyle(lipgloss.NewStyle().Foreground(BorderColor)).
+		BorderColumn(false).
+		StyleFunc(func(_, col int) lipgloss.Style {
+			if col == 0 {
+				return KeyStyle.Padding(0, 1)
+			}
+			return ValueStyle.Padding(0, 1)
+		})
+	for _, f := range fields {
+		if f.Value != "" {
+			t.Row(f.Label, f.Value)
+		}
+	}
+
+	return SuccessStyle.Render("✓ "+title) + "\n" + t.Render()
 }
 
 // PrintError prints an error message
 func PrintError(message string) {
-	fmt.Fprintf(os.Stderr, "%s %s\n", ErrorStyle.Render("Error:"), message)
+	printf(stderr, "%s %s\n", ErrorStyle.Render("Error:"), message)
 }
 
 // PrintWarning prints a warning message
 func PrintWarning(message string) {
-	fmt.Printf("%s %s\n", WarningStyle.Render("Warning:"), message)
+	printf(stdout, "%s %s\n", WarningStyle.Render("Warning:"), message)
 }
 
 // PrintSuccess prints a success message
 func PrintSuccess(message string) {
-	fmt.Printf("%s %s\n", SuccessStyle.Render("✓"), message)
+	printf(stdout, "%s %s\n", SuccessStyle.Render("✓"), message)
 }
 
 // PrintInfo prints an informational message
 func PrintInfo(message string) {
-	fmt.Printf("%s %s\n", KeyStyle.Render("•"), message)
+	printf(stdout, "%s %s\n", KeyStyle.Render("•"), message)
+}
+
+// PrintCover prints the edge length and size of the cover art being embedded
+func PrintCover(edge int, size string) {
+	printf(stdout, "%s %s %dx%d, %s\n", KeyStyle.Render("•"), KeyStyle.Render("Cover art:"), edge, edge, size)
+}
+
+// PrintDiff prints a unified diff, with removed lines in the error colour and
+// added lines in the success colour.
+func PrintDiff(diff string) {
+	for line := range strings.Lines(diff) {
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case strings.HasPrefix(line, "---"), strings.HasPrefix(line, "+++"):
+			line = KeyStyle.Render(line)
+		case strings.HasPrefix(line, "@@"):
+			line = HighlightStyle.Render(line)
+		case strings.HasPrefix(line, "-"):
+			line = diffRemovedStyle.Render(line)
+		case strings.HasPrefix(line, "+"):
+			line = diffAddedStyle.Render(line)
+		}
+		printf(stdout, "%s\n", line)
+	}
 }
 
 // PrintLabelValue prints a label with muted style and a value
 // Used for summary output like "Episode: 67 - Title"
 func PrintLabelValue(label, value string) {
-	fmt.Printf("%s %s\n", KeyStyle.Render(label), value)
+	printf(stdout, "%s %s\n", KeyStyle.Render(label), value)
 }
 
 // PrintSuccessLabel prints a success checkmark with a muted label and value
 func PrintSuccessLabel(label, value string) {
-	fmt.Printf("%s %s %s\n", SuccessStyle.Render("\u2713"), KeyStyle.Render(label), value)
+	printf(stdout, "%s %s %s\n", SuccessStyle.Render("\u2713"), KeyStyle.Render(label), value)
 }
@@ -0,0 +1,43 @@
+package cli
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// ansiSGR matches the colour and weight escape codes lipgloss emits.
+var ansiSGR = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+// TestRenderSummaryBox verifies the box carries the title and each non-empty
+// field, with the values aligned in one column.
+func TestRenderSummaryBox(t *testing.T) {
+	out := renderSummaryBox("Complete", []SummaryField{
+		{Label: "Episode", Value: "67 - Mirrors"},
+		{Label: "Output", Value: "LMP67.mp3"},
+		{Label: "Cover", Value: ""},
+	})
+
+	lines := strings.Split(ansiSGR.ReplaceAllString(out, ""), "\n")
+	if !strings.Contains(lines[0], "Complete") {
+		t.Errorf("first line = %q, want the title", lines[0])
+	}
+
+	var valueCols []int
+	for _, line := range lines {
+		if strings.Contains(line, "Cover") {
+			t.Errorf("empty field rendered: %q", line)
+		}
+		for _, value := range []string{"67 - Mirrors", "LMP67.mp3"} {
+			if i := strings.Index(line, value); i >= 0 {
+				valueCols = append(valueCols, len([]rune(line[:i])))
+			}
+		}
+	}
+	if len(valueCols) != 2 {
+		t.Fatalf("found %d field values, want 2:\n%s", len(valueCols), out)
+	}
+	if valueCols[0] != valueCols[1] {
+		t.Errorf("values start at columns %v, want them aligned:\n%s", valueCols, out)
+	}
+}
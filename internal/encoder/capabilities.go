@@ -0,0 +1,103 @@
+package encoder
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/linuxmatters/ffmpeg-statigo"
+)
+
+// requiredFilters are the libavfilter filters every encode graph is built
+// from: the source and sink buffers, the resampler, and the format pin.
+var requiredFilters = []string{"abuffer", "abuffersink", "aresample", "aformat"}
+
+// capabilityHint tells the user how to get a complete FFmpeg. Jivedrop links
+// FFmpeg statically via ffmpeg-statigo, so a missing component means the
+// library was built without it.
+const capabilityHint = "run 'just setup' to download the full ffmpeg-statigo library, or rebuild it with this component enabled"
+
+// CheckCapabilities verifies that the linked FFmpeg build provides everything
+// needed to encode to format: the audio encoder, the output muxer, and the
+// filter-graph filters. It returns an error naming the first missing component
+// so a minimal FFmpeg build fails up front with an actionable message rather
+// than partway through Initialize.
+func CheckCapabilities(format string) error {
+	preset, ok := presetFor(format)
+	if !ok {
+		return fmt.Errorf("unknown output format: %q", format)
+	}
+
+	if findEncoder(preset) == nil {
+		return missingComponentError(fmt.Sprintf("%s encoder", strings.ToUpper(preset.name)), preset.library)
+	}
+
+	muxerPtr := ffmpeg.ToCStr(preset.muxer)
+	muxer := ffmpeg.AVGuessFormat(muxerPtr, nil, nil)
+	muxerPtr.Free()
+	if muxer == nil {
+		return missingComponentError("muxer", preset.muxer)
+	}
+
+	for _, name := range requiredFilters {
+		if ffmpeg.AVFilterGetByName(ffmpeg.GlobalCStr(name)) == nil {
+			return missingComponentError("filter", name)
+		}
+	}
+
+	return nil
+}
+
+// findEncoder looks up the preset's encoder, preferring the named encoder
+// when set and falling back to the codec ID. It returns nil when FFmpeg was
+// built without a matching encoder.
+func findEncoder(preset formatPreset) *ffmpeg.AVCodec {
+	if preset.encoderName != "" {
+		namePtr := ffmpeg.ToCStr(preset.encoderName)
+		encoder := ffmpeg.AVCodecFindEncoderByName(namePtr)
+		namePtr.Free()
+		if encoder != nil {
+			return encoder
+		}
+	}
+	return ffmpeg.AVCodecFindEncoder(preset.codecID)
+}
+
+// LibraryVersions describes the linked FFmpeg build for --version and bug
+// reports. A field is empty when its query fails.
+type LibraryVersions struct {
+	FFmpeg     string // FFmpeg release, from av_version_info
+	AVCodec    string // libavcodec version as major.minor.micro
+	MP3Encoder string // encoder the mp3 preset uses, e.g. libmp3lame
+}
+
+// QueryLibraryVersions reports the linked FFmpeg and MP3 encoder versions.
+// It never fails: anything the build cannot report is left empty.
+func QueryLibraryVersions() LibraryVersions {
+	var v LibraryVersions
+	if info := ffmpeg.AVVersionInfo(); info != nil {
+		v.FFmpeg = info.String()
+	}
+	v.AVCodec = formatLibVersion(ffmpeg.AVCodecVersion())
+	if preset, ok := presetFor("mp3"); ok {
+		if codec := findEncoder(preset); codec != nil && codec.Name() != nil {
+			v.MP3Encoder = codec.Name().String()
+		}
+	}
+	return v
+}
+
+// formatLibVersion renders an FFmpeg AV_VERSION_INT, which packs the major,
+// minor and micro versions into one integer, as major.minor.micro. Zero means
+// the query failed and renders as "".
+func formatLibVersion(v uint) string {
+	if v == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d.%d.%d", v>>16, (v>>8)&0xff, v&0xff)
+}
+
+// missingComponentError formats the capability failure for a component kind
+// (e.g. "MP3 encoder", "filter") and the FFmpeg name of what is missing.
+func missingComponentError(kind, name string) error {
+	return fmt.Errorf("FFmpeg is missing the %s %q: %s", kind, name, capabilityHint)
+}
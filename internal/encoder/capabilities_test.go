@@ -0,0 +1,63 @@
+package encoder
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestCheckCapabilities_Integration verifies that the linked FFmpeg build
+// provides the encoder, muxer, and filters for every preset format.
+func TestCheckCapabilities_Integration(t *testing.T) {
+	for name := range formatPresets {
+		if err := CheckCapabilities(name); err != nil {
+			t.Errorf("CheckCapabilities(%q): %v", name, err)
+		}
+	}
+}
+
+// TestCheckCapabilitiesUnknownFormat verifies that an unknown format is
+// rejected before any FFmpeg lookup.
+func TestCheckCapabilitiesUnknownFormat(t *testing.T) {
+	err := CheckCapabilities("flac")
+	if err == nil || !strings.Contains(err.Error(), "unknown output format") {
+		t.Errorf("CheckCapabilities(flac): got %v, want unknown output format error", err)
+	}
+}
+
+// TestMissingComponentError verifies the message names the missing component
+// and tells the user how to fix it.
+func TestMissingComponentError(t *testing.T) {
+	msg := missingComponentError("MP3 encoder", "libmp3lame").Error()
+	for _, want := range []string{"MP3 encoder", `"libmp3lame"`, "just setup"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("missingComponentError: %q does not contain %q", msg, want)
+		}
+	}
+}
+
+// TestFormatLibVersion verifies the AV_VERSION_INT unpacking and that a failed
+// query renders as empty.
+func TestFormatLibVersion(t *testing.T) {
+	tests := map[uint]string{
+		61<<16 | 19<<8 | 100: "61.19.100",
+		1 << 16:              "1.0.0",
+		0:                    "",
+	}
+	for v, want := range tests {
+		if got := formatLibVersion(v); got != want {
+			t.Errorf("formatLibVersion(%#x) = %q, want %q", v, got, want)
+		}
+	}
+}
+
+// TestQueryLibraryVersions_Integration verifies the linked build reports its
+// FFmpeg version and an MP3 encoder.
+func TestQueryLibraryVersions_Integration(t *testing.T) {
+	v := QueryLibraryVersions()
+	if v.FFmpeg == "" || v.AVCodec == "" {
+		t.Errorf("FFmpeg version not reported: %+v", v)
+	}
+	if v.MP3Encoder == "" {
+		t.Errorf("MP3 encoder not reported: %+v", v)
+	}
+}
@@ -0,0 +1,310 @@
+package encoder
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/linuxmatters/ffmpeg-statigo"
+)
+
+// Chapter is a single chapter mark: where it starts in the episode and its
+// title. A chapter runs until the next one starts, and the last runs to the end
+// of the audio.
+type Chapter struct {
+	Start time.Duration
+	Title string
+}
+
+// String formats the chapter as a chapter file line, HH:MM:SS.mmm and the
+// title, which ParseChapters reads back.
+func (c Chapter) String() string {
+	ms := c.Start.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d.%03d %s", ms/3_600_000, ms/60_000%60, ms/1000%60, ms%1000, c.Title)
+}
+
+// ParseChapters reads a plain-text chapter list, one chapter per line as a
+// timestamp followed by the title:
+//
+//	00:00 Introduction
+//	04:12 News
+//	1:02:45.500 Listener feedback
+//
+// Timestamps are [HH:]MM:SS with optional fractional seconds. Blank lines and
+// lines starting with # are ignored. Chapters must be in ascending order.
+func ParseChapters(r io.Reader) ([]Chapter, error) {
+	var chapters []Chapter
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		stamp, title, _ := strings.Cut(line, " ")
+		title = strings.TrimSpace(title)
+		start, err := parseChapterTimestamp(stamp)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+		if title == "" {
+			return nil, fmt.Errorf("line %d: chapter at %s has no title", lineNum, stamp)
+		}
+		if n := len(chapters); n > 0 && start <= chapters[n-1].Start {
+			return nil, fmt.Errorf("line %d: chapter %q does not start after the previous chapter", lineNum, title)
+		}
+
+		chapters = append(chapters, Chapter{Start: start, Title: title})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read chapters: %w", err)
+	}
+
+	return chapters, nil
+}
+
+// Chapter limits. FFmpeg's mp3 muxer writes the CTOC frame's entry count as a
+// single byte, so an MP3 with more than 255 chapters gets a table of contents
+// that disagrees with its CHAP frames. Titles have no hard limit, but each is
+// stored in its chapter frame and a multi-kilobyte title only bloats the tag
+// and gets cut off by players anyway.
+const (
+	MaxChapters           = 255
+	MaxChapterTitleLength = 200 // characters
+)
+
+// CheckChapters prepares a chapter list for the output format, returning the
+// list to write and a warning for each change made: a chapter starting no
+// later than the one before it is dropped, a title longer than
+// MaxChapterTitleLength is truncated, a repeated title is numbered so each
+// chapter can be told apart, and for MP3 the chapters past MaxChapters are
+// dropped. The input is not modified.
+func CheckChapters(chapters []Chapter, format string) ([]Chapter, []string) {
+	var warnings []string
+	out := make([]Chapter, 0, len(chapters))
+	seen := make(map[string]int)
+	for _, ch := range chapters {
+		if n := len(out); n > 0 && ch.Start <= out[n-1].Start {
+			warnings = append(warnings, fmt.Sprintf("Chapter %q does not start after %q; skipping it", ch.Title, out[n-1].Title))
+			continue
+		}
+		if title := []rune(ch.Title); len(title) > MaxChapterTitleLength {
+			ch.Title = strings.TrimSpace(string(title[:MaxChapterTitleLength-1])) + "…"
+			warnings = append(warnings, fmt.Sprintf("Chapter title at %s is %d characters; truncating it to %d", ch.Start, len(title), MaxChapterTitleLength))
+		}
+		seen[ch.Title]++
+		if n := seen[ch.Title]; n > 1 {
+			numbered := fmt.Sprintf("%s (%d)", ch.Title, n)
+			for seen[numbered] > 0 {
+				n++
+				numbered = fmt.Sprintf("%s (%d)", ch.Title, n)
+			}
+			seen[numbered]++
+			warnings = append(warnings, fmt.Sprintf("Chapter title %q is repeated; writing it as %q", ch.Title, numbered))
+			ch.Title = numbered
+		}
+		out = append(out, ch)
+	}
+
+	if format == "" {
+		format = "mp3"
+	}
+	if preset, _ := presetFor(format); preset.name == "mp3" && len(out) > MaxChapters {
+		warnings = append(warnings, fmt.Sprintf("MP3 holds at most %d chapters; dropping the last %d, from %s", MaxChapters, len(out)-MaxChapters, out[MaxChapters].Start))
+		out = out[:MaxChapters]
+	}
+	return out, warnings
+}
+
+// parseChapterTimestamp parses [HH:]MM:SS[.fff] into a duration.
+func parseChapterTimestamp(s string) (time.Duration, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, fmt.Errorf("invalid chapter timestamp %q: want [HH:]MM:SS", s)
+	}
+
+	seconds, err := strconv.ParseFloat(parts[len(parts)-1], 64)
+	if err != nil || math.IsNaN(seconds) || seconds < 0 || seconds >= 60 {
+		return 0, fmt.Errorf("invalid chapter timestamp %q: seconds must be 0-59", s)
+	}
+
+	var whole [2]int
+	for i, part := range parts[:len(parts)-1] {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid chapter timestamp %q", s)
+		}
+		whole[i] = n
+	}
+
+	hours, minutes := 0, whole[0]
+	if len(parts) == 3 {
+		hours, minutes = whole[0], whole[1]
+		if minutes >= 60 {
+			return 0, fmt.Errorf("invalid chapter timestamp %q: minutes must be 0-59", s)
+		}
+	}
+
+	return time.Duration(hours)*time.Hour +
+		time.Duration(minutes)*time.Minute +
+		time.Duration(seconds*float64(time.Second)), nil
+}
+
+// formatFFMetadata renders chapters as an FFMETADATA1 document in millisecond
+// units. Each chapter ends where the next begins; the last ends at total, or
+// at its own start when the audio duration is unknown.
+func formatFFMetadata(chapters []Chapter, total time.Duration) string {
+	var b strings.Builder
+	b.WriteString(";FFMETADATA1\n")
+	for i, ch := range chapters {
+		end := max(total, ch.Start)
+		if i+1 < len(chapters) {
+			end = chapters[i+1].Start
+		}
+		fmt.Fprintf(&b, "[CHAPTER]\nTIMEBASE=1/1000\nSTART=%d\nEND=%d\ntitle=%s\n",
+			ch.Start.Milliseconds(), end.Milliseconds(), escapeFFMetadata(ch.Title))
+	}
+	return b.String()
+}
+
+// JSONChaptersVersion is the version of the podcast namespace's JSON
+// chapters format that ChaptersJSON writes.
+const JSONChaptersVersion = "1.2.0"
+
+// jsonChapters is the document ChaptersJSON writes.
+type jsonChapters struct {
+	Version  string        `json:"version"`
+	Chapters []jsonChapter `json:"chapters"`
+}
+
+// jsonChapter is one chapter: its start in seconds and its title.
+type jsonChapter struct {
+	StartTime float64 `json:"startTime"`
+	Title     string  `json:"title"`
+}
+
+// ChaptersJSON renders chapters as a JSON chapters file, the format of the
+// podcast namespace's <podcast:chapters> tag, which Apple Podcasts and the
+// Podcasting 2.0 apps fetch alongside the episode. Start times are in seconds
+// to the millisecond.
+func ChaptersJSON(chapters []Chapter) ([]byte, error) {
+	doc := jsonChapters{Version: JSONChaptersVersion, Chapters: make([]jsonChapter, len(chapters))}
+	for i, ch := range chapters {
+		doc.Chapters[i] = jsonChapter{StartTime: float64(ch.Start.Milliseconds()) / 1000, Title: ch.Title}
+	}
+
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false) // titles such as "Q&A" stay readable
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return nil, fmt.Errorf("failed to encode chapters: %w", err)
+	}
+	return b.Bytes(), nil
+}
+
+// escapeFFMetadata backslash-escapes the characters FFMETADATA treats as
+// syntax: '=', ';', '#', '\' and newline.
+func escapeFFMetadata(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '=', ';', '#', '\\', '\n':
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// attachChapters hands the chapter list to the output muxer before the header
+// is written: the mp3 muxer emits ID3 CHAP/CTOC frames, the ipod muxer a
+// QuickTime chapter track, and the Ogg muxer CHAPTERxxx comments. FFmpeg has
+// no public constructor for AVChapter, so the chapters are written as an
+// FFMETADATA file, parsed by FFmpeg's own ffmetadata demuxer, and moved across
+// to the output context, which then owns and frees them.
+func (e *Encoder) attachChapters() error {
+	if len(e.chapters) == 0 {
+		return nil
+	}
+
+	total := e.inputDuration()
+	if last := e.chapters[len(e.chapters)-1]; total > 0 && last.Start >= total {
+		return fmt.Errorf("chapter %q starts at %s, after the end of the audio (%s)", last.Title, last.Start, total)
+	}
+
+	tmp, err := os.CreateTemp("", "jivedrop-chapters-*.txt")
+	if err != nil {
+		return fmt.Errorf("failed to create chapter metadata file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(formatFFMetadata(e.chapters, total)); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write chapter metadata file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write chapter metadata file: %w", err)
+	}
+
+	fmtName := ffmpeg.ToCStr("ffmetadata")
+	defer fmtName.Free()
+	pathPtr := ffmpeg.ToCStr(tmp.Name())
+	defer pathPtr.Free()
+
+	var metaCtx *ffmpeg.AVFormatContext
+	if _, err := ffmpeg.AVFormatOpenInput(&metaCtx, pathPtr, ffmpeg.AVFindInputFormat(fmtName), nil); err != nil {
+		return fmt.Errorf("failed to parse chapter metadata: %w", err)
+	}
+	defer ffmpeg.AVFormatCloseInput(&metaCtx)
+
+	e.ofmtCtx.SetChapters(metaCtx.Chapters())
+	e.ofmtCtx.SetNbChapters(metaCtx.NbChapters())
+	metaCtx.SetChapters(nil)
+	metaCtx.SetNbChapters(0)
+
+	return nil
+}
+
+// inputDuration returns the duration of the input audio being written, from
+// the stream sample count when known and the container duration otherwise, or
+// 0 when neither is set. For a re-tag of part of the input it is the length
+// of that part.
+func (e *Encoder) inputDuration() time.Duration {
+	// A re-tag opens no decoder, so it always falls through to the container.
+	if e.decCtx != nil && e.totalSamples > 0 && e.decCtx.SampleRate() > 0 {
+		sampleRate := e.decCtx.SampleRate()
+		return time.Duration(float64(e.totalSamples) / float64(sampleRate) * float64(time.Second))
+	}
+	// The container duration is in AV_TIME_BASE units, i.e. microseconds.
+	total := time.Duration(max(e.ifmtCtx.Duration(), 0)) * time.Microsecond
+	if e.retagEnd > 0 && e.retagEnd < total {
+		total = e.retagEnd
+	}
+	return max(total-e.retagStart, 0)
+}
+
+// ReadChaptersFile opens and parses a chapter list file. See ParseChapters
+// for the format.
+func ReadChaptersFile(path string) ([]Chapter, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open chapters file: %w", err)
+	}
+	defer f.Close()
+
+	chapters, err := ParseChapters(f)
+	if err != nil {
+		return nil, fmt.Errorf("invalid chapters file %s: %w", path, err)
+	}
+	return chapters, nil
+}
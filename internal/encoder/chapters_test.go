@@ -0,0 +1,287 @@
+package encoder
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseChapters(t *testing.T) {
+	input := `# Episode 67 chapters
+00:00 Introduction
+04:12 News
+
+1:02:45.500 Listener feedback
+`
+	chapters, err := ParseChapters(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseChapters: %v", err)
+	}
+
+	want := []Chapter{
+		{Start: 0, Title: "Introduction"},
+		{Start: 4*time.Minute + 12*time.Second, Title: "News"},
+		{Start: time.Hour + 2*time.Minute + 45*time.Second + 500*time.Millisecond, Title: "Listener feedback"},
+	}
+	if len(chapters) != len(want) {
+		t.Fatalf("got %d chapters, want %d", len(chapters), len(want))
+	}
+	for i := range want {
+		if chapters[i] != want[i] {
+			t.Errorf("chapter %d = %+v, want %+v", i, chapters[i], want[i])
+		}
+	}
+}
+
+func TestParseChaptersErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		errPart string
+	}{
+		{name: "bad timestamp", input: "0:0:0:0 Intro", errPart: "invalid chapter timestamp"},
+		{name: "NaN seconds", input: "00:NaN Intro", errPart: "invalid chapter timestamp"},
+		{name: "seconds out of range", input: "00:61 Intro", errPart: "seconds must be 0-59"},
+		{name: "minutes out of range", input: "01:75:00 Intro", errPart: "minutes must be 0-59"},
+		{name: "missing title", input: "00:00", errPart: "has no title"},
+		{name: "out of order", input: "05:00 Second\n01:00 First", errPart: "does not start after"},
+		{name: "duplicate start", input: "01:00 One\n01:00 Two", errPart: "does not start after"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseChapters(strings.NewReader(tt.input))
+			if err == nil || !strings.Contains(err.Error(), tt.errPart) {
+				t.Errorf("ParseChapters(%q): got %v, want error containing %q", tt.input, err, tt.errPart)
+			}
+		})
+	}
+}
+
+func TestChaptersJSON(t *testing.T) {
+	got, err := ChaptersJSON([]Chapter{
+		{Start: 0, Title: "Introduction"},
+		{Start: 4*time.Minute + 12*time.Second + 493*time.Millisecond, Title: "Q&A"},
+	})
+	if err != nil {
+		t.Fatalf("ChaptersJSON: %v", err)
+	}
+
+	want := `{
+  "version": "1.2.0",
+  "chapters": [
+    {
+      "startTime": 0,
+      "title": "Introduction"
+    },
+    {
+      "startTime": 252.493,
+      "title": "Q&A"
+    }
+  ]
+}
+`
+	if string(got) != want {
+		t.Errorf("ChaptersJSON() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestCheckChapters(t *testing.T) {
+	longTitle := strings.Repeat("Très long ", 500)
+	tests := []struct {
+		name     string
+		format   string
+		in       []Chapter
+		want     []Chapter
+		warnings int
+	}{
+		{
+			name:   "clean list unchanged",
+			format: "mp3",
+			in:     []Chapter{{0, "Intro"}, {time.Minute, "News"}},
+			want:   []Chapter{{0, "Intro"}, {time.Minute, "News"}},
+		},
+		{
+			name:     "repeated titles numbered",
+			format:   "mp3",
+			in:       []Chapter{{0, "News"}, {time.Minute, "News (2)"}, {2 * time.Minute, "News"}, {3 * time.Minute, "News"}},
+			want:     []Chapter{{0, "News"}, {time.Minute, "News (2)"}, {2 * time.Minute, "News (3)"}, {3 * time.Minute, "News (4)"}},
+			warnings: 2,
+		},
+		{
+			name:     "out of order start dropped",
+			format:   "opus",
+			in:       []Chapter{{0, "Intro"}, {time.Minute, "News"}, {time.Minute, "Again"}, {30 * time.Second, "Back"}},
+			want:     []Chapter{{0, "Intro"}, {time.Minute, "News"}},
+			warnings: 2,
+		},
+		{
+			name:     "multi-kilobyte title truncated",
+			format:   "mp3",
+			in:       []Chapter{{0, longTitle}},
+			want:     []Chapter{{0, strings.TrimSpace(string([]rune(longTitle)[:MaxChapterTitleLength-1])) + "…"}},
+			warnings: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, warnings := CheckChapters(tt.in, tt.format)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d chapters, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("chapter %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+				if n := len([]rune(got[i].Title)); n > MaxChapterTitleLength {
+					t.Errorf("chapter %d title is %d characters", i, n)
+				}
+			}
+			if len(warnings) != tt.warnings {
+				t.Errorf("got %d warnings, want %d: %q", len(warnings), tt.warnings, warnings)
+			}
+		})
+	}
+}
+
+// TestCheckChaptersCount verifies an MP3 keeps only the chapters its table of
+// contents can list, while other formats keep them all.
+func TestCheckChaptersCount(t *testing.T) {
+	chapters := make([]Chapter, 300)
+	for i := range chapters {
+		chapters[i] = Chapter{Start: time.Duration(i) * 10 * time.Second, Title: fmt.Sprintf("Chapter %d", i+1)}
+	}
+
+	for _, format := range []string{"mp3", ""} {
+		got, warnings := CheckChapters(chapters, format)
+		if len(got) != MaxChapters || len(warnings) != 1 {
+			t.Errorf("CheckChapters(%q) = %d chapters, %d warnings; want %d, 1", format, len(got), len(warnings), MaxChapters)
+		}
+	}
+	if got, warnings := CheckChapters(chapters, "m4b"); len(got) != len(chapters) || len(warnings) != 0 {
+		t.Errorf("CheckChapters(m4b) = %d chapters, %d warnings; want %d, 0", len(got), len(warnings), len(chapters))
+	}
+}
+
+// TestChapterString verifies a chapter formats as a line ParseChapters reads
+// back unchanged.
+func TestChapterString(t *testing.T) {
+	chapters := []Chapter{
+		{Start: 0, Title: "Chapter 1"},
+		{Start: 4*time.Minute + 12*time.Second + 250*time.Millisecond, Title: "Chapter 2"},
+		{Start: time.Hour + 2*time.Minute + 45*time.Second, Title: "Listener feedback"},
+	}
+	want := []string{"00:00:00.000 Chapter 1", "00:04:12.250 Chapter 2", "01:02:45.000 Listener feedback"}
+
+	var lines []string
+	for i, ch := range chapters {
+		if got := ch.String(); got != want[i] {
+			t.Errorf("String() = %q, want %q", got, want[i])
+		}
+		lines = append(lines, ch.String())
+	}
+
+	parsed, err := ParseChapters(strings.NewReader(strings.Join(lines, "\n")))
+	if err != nil {
+		t.Fatalf("ParseChapters: %v", err)
+	}
+	for i := range chapters {
+		if parsed[i] != chapters[i] {
+			t.Errorf("round trip chapter %d = %+v, want %+v", i, parsed[i], chapters[i])
+		}
+	}
+}
+
+// TestFormatFFMetadata verifies chapter ends chain to the next start, the last
+// chapter runs to the total duration, and titles are escaped.
+func TestFormatFFMetadata(t *testing.T) {
+	chapters := []Chapter{
+		{Start: 0, Title: "Intro"},
+		{Start: 90 * time.Second, Title: "Q&A; a=b #1"},
+	}
+
+	got := formatFFMetadata(chapters, 2*time.Minute)
+	want := ";FFMETADATA1\n" +
+		"[CHAPTER]\nTIMEBASE=1/1000\nSTART=0\nEND=90000\ntitle=Intro\n" +
+		"[CHAPTER]\nTIMEBASE=1/1000\nSTART=90000\nEND=120000\ntitle=Q&A\\; a\\=b \\#1\n"
+	if got != want {
+		t.Errorf("formatFFMetadata:\ngot  %q\nwant %q", got, want)
+	}
+
+	// With an unknown duration the last chapter ends where it starts.
+	if got := formatFFMetadata(chapters[1:], 0); !strings.Contains(got, "START=90000\nEND=90000\n") {
+		t.Errorf("formatFFMetadata with unknown duration: got %q", got)
+	}
+}
+
+// TestEncodeChapters_Integration encodes with a chapter list and asserts that
+// ffprobe reads the chapters back from MP3 (ID3 CHAP) and M4B (QuickTime
+// chapters) output.
+func TestEncodeChapters_Integration(t *testing.T) {
+	inputPath := "../../testdata/LMP0.flac"
+	if _, err := os.Stat(inputPath); os.IsNotExist(err) {
+		t.Skipf("Test file not found: %s", inputPath)
+	}
+	if _, err := exec.LookPath("ffprobe"); err != nil {
+		t.Skip("ffprobe not available")
+	}
+
+	chapters := []Chapter{
+		{Start: 0, Title: "Introduction"},
+		{Start: 10 * time.Second, Title: "Main topic"},
+	}
+
+	for _, format := range []string{"mp3", "m4b"} {
+		t.Run(format, func(t *testing.T) {
+			outputPath := filepath.Join(t.TempDir(), "chapters"+ExtensionFor(format))
+
+			enc, err := New(Config{
+				InputPath:  inputPath,
+				OutputPath: outputPath,
+				Format:     format,
+				Chapters:   chapters,
+			})
+			if err != nil {
+				t.Fatalf("Failed to create encoder: %v", err)
+			}
+			defer enc.Close()
+
+			if err := enc.Initialize(); err != nil {
+				t.Fatalf("Failed to initialize encoder: %v", err)
+			}
+			if _, err := enc.Encode(nil); err != nil {
+				t.Fatalf("Encode failed: %v", err)
+			}
+			enc.Close()
+
+			cmd := exec.CommandContext(t.Context(), "ffprobe", "-show_chapters", "-of", "json", outputPath)
+			out, err := cmd.Output()
+			if err != nil {
+				t.Fatalf("ffprobe failed: %v", err)
+			}
+			var probe struct {
+				Chapters []struct {
+					Tags map[string]string `json:"tags"`
+				} `json:"chapters"`
+			}
+			if err := json.Unmarshal(out, &probe); err != nil {
+				t.Fatalf("failed to parse ffprobe output: %v", err)
+			}
+
+			if len(probe.Chapters) != len(chapters) {
+				t.Fatalf("got %d chapters, want %d", len(probe.Chapters), len(chapters))
+			}
+			for i, ch := range chapters {
+				if got := probe.Chapters[i].Tags["title"]; got != ch.Title {
+					t.Errorf("chapter %d title = %q, want %q", i, got, ch.Title)
+				}
+			}
+		})
+	}
+}
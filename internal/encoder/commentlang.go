@@ -0,0 +1,259 @@
+package encoder
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf16"
+)
+
+// DefaultCommentLanguage is the ISO 639-2 language written to MP3 comment
+// frames unless another is chosen.
+const DefaultCommentLanguage = "eng"
+
+// CheckLanguageCode reports whether code is a plausible ISO 639-2 language
+// code: three ASCII letters. Case is not significant.
+func CheckLanguageCode(code string) error {
+	if len(code) != 3 {
+		return fmt.Errorf("invalid language code %q: want three letters, such as eng or deu", code)
+	}
+	for _, r := range strings.ToLower(code) {
+		if r < 'a' || r > 'z' {
+			return fmt.Errorf("invalid language code %q: want three letters, such as eng or deu", code)
+		}
+	}
+	return nil
+}
+
+// ID3v2 text encodings, as the first byte of a text frame.
+const (
+	id3Latin1  = 0
+	id3UTF16   = 1 // with a byte order mark
+	id3UTF16BE = 2
+	id3UTF8    = 3
+)
+
+// writeCommentLanguage gives the comments in a finished MP3 their language.
+// The trailer has been written and flushed, so the tag can be patched on disk.
+func (e *Encoder) writeCommentLanguage() error {
+	if e.preset.name != "mp3" || len(e.metadata.Comments) == 0 {
+		return nil
+	}
+	if err := setCommentLanguage(e.outputPath, e.commentLanguage); err != nil {
+		return fmt.Errorf("failed to set comment language: %w", err)
+	}
+	return nil
+}
+
+// setCommentLanguage rewrites the comments in the ID3v2 tag of the MP3 at
+// path as COMM frames in lang. FFmpeg's muxer writes each comment as a TXXX
+// frame described by its metadata key ("comment", "comment-Link") and has no
+// way to give a language, which COMM frames need. The rewritten frames are
+// never larger than the originals, so the tag is rewritten in place and the
+// space saved becomes padding; the audio is not touched. A tag this cannot
+// safely parse is left as it is.
+func setCommentLanguage(path, lang string) error {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return fmt.Errorf("failed to open output: %w", err)
+	}
+	defer f.Close()
+
+	header := make([]byte, 10)
+	if _, err := io.ReadFull(f, header); err != nil {
+		return fmt.Errorf("failed to read ID3 header: %w", err)
+	}
+	version := header[3]
+	// No tag, an unknown version, or unsynchronisation or an extended header,
+	// neither of which FFmpeg writes.
+	if string(header[:3]) != "ID3" || (version != 3 && version != 4) || header[5]&0xc0 != 0 {
+		return nil
+	}
+
+	tag := make([]byte, synchsafe(header[6:10]))
+	if _, err := io.ReadFull(f, tag); err != nil {
+		return fmt.Errorf("failed to read ID3 tag: %w", err)
+	}
+
+	rewritten, ok := rewriteCommentFrames(tag, version, strings.ToLower(lang))
+	if !ok {
+		return nil
+	}
+	if _, err := f.WriteAt(rewritten, int64(len(header))); err != nil {
+		return fmt.Errorf("failed to write ID3 tag: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write ID3 tag: %w", err)
+	}
+	return nil
+}
+
+// rewriteCommentFrames returns the ID3v2 tag body with comment TXXX frames
+// turned into COMM frames and existing COMM frames given lang, padded to its
+// original length. It reports false when there was nothing to change or the
+// frames could not be parsed.
+func rewriteCommentFrames(tag []byte, version byte, lang string) ([]byte, bool) {
+	out := make([]byte, 0, len(tag))
+	changed := false
+
+	if _, ok := walkFrames(tag, version, func(id string, frame []byte) {
+		flags, body := frame[8:10], frame[10:]
+
+		// Flags mean compression, encryption and the like; leave those alone.
+		if flags[0] != 0 || flags[1] != 0 {
+			out = append(out, frame...)
+			return
+		}
+
+		switch {
+		case id == "COMM" && len(body) >= 4:
+			comm := bytes.Clone(body)
+			copy(comm[1:4], lang)
+			out = appendFrame(out, "COMM", comm, version)
+			changed = true
+		case id == "TXXX":
+			if comm, ok := commentFromTXXX(body, lang); ok {
+				out = appendFrame(out, "COMM", comm, version)
+				changed = true
+				return
+			}
+			out = append(out, frame...)
+		default:
+			out = append(out, frame...)
+		}
+	}); !ok {
+		return nil, false
+	}
+
+	if !changed || len(out) > len(tag) {
+		return nil, false
+	}
+	// Everything after the frames becomes padding.
+	return append(out, make([]byte, len(tag)-len(out))...), true
+}
+
+// commentFromTXXX returns the COMM frame body for a TXXX body holding a
+// comment, or false when the TXXX frame is something else. The comment text
+// is carried over in its original encoding.
+func commentFromTXXX(body []byte, lang string) ([]byte, bool) {
+	if len(body) < 1 {
+		return nil, false
+	}
+	enc := body[0]
+	description, value, ok := splitID3Text(body[1:], enc)
+	if !ok {
+		return nil, false
+	}
+	rest, isComment := strings.CutPrefix(decodeID3Text(description, enc), "comment")
+	if !isComment || (rest != "" && !strings.HasPrefix(rest, "-")) {
+		return nil, false
+	}
+
+	comm := append([]byte{enc}, lang...)
+	comm = append(comm, encodeID3Text(strings.TrimPrefix(rest, "-"), enc)...)
+	return append(comm, value...), true
+}
+
+// splitID3Text splits encoded text at its first terminator, returning the
+// text before it and everything after.
+func splitID3Text(data []byte, enc byte) (text, rest []byte, ok bool) {
+	switch enc {
+	case id3Latin1, id3UTF8:
+		i := bytes.IndexByte(data, 0)
+		if i < 0 {
+			return nil, nil, false
+		}
+		return data[:i], data[i+1:], true
+	case id3UTF16, id3UTF16BE:
+		for i := 0; i+1 < len(data); i += 2 {
+			if data[i] == 0 && data[i+1] == 0 {
+				return data[:i], data[i+2:], true
+			}
+		}
+	}
+	return nil, nil, false
+}
+
+// decodeID3Text decodes text in an ID3v2 encoding. Latin-1 is read as ASCII,
+// which covers the descriptions FFmpeg writes.
+func decodeID3Text(text []byte, enc byte) string {
+	if enc != id3UTF16 && enc != id3UTF16BE {
+		return string(text)
+	}
+	order := binary.ByteOrder(binary.BigEndian)
+	if enc == id3UTF16 && len(text) >= 2 {
+		if text[0] == 0xff && text[1] == 0xfe {
+			order = binary.LittleEndian
+		}
+		text = text[2:]
+	}
+	units := make([]uint16, len(text)/2)
+	for i := range units {
+		units[i] = order.Uint16(text[2*i:])
+	}
+	return string(utf16.Decode(units))
+}
+
+// encodeID3Text encodes s in an ID3v2 encoding, with its terminator.
+func encodeID3Text(s string, enc byte) []byte {
+	if enc != id3UTF16 && enc != id3UTF16BE {
+		return append([]byte(s), 0)
+	}
+	var b []byte
+	order := binary.AppendByteOrder(binary.BigEndian)
+	if enc == id3UTF16 {
+		b = []byte{0xff, 0xfe}
+		order = binary.LittleEndian
+	}
+	for _, unit := range utf16.Encode([]rune(s)) {
+		b = order.AppendUint16(b, unit)
+	}
+	return append(b, 0, 0)
+}
+
+// walkFrames calls fn, when not nil, with each frame of an ID3v2 tag body in
+// turn, header included, stopping at the padding after them. It returns the
+// offset where the frames end, and reports false when a frame overruns the
+// tag.
+func walkFrames(tag []byte, version byte, fn func(id string, frame []byte)) (int, bool) {
+	pos := 0
+	for pos+10 <= len(tag) && tag[pos] != 0 {
+		size := int(binary.BigEndian.Uint32(tag[pos+4 : pos+8]))
+		if version == 4 {
+			size = synchsafe(tag[pos+4 : pos+8])
+		}
+		if size < 0 || pos+10+size > len(tag) {
+			return 0, false
+		}
+		if fn != nil {
+			fn(string(tag[pos:pos+4]), tag[pos:pos+10+size])
+		}
+		pos += 10 + size
+	}
+	return pos, true
+}
+
+// appendFrame appends an ID3v2 frame with no flags.
+func appendFrame(out []byte, id string, body []byte, version byte) []byte {
+	out = append(out, id...)
+	if version == 4 {
+		out = append(out, toSynchsafe(len(body))...)
+	} else {
+		out = binary.BigEndian.AppendUint32(out, uint32(len(body))) //nolint:gosec // frames are far smaller than 4GiB
+	}
+	out = append(out, 0, 0)
+	return append(out, body...)
+}
+
+// synchsafe decodes a four-byte ID3v2 synchsafe integer: seven bits a byte.
+func synchsafe(b []byte) int {
+	return int(b[0]&0x7f)<<21 | int(b[1]&0x7f)<<14 | int(b[2]&0x7f)<<7 | int(b[3]&0x7f)
+}
+
+// toSynchsafe encodes n as a four-byte ID3v2 synchsafe integer.
+func toSynchsafe(n int) []byte {
+	return []byte{byte(n >> 21 & 0x7f), byte(n >> 14 & 0x7f), byte(n >> 7 & 0x7f), byte(n & 0x7f)}
+}
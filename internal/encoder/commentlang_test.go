@@ -0,0 +1,154 @@
+package encoder
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckLanguageCode(t *testing.T) {
+	for _, code := range []string{"eng", "deu", "ENG", "xxx"} {
+		if err := CheckLanguageCode(code); err != nil {
+			t.Errorf("CheckLanguageCode(%q) error: %v", code, err)
+		}
+	}
+	for _, code := range []string{"", "en", "english", "en1", "é n"} {
+		if err := CheckLanguageCode(code); err == nil {
+			t.Errorf("CheckLanguageCode(%q) = nil, want an error", code)
+		}
+	}
+}
+
+// id3Frame is one frame read back from a tag body.
+type id3Frame struct {
+	id   string
+	body []byte
+}
+
+// readFrames walks the frames of an ID3v2 tag body, stopping at padding.
+func readFrames(t *testing.T, tag []byte, version byte) []id3Frame {
+	t.Helper()
+	var frames []id3Frame
+	if _, ok := walkFrames(tag, version, func(id string, frame []byte) {
+		frames = append(frames, id3Frame{id: id, body: frame[10:]})
+	}); !ok {
+		t.Fatal("a frame overruns the tag")
+	}
+	return frames
+}
+
+func TestRewriteCommentFrames(t *testing.T) {
+	utf16Text := func(s string) []byte { return encodeID3Text(s, id3UTF16) }
+
+	tests := []struct {
+		name    string
+		version byte
+		in      []id3Frame
+		want    []id3Frame
+	}{
+		{
+			name:    "v2.4 UTF-8 comments",
+			version: 4,
+			in: []id3Frame{
+				{"TIT2", []byte("\x03Episode 67")},
+				{"TXXX", []byte("\x03comment\x00Show notes")},
+				{"TXXX", []byte("\x03comment-Link\x00https://linuxmatters.sh")},
+				{"TXXX", []byte("\x03commentary\x00Not a comment")},
+			},
+			want: []id3Frame{
+				{"TIT2", []byte("\x03Episode 67")},
+				{"COMM", []byte("\x03deu\x00Show notes")},
+				{"COMM", []byte("\x03deuLink\x00https://linuxmatters.sh")},
+				{"TXXX", []byte("\x03commentary\x00Not a comment")},
+			},
+		},
+		{
+			name:    "v2.3 Latin-1 comment",
+			version: 3,
+			in:      []id3Frame{{"TXXX", []byte("\x00comment\x00Show notes")}},
+			want:    []id3Frame{{"COMM", []byte("\x00deu\x00Show notes")}},
+		},
+		{
+			name:    "v2.3 UTF-16 comment",
+			version: 3,
+			in:      []id3Frame{{"TXXX", append(append([]byte{id3UTF16}, utf16Text("comment-Notes")...), utf16Text("Grüße")...)}},
+			want:    []id3Frame{{"COMM", append(append([]byte{id3UTF16, 'd', 'e', 'u'}, utf16Text("Notes")...), utf16Text("Grüße")...)}},
+		},
+		{
+			name:    "existing COMM",
+			version: 4,
+			in:      []id3Frame{{"COMM", []byte("\x03eng\x00Show notes")}},
+			want:    []id3Frame{{"COMM", []byte("\x03deu\x00Show notes")}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var tag []byte
+			for _, f := range tt.in {
+				tag = appendFrame(tag, f.id, f.body, tt.version)
+			}
+			tag = append(tag, make([]byte, 64)...)
+
+			got, ok := rewriteCommentFrames(tag, tt.version, "deu")
+			if !ok {
+				t.Fatal("rewriteCommentFrames() reported no change")
+			}
+			if len(got) != len(tag) {
+				t.Errorf("tag length = %d, want %d", len(got), len(tag))
+			}
+			frames := readFrames(t, got, tt.version)
+			if len(frames) != len(tt.want) {
+				t.Fatalf("got %d frames, want %d", len(frames), len(tt.want))
+			}
+			for i, f := range frames {
+				if f.id != tt.want[i].id || !bytes.Equal(f.body, tt.want[i].body) {
+					t.Errorf("frame %d = %s %q, want %s %q", i, f.id, f.body, tt.want[i].id, tt.want[i].body)
+				}
+			}
+		})
+	}
+
+	tag := appendFrame(nil, "TIT2", []byte("\x03Episode 67"), 4)
+	if _, ok := rewriteCommentFrames(tag, 4, "deu"); ok {
+		t.Error("rewriteCommentFrames() without comments reported a change")
+	}
+}
+
+// TestSetCommentLanguage verifies the tag is rewritten in place, leaving the
+// file size and the audio after the tag as they were.
+func TestSetCommentLanguage(t *testing.T) {
+	frames := appendFrame(nil, "TXXX", []byte("\x03comment\x00Show notes"), 4)
+	header := append([]byte("ID3\x04\x00\x00"), toSynchsafe(len(frames))...)
+	audio := []byte("\xff\xfbaudio frames")
+	data := append(append(header, frames...), audio...)
+
+	path := filepath.Join(t.TempDir(), "episode.mp3")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := setCommentLanguage(path, "FRA"); err != nil {
+		t.Fatalf("setCommentLanguage() error: %v", err)
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(data) || !bytes.HasSuffix(got, audio) {
+		t.Fatalf("file changed outside the tag: %q", got)
+	}
+	want := []id3Frame{{"COMM", []byte("\x03fra\x00Show notes")}}
+	if f := readFrames(t, got[10:len(got)-len(audio)], 4); len(f) != 1 || f[0].id != want[0].id || !bytes.Equal(f[0].body, want[0].body) {
+		t.Errorf("frames = %q, want %q", f, want)
+	}
+
+	noTag := filepath.Join(t.TempDir(), "plain.mp3")
+	if err := os.WriteFile(noTag, audio, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := setCommentLanguage(noTag, "eng"); err != nil {
+		t.Errorf("setCommentLanguage() on an untagged file error: %v", err)
+	}
+}
@@ -0,0 +1,148 @@
+package encoder
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cueFramesPerSecond is the CD frame rate CUE sheet timecodes count in.
+const cueFramesPerSecond = 75
+
+// ParseCue reads the chapters from a CUE sheet, as exported by a DAW: one
+// chapter per TRACK, starting at its INDEX 01 and titled by its TITLE.
+//
+//	FILE "episode.wav" WAVE
+//	  TRACK 01 AUDIO
+//	    TITLE "Introduction"
+//	    INDEX 01 00:00:00
+//	  TRACK 02 AUDIO
+//	    TITLE "News"
+//	    INDEX 01 04:12:37
+//
+// Timecodes are MM:SS:FF, where FF counts CD frames of 1/75 second and
+// minutes may pass 59. A track without a title is named "Track NN". The
+// sheet's own TITLE, PERFORMER and REM lines, and INDEX 00 pregaps, are
+// ignored. Track times are relative to their FILE, so a sheet spanning more
+// than one file is an error, as are tracks out of order.
+func ParseCue(r io.Reader) ([]Chapter, error) {
+	var chapters []Chapter
+	files := 0
+	track := -1 // index in chapters of the current TRACK, -1 before the first
+	trackNum := ""
+	lineNum := 0
+
+	// finishTrack checks the track just read has a start.
+	finishTrack := func() error {
+		if track >= 0 && chapters[track].Start < 0 {
+			return fmt.Errorf("track %s has no INDEX 01", trackNum)
+		}
+		return nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if lineNum == 1 {
+			line = strings.TrimPrefix(line, "\ufeff") // byte order mark
+		}
+		command, args, _ := strings.Cut(line, " ")
+		args = strings.TrimSpace(args)
+
+		switch strings.ToUpper(command) {
+		case "FILE":
+			if files++; files > 1 {
+				return nil, fmt.Errorf("line %d: cue sheets spanning several files are not supported", lineNum)
+			}
+		case "TRACK":
+			if err := finishTrack(); err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNum, err)
+			}
+			trackNum, _, _ = strings.Cut(args, " ")
+			chapters = append(chapters, Chapter{Start: -1, Title: "Track " + trackNum})
+			track = len(chapters) - 1
+		case "TITLE":
+			if title := unquoteCue(args); track >= 0 && title != "" {
+				chapters[track].Title = title
+			}
+		case "INDEX":
+			number, stamp, _ := strings.Cut(args, " ")
+			if n, err := strconv.Atoi(number); track < 0 || err != nil || n != 1 {
+				continue
+			}
+			start, err := parseCueTimestamp(strings.TrimSpace(stamp))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNum, err)
+			}
+			if track > 0 && start <= chapters[track-1].Start {
+				return nil, fmt.Errorf("line %d: track %s does not start after the previous track", lineNum, trackNum)
+			}
+			chapters[track].Start = start
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read cue sheet: %w", err)
+	}
+	if err := finishTrack(); err != nil {
+		return nil, err
+	}
+
+	return chapters, nil
+}
+
+// parseCueTimestamp parses an MM:SS:FF CUE timecode into a duration.
+func parseCueTimestamp(s string) (time.Duration, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("invalid cue timestamp %q: want MM:SS:FF", s)
+	}
+
+	var n [3]int
+	for i, part := range parts {
+		v, err := strconv.Atoi(part)
+		if err != nil || v < 0 {
+			return 0, fmt.Errorf("invalid cue timestamp %q: want MM:SS:FF", s)
+		}
+		n[i] = v
+	}
+	minutes, seconds, frames := n[0], n[1], n[2]
+	if seconds >= 60 {
+		return 0, fmt.Errorf("invalid cue timestamp %q: seconds must be 0-59", s)
+	}
+	if frames >= cueFramesPerSecond {
+		return 0, fmt.Errorf("invalid cue timestamp %q: frames must be 0-74", s)
+	}
+
+	return time.Duration(minutes)*time.Minute +
+		time.Duration(seconds)*time.Second +
+		(time.Duration(frames) * time.Second / cueFramesPerSecond).Truncate(time.Millisecond), nil
+}
+
+// unquoteCue strips the double quotes around a CUE string value. CUE sheets
+// have no escapes, so the text between the quotes is taken as it is.
+func unquoteCue(s string) string {
+	if len(s) >= 2 && strings.HasPrefix(s, `"`) && strings.HasSuffix(s, `"`) {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// ReadCueFile opens and parses a CUE sheet. See ParseCue for what is read.
+func ReadCueFile(path string) ([]Chapter, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cue sheet: %w", err)
+	}
+	defer f.Close()
+
+	chapters, err := ParseCue(f)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cue sheet %s: %w", path, err)
+	}
+	return chapters, nil
+}
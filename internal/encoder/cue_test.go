@@ -0,0 +1,68 @@
+package encoder
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseCue(t *testing.T) {
+	input := "\ufeff" + `REM GENRE Podcast
+PERFORMER "Linux Matters"
+TITLE "Episode 67"
+FILE "LMP67.wav" WAVE
+  TRACK 01 AUDIO
+    TITLE "Introduction"
+    INDEX 01 00:00:00
+  TRACK 02 AUDIO
+    TITLE "News"
+    PERFORMER "Martin"
+    INDEX 00 04:10:00
+    INDEX 01 04:12:37
+  TRACK 03 AUDIO
+    INDEX 01 62:45:74
+`
+	chapters, err := ParseCue(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseCue: %v", err)
+	}
+
+	want := []Chapter{
+		{Start: 0, Title: "Introduction"},
+		{Start: 4*time.Minute + 12*time.Second + 493*time.Millisecond, Title: "News"},
+		{Start: 62*time.Minute + 45*time.Second + 986*time.Millisecond, Title: "Track 03"},
+	}
+	if len(chapters) != len(want) {
+		t.Fatalf("got %d chapters, want %d", len(chapters), len(want))
+	}
+	for i := range want {
+		if chapters[i] != want[i] {
+			t.Errorf("chapter %d = %+v, want %+v", i, chapters[i], want[i])
+		}
+	}
+}
+
+func TestParseCueErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		errPart string
+	}{
+		{name: "bad timestamp", input: "TRACK 01 AUDIO\nINDEX 01 00:00", errPart: "want MM:SS:FF"},
+		{name: "seconds out of range", input: "TRACK 01 AUDIO\nINDEX 01 00:60:00", errPart: "seconds must be 0-59"},
+		{name: "frames out of range", input: "TRACK 01 AUDIO\nINDEX 01 00:00:75", errPart: "frames must be 0-74"},
+		{name: "track without index", input: "TRACK 01 AUDIO\nINDEX 00 00:00:00\nTRACK 02 AUDIO\nINDEX 01 01:00:00", errPart: "track 01 has no INDEX 01"},
+		{name: "last track without index", input: "TRACK 01 AUDIO\nINDEX 01 00:00:00\nTRACK 02 AUDIO", errPart: "track 02 has no INDEX 01"},
+		{name: "out of order", input: "TRACK 01 AUDIO\nINDEX 01 05:00:00\nTRACK 02 AUDIO\nINDEX 01 01:00:00", errPart: "does not start after"},
+		{name: "several files", input: "FILE \"a.wav\" WAVE\nTRACK 01 AUDIO\nINDEX 01 00:00:00\nFILE \"b.wav\" WAVE", errPart: "several files"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseCue(strings.NewReader(tt.input))
+			if err == nil || !strings.Contains(err.Error(), tt.errPart) {
+				t.Errorf("ParseCue(%q): got %v, want error containing %q", tt.input, err, tt.errPart)
+			}
+		})
+	}
+}
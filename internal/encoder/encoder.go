@@ -5,8 +5,14 @@ import (
 	"errors"
 	"fmt"
 	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"sync/atomic"
+	"time"
 	"unsafe"
 
 	"github.com/linuxmatters/ffmpeg-statigo"
@@ -16,6 +22,25 @@ import (
 // encode finished. Callers treat it as a clean stop, not an encoding failure.
 var ErrCancelled = errors.New("encoding cancelled")
 
+// ErrNoAudio is returned by Encode when the input decodes to no audio, or too
+// little to be an episode, so an empty recording does not become a
+// plausible-looking output file.
+var ErrNoAudio = errors.New("input contains no audio")
+
+// ErrUnsupportedInput is returned when the input is not audio FFmpeg can
+// decode: an unknown container, a file with no audio stream, or a codec the
+// linked FFmpeg has no decoder for.
+var ErrUnsupportedInput = errors.New("unsupported input")
+
+// supportedInputs names the source formats jivedrop is tested with, for
+// unsupported-input errors.
+const supportedInputs = "jivedrop takes WAV, FLAC, AIFF and M4A/MP4 (AAC or ALAC) audio"
+
+// MinInputDuration is the shortest input Encode accepts, and so the shortest
+// preview. Anything shorter is a truncated or empty recording, not an
+// episode.
+const MinInputDuration = time.Second
+
 // Podcast bitrate presets in bits per second: 192kbps stereo, 112kbps mono.
 const (
 	MonoBitrate   = 112000
@@ -44,15 +69,59 @@ type Encoder struct {
 
 	preset   formatPreset
 	metadata Metadata
-	coverArt []byte // scaled PNG cover bytes; empty disables the attached-picture stream
-
-	streamIndex      int
-	outStreamIndex   int // OUTPUT audio stream index, distinct from input streamIndex
-	coverStreamIndex int // attached-picture stream index, -1 when no cover stream
-	samplesRead      int64
-	totalSamples     int64
-	nextPts          int64 // Track PTS for output frames
-	closed           bool  // Track if Close() has been called to prevent double-free
+	pictures []Picture // front cover first, then any extra images; empty disables attached pictures
+	chapters []Chapter
+
+	progressInterval time.Duration
+	progressStep     float64
+	id3Version       int    // ID3v2 major version for MP3 output (3 or 4)
+	gapless          bool   // write the Xing/LAME info frame for MP3 output
+	ioRetries        int    // extra attempts at opening the output file
+	verbose          bool   // capture FFmpeg info/warning messages instead of suppressing them
+	dither           bool   // dither high bit depth sources down to 16-bit samples
+	downmix          string // mono downmix mode: DownmixAverage, DownmixLeft or DownmixRight
+	log              ffmpegLog
+
+	// The peak limiter runs at the end of the filter chain when limit is set;
+	// peakLevel is the highest peak seen ahead of it, in dBFS.
+	limit        bool
+	limitCeiling float64 // dB
+	peakLevel    float64
+
+	// debugLogPath, when set, enables the FFmpeg debug trace written to debug.
+	debugLogPath string
+	debug        *debugLog
+
+	// retagStart and retagEnd limit Retag to part of the input; a zero
+	// retagEnd copies to the end.
+	retagStart time.Duration
+	retagEnd   time.Duration
+
+	// commentLanguage is the ISO 639-2 code given to MP3 comment frames,
+	// and to the transcript's SYLT frame.
+	commentLanguage string
+
+	transcript []TranscriptLine
+
+	// matchInputRate encodes a low-rate input at its own rate; see
+	// formatPreset.atInputRate.
+	matchInputRate bool
+
+	// preview stops the encode after this much audio; previewSamples is the
+	// same length in input samples, set by Initialize.
+	preview        time.Duration
+	previewSamples int64
+
+	audioTrack     int          // requested 1-based audio track; 0 lets FFmpeg pick
+	audioTracks    []AudioTrack // audio streams found in the input
+	inputMetadata  map[string]string
+	streamIndex    int
+	outStreamIndex int   // OUTPUT audio stream index, distinct from input streamIndex
+	pictureStreams []int // attached-picture stream index for each entry in pictures
+	samplesRead    int64
+	totalSamples   int64
+	nextPts        int64 // Track PTS for output frames
+	closed         bool  // Track if Close() has been called to prevent double-free
 
 	// cancelled is set by Cancel and observed at the top of the decode loop so
 	// Encode unwinds the cgo call chain before any Close frees the AV contexts.
@@ -68,20 +137,106 @@ type Metadata struct {
 	Title         string
 	Artist        string
 	Album         string
+	AlbumArtist   string
 	Date          string
-	Comment       string
+	Comments      []string
+	// TitleFormat is the title tag template with {num} and {title}
+	// placeholders; empty selects DefaultTitleFormat.
+	TitleFormat string
+	// Explicit writes the iTunes advisory marker.
+	Explicit bool
 }
 
 // Config holds encoder configuration
 type Config struct {
 	InputPath  string
 	OutputPath string
-	Stereo     bool     // true = 192kbps stereo, false = 112kbps mono
-	Format     string   // output format (mp3, aac, opus); defaults to mp3 when empty
-	Metadata   Metadata // episode tag fields written as muxer-native metadata
-	CoverArt   []byte   // scaled PNG cover bytes; embedded as an attached picture for cover-capable formats
+	Stereo     bool      // true = 192kbps stereo, false = 112kbps mono
+	Format     string    // output format (mp3, aac, opus); defaults to mp3 when empty
+	Metadata   Metadata  // episode tag fields written as muxer-native metadata
+	CoverArt   []byte    // scaled PNG cover bytes; embedded as an attached picture for cover-capable formats
+	Pictures   []Picture // extra images such as a back cover, embedded after CoverArt
+	ID3Version int       // ID3v2 major version for MP3 output (3 or 4); defaults to 4 when zero
+	Verbose    bool      // capture FFmpeg warnings for LogMessages instead of suppressing them
+	DebugLog   string    // path for an FFmpeg debug trace; empty disables it
+	AudioTrack int       // 1-based audio track to encode; 0 picks the best stream
+	IORetries  int       // extra attempts at opening the output, with backoff; 0 tries once
+	Chapters   []Chapter // chapter marks written through the muxer; empty writes none
+
+	// CoverDescription is the front cover's picture description, written to
+	// the APIC frame or picture block; empty leaves it blank.
+	CoverDescription string
+
+	// Dither adds triangular dither when a 24-bit, 32-bit or float source is
+	// reduced to 16-bit samples for MP3, instead of truncating.
+	Dither bool
+
+	// Downmix chooses how a multi-channel source becomes a mono output:
+	// DownmixAverage (the default when empty), DownmixLeft or DownmixRight.
+	// It has no effect on stereo output.
+	Downmix string
+
+	// Limit runs a peak limiter at the end of the filter chain so no sample
+	// exceeds LimitCeiling, in dB (DefaultLimitCeiling is -1). The ceiling
+	// must be between MinLimitCeiling and 0.
+	Limit        bool
+	LimitCeiling float64
+
+	// NoGapless leaves out the Xing/LAME info frame at the start of MP3
+	// output. Players read the encoder delay and padding from it to trim the
+	// silence the encoder adds, so gapless playback needs it; turn it off only
+	// for players that mishandle the frame. Other formats record the delay in
+	// the container regardless.
+	NoGapless bool
+
+	// RetagStart and RetagEnd limit Retag to the audio between them, so an
+	// encoded episode can be copied out in parts. A zero RetagEnd copies to
+	// the end. Chapters are relative to RetagStart. Encode ignores both.
+	RetagStart time.Duration
+	RetagEnd   time.Duration
+
+	// CommentLanguage is the ISO 639-2 language code of the MP3 COMM frames
+	// that hold the comments; DefaultCommentLanguage when empty. Opus and AAC
+	// comments carry no language.
+	CommentLanguage string
+
+	// Transcript is embedded in MP3 output as a SYLT frame, so apps that
+	// support synchronised lyrics can show each line as it is spoken. Its
+	// frame must fit in MaxTranscriptBytes. Other formats have no equivalent
+	// and reject it.
+	Transcript []TranscriptLine
+
+	// MatchInputRate encodes an input whose sample rate is below the format's
+	// at the input's own rate, when the encoder accepts it, instead of
+	// upsampling. MP3 bitrates are lowered to what MPEG audio allows there.
+	MatchInputRate bool
+
+	// Preview stops Encode after this much audio, for auditioning filter
+	// settings on a short clip; the progress total is the preview length.
+	// Chapters starting after it are left out. Zero encodes everything, and
+	// Retag ignores it.
+	Preview time.Duration
+
+	// ProgressInterval and ProgressStep coalesce progress callbacks: a call
+	// fires only once at least ProgressInterval has passed and progress has
+	// advanced by at least ProgressStep percentage points since the last one.
+	// Zero disables either limit; both zero fire on every decoded frame.
+	ProgressInterval time.Duration
+	ProgressStep     float64
 }
 
+// DefaultID3Version is the ID3v2 major version written to MP3 output unless a
+// legacy-compatible version 3 is requested.
+const DefaultID3Version = 4
+
+// DefaultLimitCeiling is the usual podcast peak ceiling, in dB, leaving
+// headroom for the overshoot lossy encoding adds.
+const DefaultLimitCeiling = -1.0
+
+// MinLimitCeiling is the lowest ceiling FFmpeg's alimiter accepts, in dB (a
+// linear limit of 0.0625).
+const MinLimitCeiling = -24.0
+
 // New creates a new encoder instance
 func New(cfg Config) (*Encoder, error) {
 	if cfg.InputPath == "" {
@@ -100,28 +255,128 @@ func New(cfg Config) (*Encoder, error) {
 		return nil, fmt.Errorf("unknown output format: %q", format)
 	}
 
+	id3Version := cfg.ID3Version
+	if id3Version == 0 {
+		id3Version = DefaultID3Version
+	}
+	if id3Version != 3 && id3Version != 4 {
+		return nil, fmt.Errorf("unsupported ID3v2 version: %d (must be 3 or 4)", id3Version)
+	}
+
+	if cfg.ProgressInterval < 0 || cfg.ProgressStep < 0 {
+		return nil, fmt.Errorf("progress interval and step must not be negative")
+	}
+
+	if cfg.IORetries < 0 {
+		return nil, fmt.Errorf("invalid I/O retries: %d (must not be negative)", cfg.IORetries)
+	}
+
+	if cfg.AudioTrack < 0 {
+		return nil, fmt.Errorf("invalid audio track: %d (must be 1 or more)", cfg.AudioTrack)
+	}
+
+	if cfg.Preview < 0 {
+		return nil, fmt.Errorf("invalid preview length: %s (must not be negative)", cfg.Preview)
+	}
+
+	if preset.name == "mp3" && len(cfg.Chapters) > MaxChapters {
+		return nil, fmt.Errorf("too many chapters for MP3: %d (ID3 holds at most %d)", len(cfg.Chapters), MaxChapters)
+	}
+
+	if len(cfg.Transcript) > 0 {
+		if preset.name != "mp3" {
+			return nil, fmt.Errorf("a transcript can only be embedded in MP3, not %s", format)
+		}
+		if size := TranscriptFrameSize(cfg.Transcript, id3Version); size > MaxTranscriptBytes {
+			return nil, fmt.Errorf("transcript too large to embed: %s (at most %s)", FormatBytes(int64(size)), FormatBytes(MaxTranscriptBytes))
+		}
+	}
+
+	downmix := cfg.Downmix
+	if downmix == "" {
+		downmix = DownmixAverage
+	}
+	if _, ok := downmixPans[downmix]; !ok && downmix != DownmixAverage {
+		return nil, fmt.Errorf("unknown downmix: %q (must be average, left or right)", cfg.Downmix)
+	}
+
+	if cfg.RetagStart < 0 || (cfg.RetagEnd != 0 && cfg.RetagEnd <= cfg.RetagStart) {
+		return nil, fmt.Errorf("invalid re-tag range: %s to %s", cfg.RetagStart, cfg.RetagEnd)
+	}
+
+	commentLanguage := strings.ToLower(cfg.CommentLanguage)
+	if commentLanguage == "" {
+		commentLanguage = DefaultCommentLanguage
+	}
+	if err := CheckLanguageCode(commentLanguage); err != nil {
+		return nil, err
+	}
+
+	if cfg.Limit && (cfg.LimitCeiling < MinLimitCeiling || cfg.LimitCeiling > 0) {
+		return nil, fmt.Errorf("invalid limiter ceiling: %gdB (must be between %gdB and 0dB)", cfg.LimitCeiling, MinLimitCeiling)
+	}
+
+	// The front cover leads so players that show only the first picture still
+	// show the cover.
+	var pictures []Picture
+	if len(cfg.CoverArt) > 0 {
+		pictures = append(pictures, Picture{Type: PictureFrontCover, Description: cfg.CoverDescription, Data: cfg.CoverArt})
+	}
+	for _, pic := range cfg.Pictures {
+		if len(pic.Data) > 0 {
+			pictures = append(pictures, pic)
+		}
+	}
+
 	return &Encoder{
 		inputPath:        cfg.InputPath,
 		outputPath:       cfg.OutputPath,
 		stereo:           cfg.Stereo,
 		preset:           preset,
 		metadata:         cfg.Metadata,
-		coverArt:         cfg.CoverArt,
+		pictures:         pictures,
+		chapters:         cfg.Chapters,
+		progressInterval: cfg.ProgressInterval,
+		progressStep:     cfg.ProgressStep,
+		id3Version:       id3Version,
+		gapless:          !cfg.NoGapless,
+		verbose:          cfg.Verbose,
+		dither:           cfg.Dither,
+		downmix:          downmix,
+		limit:            cfg.Limit,
+		limitCeiling:     cfg.LimitCeiling,
+		peakLevel:        math.Inf(-1),
+		debugLogPath:     cfg.DebugLog,
+		audioTrack:       cfg.AudioTrack,
+		ioRetries:        cfg.IORetries,
+		retagStart:       cfg.RetagStart,
+		retagEnd:         cfg.RetagEnd,
+		commentLanguage:  commentLanguage,
+		transcript:       cfg.Transcript,
+		matchInputRate:   cfg.MatchInputRate,
+		preview:          cfg.Preview,
 		streamIndex:      -1,
 		outStreamIndex:   -1,
-		coverStreamIndex: -1,
 	}, nil
 }
 
 // Initialize opens input and output files, sets up decoder and encoder
 func (e *Encoder) Initialize() error {
-	// Keep stderr quiet: only surface FFmpeg errors, not its info/warning spam.
-	ffmpeg.AVLogSetLevel(ffmpeg.AVLogError)
+	if err := e.configureLogging(); err != nil {
+		return fmt.Errorf("failed to open debug log: %w", err)
+	}
 
 	if err := e.openInput(); err != nil {
 		return fmt.Errorf("failed to open input: %w", err)
 	}
 
+	if e.matchInputRate {
+		e.preset, _ = e.preset.atInputRate(e.decCtx.SampleRate())
+	}
+	if e.preview > 0 {
+		e.limitToPreview()
+	}
+
 	if err := e.openOutput(); err != nil {
 		e.Close()
 		return fmt.Errorf("failed to open output: %w", err)
@@ -138,31 +393,60 @@ func (e *Encoder) Initialize() error {
 	return nil
 }
 
+// limitToPreview shortens the encode to the preview length: the progress
+// total becomes the preview's sample count and chapters after it are dropped.
+// A preview at least as long as the input changes nothing.
+func (e *Encoder) limitToPreview() {
+	samples := int64(e.preview.Seconds() * float64(e.decCtx.SampleRate()))
+	if e.totalSamples > 0 && samples >= e.totalSamples {
+		return
+	}
+	e.previewSamples = samples
+	e.totalSamples = samples
+	e.chapters = slices.DeleteFunc(slices.Clone(e.chapters), func(ch Chapter) bool {
+		return ch.Start >= e.preview
+	})
+}
+
 // openInput opens and analyzes the input audio file
 func (e *Encoder) openInput() error {
 	urlPtr := ffmpeg.ToCStr(e.inputPath)
 	defer urlPtr.Free()
 
 	if _, err := ffmpeg.AVFormatOpenInput(&e.ifmtCtx, urlPtr, nil, nil); err != nil {
-		return fmt.Errorf("cannot open input file: %w", err)
+		return unreadableInput(e.inputPath, err)
 	}
 
 	if _, err := ffmpeg.AVFormatFindStreamInfo(e.ifmtCtx, nil); err != nil {
 		return fmt.Errorf("cannot find stream information: %w", err)
 	}
 
-	streamIdx, err := ffmpeg.AVFindBestStream(e.ifmtCtx, ffmpeg.AVMediaTypeAudio, -1, -1, nil, 0)
-	if err != nil {
-		return fmt.Errorf("cannot find audio stream: %w", err)
+	e.inputMetadata = readMetadata(e.ifmtCtx.Metadata())
+
+	// An explicit --audio-track wins; otherwise let FFmpeg pick the best
+	// stream, which honours the container's default disposition.
+	e.audioTracks = listAudioTracks(e.ifmtCtx)
+	if e.audioTrack > 0 {
+		track, err := trackByNumber(e.audioTracks, e.audioTrack)
+		if err != nil {
+			return err
+		}
+		e.streamIndex = track.StreamIndex
+	} else {
+		streamIdx, err := ffmpeg.AVFindBestStream(e.ifmtCtx, ffmpeg.AVMediaTypeAudio, -1, -1, nil, 0)
+		if err != nil {
+			return fmt.Errorf("%w: %s has no audio stream (%w)", ErrUnsupportedInput, filepath.Base(e.inputPath), err)
+		}
+		e.streamIndex = streamIdx
 	}
-	e.streamIndex = streamIdx
 
-	stream := e.ifmtCtx.Streams().Get(uintptr(e.streamIndex)) //nolint:gosec // streamIndex is validated by AVFindBestStream
+	stream := e.ifmtCtx.Streams().Get(uintptr(e.streamIndex)) //nolint:gosec // streamIndex is validated by AVFindBestStream or listAudioTracks
 	codecPar := stream.Codecpar()
 
 	decoder := ffmpeg.AVCodecFindDecoder(codecPar.CodecId())
 	if decoder == nil {
-		return fmt.Errorf("decoder not found for codec %d", codecPar.CodecId())
+		return fmt.Errorf("%w: FFmpeg has no decoder for the %s audio in %s; %s",
+			ErrUnsupportedInput, codecName(codecPar.CodecId()), filepath.Base(e.inputPath), supportedInputs)
 	}
 
 	e.decCtx = ffmpeg.AVCodecAllocContext3(decoder)
@@ -189,6 +473,27 @@ func (e *Encoder) openInput() error {
 	return nil
 }
 
+// unreadableInput explains why FFmpeg could not open the input at path. A
+// file Go can open is there and readable, so FFmpeg rejected its contents.
+func unreadableInput(path string, err error) error {
+	f, openErr := os.Open(path)
+	if openErr != nil {
+		return fmt.Errorf("cannot open input file: %w", openErr)
+	}
+	f.Close()
+	return fmt.Errorf("%w: %s is not in an audio format FFmpeg can read (%w); %s",
+		ErrUnsupportedInput, filepath.Base(path), err, supportedInputs)
+}
+
+// codecName returns FFmpeg's short name for a codec, such as "aac", or its
+// numeric ID when FFmpeg has no name for it.
+func codecName(id ffmpeg.AVCodecID) string {
+	if name := ffmpeg.AVCodecGetName(id); name != nil {
+		return name.String()
+	}
+	return fmt.Sprintf("codec %d", id)
+}
+
 // openOutput creates the output MP3 file and sets up the encoder
 func (e *Encoder) openOutput() error {
 	namePtr := ffmpeg.ToCStr(e.outputPath)
@@ -198,17 +503,9 @@ func (e *Encoder) openOutput() error {
 		return fmt.Errorf("failed to create output context: %w", err)
 	}
 
-	var encoder *ffmpeg.AVCodec
-	if e.preset.encoderName != "" {
-		namePtr := ffmpeg.ToCStr(e.preset.encoderName)
-		encoder = ffmpeg.AVCodecFindEncoderByName(namePtr)
-		namePtr.Free()
-	}
+	encoder := findEncoder(e.preset)
 	if encoder == nil {
-		encoder = ffmpeg.AVCodecFindEncoder(e.preset.codecID)
-	}
-	if encoder == nil {
-		return fmt.Errorf("%s encoder not found", e.preset.name)
+		return missingComponentError(fmt.Sprintf("%s encoder", strings.ToUpper(e.preset.name)), e.preset.library)
 	}
 
 	outStream := ffmpeg.AVFormatNewStream(e.ofmtCtx, encoder)
@@ -265,10 +562,43 @@ func (e *Encoder) openOutput() error {
 
 	outStream.SetTimeBase(e.encCtx.TimeBase())
 
+	return e.writeOutputHeader()
+}
+
+// mp3MuxerOptions returns the mp3 muxer's private options. id3v2_version picks
+// the tag version; for v2.3 the muxer itself splits the "date" key into TYER
+// (year) and TDAT (day and month, only for a full YYYY-MM-DD date), as v2.3
+// has no TDRC frame. write_xing controls the Xing/LAME info frame, which the
+// muxer fills with the frame count, seek table and the encoder delay and end
+// padding when it writes the trailer, so the output must be seekable. The
+// frame is written for CBR output too, tagged "Info", so players read the
+// exact duration rather than estimating it from the bitrate.
+func mp3MuxerOptions(id3Version int, gapless bool) map[string]string {
+	writeXing := "0"
+	if gapless {
+		writeXing = "1"
+	}
+	return map[string]string{
+		"id3v2_version": strconv.Itoa(id3Version),
+		"write_xing":    writeXing,
+	}
+}
+
+// writeOutputHeader opens the output file for the streams already added to
+// ofmtCtx, sets the tags, chapters and pictures, and writes the header and
+// picture packets, leaving the muxer ready for audio packets.
+func (e *Encoder) writeOutputHeader() error {
 	// Formats without the NOFILE flag need an explicit AVIO output handle.
 	if e.ofmtCtx.Oformat().Flags()&ffmpeg.AVFmtNofile == 0 {
 		var pb *ffmpeg.AVIOContext
-		if _, err := ffmpeg.AVIOOpen(&pb, e.ofmtCtx.Url(), ffmpeg.AVIOFlagWrite); err != nil {
+		err := RetryIO(e.ioRetries, func() error {
+			_, err := ffmpeg.AVIOOpen(&pb, e.ofmtCtx.Url(), ffmpeg.AVIOFlagWrite)
+			if err != nil {
+				e.debugf("output open failed: %v", err)
+			}
+			return err
+		})
+		if err != nil {
 			return fmt.Errorf("failed to open output file: %w", err)
 		}
 		e.ofmtCtx.SetPb(pb)
@@ -278,28 +608,36 @@ func (e *Encoder) openOutput() error {
 		return err
 	}
 
-	// Add the attached-picture stream after the audio stream so audio keeps
-	// index 0 (outStreamIndex). Opus is not cover-capable and absent cover
-	// bytes mean no second stream, leaving the audio-only path unchanged.
-	if e.preset.coverCapable && len(e.coverArt) > 0 {
-		if err := e.addCoverStream(); err != nil {
-			return err
+	if err := e.attachChapters(); err != nil {
+		return fmt.Errorf("failed to add chapters: %w", err)
+	}
+
+	// Add the attached-picture streams after the audio stream so audio keeps
+	// index 0 (outStreamIndex). Opus is not cover-capable and no pictures mean
+	// no extra streams, leaving the audio-only path unchanged.
+	if e.preset.coverCapable {
+		for _, pic := range e.pictures {
+			if err := e.addPictureStream(pic); err != nil {
+				return err
+			}
 		}
 	}
 
-	// id3v2_version is an mp3-muxer-private option, so it goes through the
-	// WriteHeader options dict, not the format-context metadata. Other muxers
-	// ignore it. The dict is owned here and freed after WriteHeader.
+	// The mp3 muxer's private options go through the WriteHeader options
+	// dict, not the format-context metadata. The dict is owned here and freed
+	// after WriteHeader.
 	var muxerOpts *ffmpeg.AVDictionary
 	if e.preset.name == "mp3" {
-		keyPtr := ffmpeg.ToCStr("id3v2_version")
-		valPtr := ffmpeg.ToCStr("4")
-		_, err := ffmpeg.AVDictSet(&muxerOpts, keyPtr, valPtr, 0)
-		keyPtr.Free()
-		valPtr.Free()
-		if err != nil {
-			ffmpeg.AVDictFree(&muxerOpts)
-			return fmt.Errorf("failed to set id3v2_version option: %w", err)
+		for key, val := range mp3MuxerOptions(e.id3Version, e.gapless) {
+			keyPtr := ffmpeg.ToCStr(key)
+			valPtr := ffmpeg.ToCStr(val)
+			_, err := ffmpeg.AVDictSet(&muxerOpts, keyPtr, valPtr, 0)
+			keyPtr.Free()
+			valPtr.Free()
+			if err != nil {
+				ffmpeg.AVDictFree(&muxerOpts)
+				return fmt.Errorf("failed to set muxer option %s: %w", key, err)
+			}
 		}
 	}
 
@@ -309,10 +647,10 @@ func (e *Encoder) openOutput() error {
 	}
 	ffmpeg.AVDictFree(&muxerOpts)
 
-	// Write the cover picture immediately after the header so the muxer carries
-	// it as the attached picture before any audio packet.
-	if e.coverStreamIndex >= 0 {
-		if err := e.writeCoverPacket(); err != nil {
+	// Write the pictures immediately after the header so the muxer carries
+	// them as attached pictures before any audio packet.
+	for i, index := range e.pictureStreams {
+		if err := e.writePicturePacket(index, e.pictures[i].Data); err != nil {
 			return err
 		}
 	}
@@ -320,11 +658,11 @@ func (e *Encoder) openOutput() error {
 	return nil
 }
 
-// addCoverStream creates the attached-picture stream that carries the scaled
-// PNG cover. It is added after the audio stream, so the audio stream keeps
+// addPictureStream creates an attached-picture stream that carries one scaled
+// PNG image. It is added after the audio stream, so the audio stream keeps
 // index 0. The packet itself is written after AVFormatWriteHeader by
-// writeCoverPacket.
-func (e *Encoder) addCoverStream() error {
+// writePicturePacket.
+func (e *Encoder) addPictureStream(pic Picture) error {
 	coverStream := ffmpeg.AVFormatNewStream(e.ofmtCtx, nil)
 	if coverStream == nil {
 		return fmt.Errorf("failed to create cover stream")
@@ -332,11 +670,26 @@ func (e *Encoder) addCoverStream() error {
 
 	// The mp3 and ipod muxers reject an attached-picture stream without
 	// dimensions, so read them from the PNG header.
-	cfg, err := png.DecodeConfig(bytes.NewReader(e.coverArt))
+	cfg, err := png.DecodeConfig(bytes.NewReader(pic.Data))
 	if err != nil {
 		return fmt.Errorf("failed to read cover dimensions: %w", err)
 	}
 
+	// SetMetadata hands the dict to the stream, so it is not freed here.
+	var dict *ffmpeg.AVDictionary
+	for _, tag := range pictureStreamTags(pic) {
+		keyPtr := ffmpeg.ToCStr(tag.Key)
+		valPtr := ffmpeg.ToCStr(tag.Value)
+		_, err := ffmpeg.AVDictSet(&dict, keyPtr, valPtr, 0)
+		keyPtr.Free()
+		valPtr.Free()
+		if err != nil {
+			ffmpeg.AVDictFree(&dict)
+			return fmt.Errorf("failed to set picture metadata %s: %w", tag.Key, err)
+		}
+	}
+	coverStream.SetMetadata(dict)
+
 	codecPar := coverStream.Codecpar()
 	codecPar.SetCodecType(ffmpeg.AVMediaTypeVideo)
 	// ScaleCoverArt always emits PNG, so the picture stream uses the PNG codec.
@@ -345,29 +698,29 @@ func (e *Encoder) addCoverStream() error {
 	codecPar.SetHeight(cfg.Height)
 	coverStream.SetDisposition(ffmpeg.AVDispositionAttachedPic)
 
-	e.coverStreamIndex = coverStream.Index()
+	e.pictureStreams = append(e.pictureStreams, coverStream.Index())
 	return nil
 }
 
-// writeCoverPacket allocates a packet sized to the cover bytes, copies the PNG
-// data into it, marks it a keyframe on the attached-picture stream, and writes
-// it to the muxer. The packet is freed before returning, so Close never touches
-// it.
-func (e *Encoder) writeCoverPacket() error {
+// writePicturePacket allocates a packet sized to the picture bytes, copies the
+// PNG data into it, marks it a keyframe on the attached-picture stream at
+// index, and writes it to the muxer. The packet is freed before returning, so
+// Close never touches it.
+func (e *Encoder) writePicturePacket(index int, data []byte) error {
 	pkt := ffmpeg.AVPacketAlloc()
 	if pkt == nil {
 		return fmt.Errorf("failed to allocate cover packet")
 	}
 	defer ffmpeg.AVPacketFree(&pkt)
 
-	if _, err := ffmpeg.AVNewPacket(pkt, len(e.coverArt)); err != nil {
+	if _, err := ffmpeg.AVNewPacket(pkt, len(data)); err != nil {
 		return fmt.Errorf("failed to allocate cover packet data: %w", err)
 	}
 
-	dst := unsafe.Slice((*byte)(pkt.Data()), len(e.coverArt))
-	copy(dst, e.coverArt)
+	dst := unsafe.Slice((*byte)(pkt.Data()), len(data))
+	copy(dst, data)
 
-	pkt.SetStreamIndex(e.coverStreamIndex)
+	pkt.SetStreamIndex(index)
 	pkt.SetFlags(pkt.Flags() | ffmpeg.AVPktFlagKey)
 
 	if _, err := ffmpeg.AVInterleavedWriteFrame(e.ofmtCtx, pkt); err != nil {
@@ -383,6 +736,15 @@ func (e *Encoder) writeCoverPacket() error {
 // never freed here. Preset-agnostic: every format gets the same standard keys.
 func (e *Encoder) setMuxerMetadata() error {
 	tags := buildMuxerTags(e.metadata)
+	if e.preset.pictureTag {
+		for _, pic := range e.pictures {
+			tag, err := pictureBlockTag(pic)
+			if err != nil {
+				return fmt.Errorf("failed to build cover picture block: %w", err)
+			}
+			tags = append(tags, tag)
+		}
+	}
 	if len(tags) == 0 {
 		return nil
 	}
@@ -391,7 +753,13 @@ func (e *Encoder) setMuxerMetadata() error {
 	for _, tag := range tags {
 		keyPtr := ffmpeg.ToCStr(tag.Key)
 		valPtr := ffmpeg.ToCStr(tag.Value)
-		_, err := ffmpeg.AVDictSet(&dict, keyPtr, valPtr, 0)
+		// Each picture is its own METADATA_BLOCK_PICTURE comment, so that key
+		// must not overwrite an earlier entry.
+		flags := 0
+		if tag.Key == pictureBlockKey {
+			flags = ffmpeg.AVDictMultikey
+		}
+		_, err := ffmpeg.AVDictSet(&dict, keyPtr, valPtr, flags)
 		keyPtr.Free()
 		valPtr.Free()
 		if err != nil {
@@ -406,6 +774,53 @@ func (e *Encoder) setMuxerMetadata() error {
 
 // initFilter sets up audio filter graph for resampling and frame buffering
 func (e *Encoder) initFilter() error {
+	// Build filter spec: resample to the preset's sample rate and sample format,
+	// set the target channel layout (stereo keeps channels, mono downmixes).
+	// Frame sizing is applied to the buffer sink after this graph is built
+	// (see below), not via asetnsamples, so each encoder gets its required size.
+	// Dither applies only where precision is lost: a 24-bit, 32-bit or float
+	// source going to 16-bit integer samples.
+	dither := e.dither &&
+		ffmpeg.AVGetBytesPerSample(e.decCtx.SampleFmt()) > 2 &&
+		ffmpeg.AVGetBytesPerSample(e.preset.sampleFmt) == 2
+	sampleFmtName := ffmpeg.AVGetSampleFmtName(e.preset.sampleFmt).String()
+	// Picking one channel only makes sense for a mono output from a source
+	// that has more than one.
+	downmix := ""
+	if !e.stereo && e.decCtx.ChLayout().NbChannels() > 1 {
+		downmix = e.downmix
+	}
+	limit := 0.0
+	if e.limit {
+		limit = math.Pow(10, e.limitCeiling/20)
+	}
+	filterSpec := buildFilterSpec(e.preset.sampleRate, sampleFmtName, e.stereo, dither, downmix, limit)
+	if e.previewSamples > 0 {
+		// Decoding stops on the frame that reaches the preview length, so trim
+		// the overshoot to end the preview on the exact sample.
+		filterSpec = fmt.Sprintf("atrim=end_sample=%d,%s", e.previewSamples, filterSpec)
+	}
+
+	if err := e.buildFilterGraph(filterSpec); err != nil {
+		return err
+	}
+
+	// Fix the buffer-sink frame size to the encoder's required frame size so the
+	// filter delivers exactly the frames the encoder expects (LAME 1152, native
+	// AAC 1024, libopus its own). Encoders that accept variable-size frames
+	// advertise AV_CODEC_CAP_VARIABLE_FRAME_SIZE and need no fixed size.
+	// openOutput runs before initFilter, so encCtx.FrameSize() is populated here.
+	if frameSize := e.encCtx.FrameSize(); frameSize > 0 &&
+		e.encCtx.Codec().Capabilities()&ffmpeg.AVCodecCapVariableFrameSize == 0 {
+		ffmpeg.AVBuffersinkSetFrameSize(e.bufferSinkCtx, uint(frameSize))
+	}
+
+	return nil
+}
+
+// buildFilterGraph creates the filter graph from the decoder's output format
+// through filterSpec to a buffer sink, and configures it.
+func (e *Encoder) buildFilterGraph(filterSpec string) error {
 	e.filterGraph = ffmpeg.AVFilterGraphAlloc()
 	if e.filterGraph == nil {
 		return fmt.Errorf("failed to allocate filter graph")
@@ -432,6 +847,7 @@ func (e *Encoder) initFilter() error {
 		layoutPtr.String(),
 	)
 
+	e.debugf("abuffer args: %s", args)
 	argsC := ffmpeg.ToCStr(args)
 	defer argsC.Free()
 
@@ -457,7 +873,7 @@ func (e *Encoder) initFilter() error {
 		return fmt.Errorf("failed to create buffer sink: %w", err)
 	}
 
-	// Parse filter graph - use aresample for format/rate/channel conversion
+	// Parse the filter chain between the buffer source and sink
 	outputs := ffmpeg.AVFilterInoutAlloc()
 	inputs := ffmpeg.AVFilterInoutAlloc()
 	defer ffmpeg.AVFilterInoutFree(&outputs)
@@ -473,18 +889,7 @@ func (e *Encoder) initFilter() error {
 	inputs.SetPadIdx(0)
 	inputs.SetNext(nil)
 
-	// Build filter spec: resample to the preset's sample rate and sample format,
-	// set the target channel layout (stereo keeps channels, mono downmixes).
-	// Frame sizing is applied to the buffer sink after this graph is built
-	// (see below), not via asetnsamples, so each encoder gets its required size.
-	channelLayout := "mono"
-	if e.stereo {
-		channelLayout = "stereo"
-	}
-	sampleFmtName := ffmpeg.AVGetSampleFmtName(e.preset.sampleFmt).String()
-	filterSpec := fmt.Sprintf("aresample=%d:async=1,aformat=sample_fmts=%s:sample_rates=%d:channel_layouts=%s",
-		e.preset.sampleRate, sampleFmtName, e.preset.sampleRate, channelLayout)
-
+	e.debugf("filter spec: %s", filterSpec)
 	filterSpecC := ffmpeg.ToCStr(filterSpec)
 	defer filterSpecC.Free()
 
@@ -496,29 +901,155 @@ func (e *Encoder) initFilter() error {
 		return fmt.Errorf("failed to configure filter graph: %w", err)
 	}
 
-	// Fix the buffer-sink frame size to the encoder's required frame size so the
-	// filter delivers exactly the frames the encoder expects (LAME 1152, native
-	// AAC 1024, libopus its own). Encoders that accept variable-size frames
-	// advertise AV_CODEC_CAP_VARIABLE_FRAME_SIZE and need no fixed size.
-	// openOutput runs before initFilter, so encCtx.FrameSize() is populated here.
-	if frameSize := e.encCtx.FrameSize(); frameSize > 0 &&
-		e.encCtx.Codec().Capabilities()&ffmpeg.AVCodecCapVariableFrameSize == 0 {
-		ffmpeg.AVBuffersinkSetFrameSize(e.bufferSinkCtx, uint(frameSize))
+	return nil
+}
+
+// Downmix modes for a mono output: average every channel, FFmpeg's default,
+// or keep only the first (left) or second (right) channel.
+const (
+	DownmixAverage = "average"
+	DownmixLeft    = "left"
+	DownmixRight   = "right"
+)
+
+// downmixPans maps the single-channel downmix modes to the pan filter that
+// keeps just that channel.
+var downmixPans = map[string]string{
+	DownmixLeft:  "pan=mono|c0=c0",
+	DownmixRight: "pan=mono|c0=c1",
+}
+
+// buildFilterSpec returns the filter graph description that resamples to
+// sampleRate and sampleFmt and sets the channel layout (stereo keeps
+// channels, mono downmixes). With dither, the resampler adds triangular
+// dither when it reduces the sample bit depth, in place of plain truncation.
+// downmix picks a single channel for the mono downmix; "" or DownmixAverage
+// leaves the default averaging. A non-zero limit is a linear peak ceiling:
+// astats measures the peaks ahead of an alimiter held to it, so the encoder
+// can tell whether the limiter had anything to do.
+func buildFilterSpec(sampleRate int, sampleFmt string, stereo, dither bool, downmix string, limit float64) string {
+	channelLayout := "mono"
+	if stereo {
+		channelLayout = "stereo"
 	}
+	var filters []string
+	if pan, ok := downmixPans[downmix]; ok && !stereo {
+		filters = append(filters, pan)
+	}
+	resample := fmt.Sprintf("aresample=%d:async=1", sampleRate)
+	if dither {
+		resample += ":dither_method=triangular"
+	}
+	filters = append(filters, resample)
+	if limit > 0 {
+		// level=0 stops alimiter raising the output back up to the ceiling,
+		// and latency=1 keeps the audio in step with the chapter marks.
+		filters = append(filters,
+			"astats=metadata=1:measure_perchannel=none:measure_overall=Peak_level",
+			fmt.Sprintf("alimiter=limit=%.4f:level=0:latency=1", limit))
+	}
+	filters = append(filters, fmt.Sprintf("aformat=sample_fmts=%s:sample_rates=%d:channel_layouts=%s",
+		sampleFmt, sampleRate, channelLayout))
+	return strings.Join(filters, ",")
+}
 
-	return nil
+// peakLevelKey is the frame metadata entry astats sets to the highest peak
+// seen so far, in dBFS.
+const peakLevelKey = "lavfi.astats.Overall.Peak_level"
+
+// trackPeakLevel records the peak astats reported on a filtered frame. The
+// metadata rides through alimiter on the frame, so it describes the audio
+// before limiting.
+func (e *Encoder) trackPeakLevel(frame *ffmpeg.AVFrame) {
+	keyPtr := ffmpeg.ToCStr(peakLevelKey)
+	defer keyPtr.Free()
+
+	entry := ffmpeg.AVDictGet(frame.Metadata(), keyPtr, nil, 0)
+	if entry == nil {
+		return
+	}
+	// FFmpeg writes silence as "-inf", which ParseFloat accepts.
+	if peak, err := strconv.ParseFloat(entry.Value().String(), 64); err == nil {
+		e.peakLevel = max(e.peakLevel, peak)
+	}
+}
+
+// limited reports whether the limiter engaged: some peak ahead of it rose
+// above the ceiling.
+func (e *Encoder) limited() bool {
+	return e.limit && e.peakLevel > e.limitCeiling
 }
 
-// ProgressCallback is called during encoding with progress updates
+// ProgressCallback is called during encoding with progress updates. By default
+// it fires for every decoded frame; Config.ProgressInterval and
+// Config.ProgressStep coalesce the calls.
 type ProgressCallback func(samplesProcessed, totalSamples int64)
 
-// Encode performs the actual encoding with progress callbacks
-func (e *Encoder) Encode(progressCb ProgressCallback) error {
+// EncodeResult summarises a finished encode so callers need not query the
+// encoder afterwards.
+type EncodeResult struct {
+	OutputPath       string
+	DurationSecs     int64 // encoded duration, rounded to the nearest second
+	SamplesProcessed int64 // output-rate samples sent to the encoder
+	BytesWritten     int64 // size of the finished output file
+	Limited          bool  // the peak limiter reduced at least one peak
+
+	// AudioDuration is the length of the encoded audio and Elapsed the
+	// wall-clock time Encode took, for RealtimeFactor.
+	AudioDuration time.Duration
+	Elapsed       time.Duration
+}
+
+// RealtimeFactor returns how many times faster than realtime the encode ran:
+// the audio duration over the wall-clock time. It is 0 when nothing was timed.
+func (r EncodeResult) RealtimeFactor() float64 {
+	if r.Elapsed <= 0 {
+		return 0
+	}
+	return r.AudioDuration.Seconds() / r.Elapsed.Seconds()
+}
+
+// Encode performs the actual encoding with progress callbacks and returns a
+// summary of the written file. On error the result is the zero value.
+func (e *Encoder) Encode(progressCb ProgressCallback) (EncodeResult, error) {
+	start := time.Now()
+	if err := e.encode(progressCb); err != nil {
+		return EncodeResult{}, err
+	}
+	if err := e.writeCommentLanguage(); err != nil {
+		return EncodeResult{}, err
+	}
+	if err := e.writeTranscript(); err != nil {
+		return EncodeResult{}, err
+	}
+	elapsed := time.Since(start)
+
+	info, err := os.Stat(e.outputPath)
+	if err != nil {
+		return EncodeResult{}, fmt.Errorf("failed to stat encoded file: %w", err)
+	}
+
+	return EncodeResult{
+		OutputPath:       e.outputPath,
+		DurationSecs:     e.GetDurationSecs(),
+		SamplesProcessed: e.nextPts,
+		BytesWritten:     info.Size(),
+		Limited:          e.limited(),
+		AudioDuration:    time.Duration(float64(e.nextPts) / float64(e.preset.sampleRate) * float64(time.Second)),
+		Elapsed:          elapsed,
+	}, nil
+}
+
+// encode runs the decode → filter → encode loop and writes the trailer.
+func (e *Encoder) encode(progressCb ProgressCallback) error {
 	packet := ffmpeg.AVPacketAlloc()
 	defer ffmpeg.AVPacketFree(&packet)
 
 	outStream := e.ofmtCtx.Streams().Get(uintptr(e.outStreamIndex)) //nolint:gosec // outStreamIndex is set from AVFormatNewStream in openOutput
 
+	throttle := newProgressThrottle(e.progressInterval, e.progressStep)
+
+	previewDone := false
 	for {
 		// Observe cancellation before the next cgo call so Encode returns while
 		// the AV contexts are still valid, ahead of any Close.
@@ -557,8 +1088,18 @@ func (e *Encoder) Encode(progressCb ProgressCallback) error {
 				return fmt.Errorf("receive frame from decoder failed: %w", err)
 			}
 
+			// Past the preview, frames are drained from the decoder unused.
+			if previewDone {
+				ffmpeg.AVFrameUnref(e.decFrame)
+				continue
+			}
+
 			e.samplesRead += int64(e.decFrame.NbSamples())
-			if progressCb != nil && e.totalSamples > 0 {
+			if e.previewSamples > 0 && e.samplesRead >= e.previewSamples {
+				previewDone = true
+				e.samplesRead = e.previewSamples
+			}
+			if progressCb != nil && e.totalSamples > 0 && throttle.allow(e.samplesRead, e.totalSamples) {
 				progressCb(e.samplesRead, e.totalSamples)
 			}
 
@@ -572,6 +1113,15 @@ func (e *Encoder) Encode(progressCb ProgressCallback) error {
 
 			ffmpeg.AVFrameUnref(e.decFrame)
 		}
+		if previewDone {
+			break
+		}
+	}
+
+	// Deliver the final position if the throttle held it back, so consumers
+	// always see the last progress reading.
+	if progressCb != nil && e.totalSamples > 0 && throttle.pending {
+		progressCb(e.samplesRead, e.totalSamples)
 	}
 
 	// Flush decoder
@@ -587,6 +1137,18 @@ func (e *Encoder) Encode(progressCb ProgressCallback) error {
 			return fmt.Errorf("flush decoder receive failed: %w", err)
 		}
 
+		// Frames held in the decoder count towards the length, up to the end
+		// of any preview; past it they are dropped.
+		if previewDone {
+			ffmpeg.AVFrameUnref(e.decFrame)
+			continue
+		}
+		e.samplesRead += int64(e.decFrame.NbSamples())
+		if e.previewSamples > 0 && e.samplesRead >= e.previewSamples {
+			previewDone = true
+			e.samplesRead = e.previewSamples
+		}
+
 		// Keep a ref (AVBuffersrcFlagKeepRef) because we reuse e.decFrame each
 		// iteration and unref it ourselves below. The filter-graph flush feeds a
 		// nil frame, so KEEP_REF is inapplicable there and it passes 0.
@@ -601,6 +1163,10 @@ func (e *Encoder) Encode(progressCb ProgressCallback) error {
 		ffmpeg.AVFrameUnref(e.decFrame)
 	}
 
+	if err := checkInputLength(e.samplesRead, e.decCtx.SampleRate()); err != nil {
+		return err
+	}
+
 	// Flush filter graph
 	if _, err := ffmpeg.AVBuffersrcAddFrameFlags(e.bufferSrcCtx, nil, 0); err != nil {
 		return fmt.Errorf("failed to flush filter graph: %w", err)
@@ -623,6 +1189,19 @@ func (e *Encoder) Encode(progressCb ProgressCallback) error {
 	return nil
 }
 
+// checkInputLength returns ErrNoAudio when samples decoded at sampleRate fall
+// short of MinInputDuration.
+func checkInputLength(samples int64, sampleRate int) error {
+	if samples == 0 {
+		return fmt.Errorf("%w: the input is empty", ErrNoAudio)
+	}
+	if sampleRate > 0 && samples < int64(MinInputDuration.Seconds()*float64(sampleRate)) {
+		length := time.Duration(float64(samples) / float64(sampleRate) * float64(time.Second))
+		return fmt.Errorf("%w: only %s decoded, under the %s minimum", ErrNoAudio, length.Round(time.Millisecond), MinInputDuration)
+	}
+	return nil
+}
+
 // drainFilterGraph reads filtered frames from the buffersink until EAGAIN or
 // EOF, encoding each one. Callers feed the buffersrc before invoking this.
 func (e *Encoder) drainFilterGraph(outStream *ffmpeg.AVStream) error {
@@ -634,6 +1213,10 @@ func (e *Encoder) drainFilterGraph(outStream *ffmpeg.AVStream) error {
 			return fmt.Errorf("failed to get filtered frame: %w", err)
 		}
 
+		if e.limit {
+			e.trackPeakLevel(e.filteredFrame)
+		}
+
 		if err := e.encodeFrame(e.filteredFrame, outStream); err != nil {
 			return err
 		}
@@ -737,6 +1320,9 @@ func (e *Encoder) Close() {
 	if e.ifmtCtx != nil {
 		ffmpeg.AVFormatCloseInput(&e.ifmtCtx)
 	}
+	if e.debug != nil {
+		e.debug.close()
+	}
 }
 
 // GetInputInfo returns information about the input audio
@@ -750,7 +1336,9 @@ func (e *Encoder) GetInputInfo() (sampleRate, channels int, format string) {
 }
 
 // GetDurationSecs returns the duration of the encoded audio in seconds.
-// This is calculated from the samples processed during encoding, avoiding
+// This is calculated from the samples actually fed to the encoder (nextPts) at
+// the output sample rate, not from input samples read, so it stays accurate
+// when the filter graph resamples (e.g. 44.1kHz to 48kHz for Opus). It avoids
 // the need to re-open the output file. Should be called after Encode() completes.
 func (e *Encoder) GetDurationSecs() int64 {
 	if e.encCtx == nil {
@@ -760,10 +1348,27 @@ func (e *Encoder) GetDurationSecs() int64 {
 	if sampleRate <= 0 {
 		return 0
 	}
-	// nextPts tracks total samples written to the encoder; round to nearest second
+	// nextPts counts output-rate samples sent to the encoder; round to nearest second
 	return (e.nextPts + int64(sampleRate)/2) / int64(sampleRate)
 }
 
+// EstimatedOutputBytes estimates the output file size from the input duration
+// and the target bitrate, plus the embedded pictures. Opus is VBR, so its
+// estimate is only as good as the target. It returns 0 when the input duration
+// is unknown. Call it after Initialize.
+func (e *Encoder) EstimatedOutputBytes() int64 {
+	duration := e.inputDuration()
+	if duration <= 0 {
+		return 0
+	}
+
+	size := int64(duration.Seconds() * float64(e.Bitrate()) * 1000 / 8)
+	for _, pic := range e.pictures {
+		size += int64(len(pic.Data))
+	}
+	return size
+}
+
 // Bitrate returns the output bitrate in kbps for the configured channel mode,
 // read from the active format preset (CBR for MP3/AAC, the VBR target for Opus).
 func (e *Encoder) Bitrate() int {
@@ -773,6 +1378,12 @@ func (e *Encoder) Bitrate() int {
 	return e.preset.monoBitrate / 1000
 }
 
+// AcceptsSampleRate reports whether the output format can encode at rate,
+// either its own rate or one MatchInputRate can use.
+func (e *Encoder) AcceptsSampleRate(rate int) bool {
+	return rate == e.preset.sampleRate || slices.Contains(e.preset.lowerRates, rate)
+}
+
 // FormatLabel returns the uppercase format name for display (e.g. "MP3",
 // "AAC", "OPUS").
 func (e *Encoder) FormatLabel() string {
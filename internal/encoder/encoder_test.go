@@ -1,17 +1,52 @@
 package encoder
 
 import (
+	"bytes"
+	"encoding/binary"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"math"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/linuxmatters/jivedrop/internal/id3"
 )
 
+// TestNewPictures verifies that New puts the front cover ahead of the extra
+// pictures and drops any picture without data.
+func TestNewPictures(t *testing.T) {
+	enc, err := New(Config{
+		InputPath:  "in.flac",
+		OutputPath: "out.mp3",
+		CoverArt:   []byte("front"),
+		Pictures: []Picture{
+			{Type: PictureBackCover, Data: []byte("back")},
+			{Type: PictureBandLogo},
+		},
+	})
+	if err != nil {
+		t.Fatalf("New() unexpected error: %v", err)
+	}
+
+	var types []PictureType
+	for _, pic := range enc.pictures {
+		types = append(types, pic.Type)
+	}
+	if want := []PictureType{PictureFrontCover, PictureBackCover}; !slices.Equal(types, want) {
+		t.Errorf("picture types = %v, want %v", types, want)
+	}
+}
+
 // TestNewFormatResolution verifies that New defaults an empty Format to the
 // mp3 preset and rejects an unknown format.
 func TestNewFormatResolution(t *testing.T) {
@@ -25,6 +60,101 @@ func TestNewFormatResolution(t *testing.T) {
 		}
 	})
 
+	t.Run("unsupported ID3 version errors", func(t *testing.T) {
+		if _, err := New(Config{
+			InputPath:  "in.flac",
+			OutputPath: "out.mp3",
+			ID3Version: 2,
+		}); err == nil {
+			t.Fatal("expected error for ID3v2.2, got nil")
+		}
+	})
+
+	t.Run("negative audio track errors", func(t *testing.T) {
+		if _, err := New(Config{
+			InputPath:  "in.flac",
+			OutputPath: "out.mp3",
+			AudioTrack: -1,
+		}); err == nil {
+			t.Fatal("expected error for audio track -1, got nil")
+		}
+	})
+
+	t.Run("negative I/O retries errors", func(t *testing.T) {
+		if _, err := New(Config{
+			InputPath:  "in.flac",
+			OutputPath: "out.mp3",
+			IORetries:  -1,
+		}); err == nil {
+			t.Fatal("expected error for I/O retries -1, got nil")
+		}
+	})
+
+	t.Run("too many MP3 chapters errors", func(t *testing.T) {
+		chapters := make([]Chapter, MaxChapters+1)
+		for i := range chapters {
+			chapters[i] = Chapter{Start: time.Duration(i) * time.Second, Title: "Chapter"}
+		}
+		if _, err := New(Config{
+			InputPath:  "in.flac",
+			OutputPath: "out.mp3",
+			Chapters:   chapters,
+		}); err == nil {
+			t.Fatalf("expected error for %d MP3 chapters, got nil", len(chapters))
+		}
+		if _, err := New(Config{
+			InputPath:  "in.flac",
+			OutputPath: "out.m4b",
+			Format:     "m4b",
+			Chapters:   chapters,
+		}); err != nil {
+			t.Fatalf("New() with %d M4B chapters error: %v", len(chapters), err)
+		}
+	})
+
+	t.Run("transcript outside MP3 or over the cap errors", func(t *testing.T) {
+		transcript := []TranscriptLine{{Start: 0, Text: "Hello"}}
+		if _, err := New(Config{
+			InputPath:  "in.flac",
+			OutputPath: "out.opus",
+			Format:     "opus",
+			Transcript: transcript,
+		}); err == nil {
+			t.Fatal("expected error for an Opus transcript, got nil")
+		}
+		huge := []TranscriptLine{{Start: 0, Text: strings.Repeat("x", MaxTranscriptBytes)}}
+		if _, err := New(Config{
+			InputPath:  "in.flac",
+			OutputPath: "out.mp3",
+			Transcript: huge,
+		}); err == nil {
+			t.Fatal("expected error for a transcript over MaxTranscriptBytes, got nil")
+		}
+	})
+
+	t.Run("unknown downmix errors", func(t *testing.T) {
+		if _, err := New(Config{
+			InputPath:  "in.flac",
+			OutputPath: "out.mp3",
+			Downmix:    "centre",
+		}); err == nil {
+			t.Fatal("expected error for downmix centre, got nil")
+		}
+	})
+
+	t.Run("limiter ceiling out of range errors", func(t *testing.T) {
+		for _, ceiling := range []float64{0.5, -30} {
+			if _, err := New(Config{
+				InputPath:    "in.flac",
+				OutputPath:   "out.mp3",
+				Limit:        true,
+				LimitCeiling: ceiling,
+			}); err == nil {
+				t.Fatalf("expected error for limiter ceiling %gdB, got nil", ceiling)
+			}
+		}
+	})
+
 	t.Run("empty format resolves to mp3", func(t *testing.T) {
 		enc, err := New(Config{
 			InputPath:  "in.flac",
@@ -39,6 +169,12 @@ func TestNewFormatResolution(t *testing.T) {
 		if enc.outStreamIndex != -1 {
 			t.Fatalf("expected outStreamIndex -1, got %d", enc.outStreamIndex)
 		}
+		if enc.id3Version != DefaultID3Version {
+			t.Fatalf("expected ID3v2.%d by default, got %d", DefaultID3Version, enc.id3Version)
+		}
+		if enc.downmix != DownmixAverage {
+			t.Fatalf("expected %s downmix by default, got %q", DownmixAverage, enc.downmix)
+		}
 	})
 }
 
@@ -51,78 +187,357 @@ func TestEncodeToMP3_Integration(t *testing.T) {
 		t.Skipf("Test file not found: %s", inputPath)
 	}
 
-	outputPath := "../../testdata/LMP0.mp3"
-
-	// Not cleaned up: other tests reuse this MP3, and it is already gitignored.
-
-	t.Run("mono encoding", func(t *testing.T) {
-		enc, err := New(Config{
-			InputPath:  inputPath,
-			OutputPath: outputPath,
-			Stereo:     false,
-		})
+	// The encodes go to memory, so nothing is left in testdata.
+	encode := func(t *testing.T, stereo bool) []byte {
+		t.Helper()
+		var out bytes.Buffer
+		result, err := EncodeTo(&out, Config{InputPath: inputPath, Stereo: stereo}, nil)
 		if err != nil {
-			t.Fatalf("Failed to create encoder: %v", err)
+			t.Fatalf("EncodeTo failed: %v", err)
 		}
-		defer enc.Close()
-
-		if err := enc.Initialize(); err != nil {
-			t.Fatalf("Failed to initialize encoder: %v", err)
+		if result.BytesWritten != int64(out.Len()) {
+			t.Errorf("BytesWritten = %d, want %d", result.BytesWritten, out.Len())
 		}
-
-		err = enc.Encode(nil)
-		if err != nil {
-			t.Fatalf("Encode failed: %v", err)
+		// A real audio file should exceed 1KB; a smaller output signals a broken encode.
+		if out.Len() < 1024 {
+			t.Errorf("Output too small: %d bytes", out.Len())
+		}
+		if !bytes.HasPrefix(out.Bytes(), []byte("ID3")) {
+			t.Error("Output does not start with an ID3 tag")
 		}
+		return out.Bytes()
+	}
 
-		info, err := os.Stat(outputPath)
+	mono := encode(t, false)
+	stereo := encode(t, true)
+
+	// Stereo file should be larger than mono (approximately 192/112 ratio)
+	if len(stereo) <= len(mono) {
+		t.Errorf("stereo output %d bytes, want more than mono's %d", len(stereo), len(mono))
+	}
+	t.Logf("Encoded MP3: %d bytes mono, %d bytes stereo", len(mono), len(stereo))
+}
+
+// BenchmarkEncodeMP3 measures encode throughput on ten seconds of 24-bit
+// stereo audio, encoded in memory. The realtime metric is seconds of audio
+// encoded per second.
+func BenchmarkEncodeMP3(b *testing.B) {
+	inputPath := filepath.Join(b.TempDir(), "tone.wav")
+	writeTestWAV24(b, inputPath, 48000, 10)
+
+	b.ResetTimer()
+	for b.Loop() {
+		result, err := EncodeTo(io.Discard, Config{InputPath: inputPath}, nil)
 		if err != nil {
-			t.Fatalf("Output file not created: %v", err)
+			b.Fatalf("EncodeTo failed: %v", err)
 		}
+		b.ReportMetric(result.RealtimeFactor(), "x_realtime")
+	}
+}
 
-		// A real audio file should exceed 1KB; a smaller output signals a broken encode.
-		if info.Size() < 1024 {
-			t.Errorf("Output file too small: %d bytes", info.Size())
-		}
+func TestBuildFilterSpec(t *testing.T) {
+	tests := []struct {
+		name    string
+		stereo  bool
+		dither  bool
+		downmix string
+		limit   float64
+		want    string
+	}{
+		{name: "mono", want: "aresample=44100:async=1,aformat=sample_fmts=s16p:sample_rates=44100:channel_layouts=mono"},
+		{name: "stereo", stereo: true, want: "aresample=44100:async=1,aformat=sample_fmts=s16p:sample_rates=44100:channel_layouts=stereo"},
+		{name: "dither", dither: true, want: "aresample=44100:async=1:dither_method=triangular,aformat=sample_fmts=s16p:sample_rates=44100:channel_layouts=mono"},
+		{name: "average downmix", downmix: DownmixAverage, want: "aresample=44100:async=1,aformat=sample_fmts=s16p:sample_rates=44100:channel_layouts=mono"},
+		{name: "left downmix", downmix: DownmixLeft, want: "pan=mono|c0=c0,aresample=44100:async=1,aformat=sample_fmts=s16p:sample_rates=44100:channel_layouts=mono"},
+		{name: "right downmix", downmix: DownmixRight, want: "pan=mono|c0=c1,aresample=44100:async=1,aformat=sample_fmts=s16p:sample_rates=44100:channel_layouts=mono"},
+		{name: "downmix ignored for stereo", stereo: true, downmix: DownmixLeft, want: "aresample=44100:async=1,aformat=sample_fmts=s16p:sample_rates=44100:channel_layouts=stereo"},
+		{name: "limit", limit: 0.8913, want: "aresample=44100:async=1,astats=metadata=1:measure_perchannel=none:measure_overall=Peak_level,alimiter=limit=0.8913:level=0:latency=1,aformat=sample_fmts=s16p:sample_rates=44100:channel_layouts=mono"},
+		{name: "limit after downmix and dither", dither: true, downmix: DownmixRight, limit: 0.5, want: "pan=mono|c0=c1,aresample=44100:async=1:dither_method=triangular,astats=metadata=1:measure_perchannel=none:measure_overall=Peak_level,alimiter=limit=0.5000:level=0:latency=1,aformat=sample_fmts=s16p:sample_rates=44100:channel_layouts=mono"},
+	}
 
-		t.Logf("Created MP3: %s (%d bytes)", outputPath, info.Size())
-	})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := buildFilterSpec(44100, "s16p", tt.stereo, tt.dither, tt.downmix, tt.limit); got != tt.want {
+				t.Errorf("buildFilterSpec() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
 
-	t.Run("stereo encoding", func(t *testing.T) {
-		stereoOutput := "../../testdata/LMP0-stereo.mp3"
-		defer os.Remove(stereoOutput)
+func TestEncoderLimited(t *testing.T) {
+	tests := []struct {
+		name  string
+		limit bool
+		peak  float64
+		want  bool
+	}{
+		{name: "limiter off", peak: 0, want: false},
+		{name: "peak above ceiling", limit: true, peak: -0.2, want: true},
+		{name: "peak at ceiling", limit: true, peak: -1, want: false},
+		{name: "silence", limit: true, peak: math.Inf(-1), want: false},
+	}
 
-		enc, err := New(Config{
-			InputPath:  inputPath,
-			OutputPath: stereoOutput,
-			Stereo:     true,
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &Encoder{limit: tt.limit, limitCeiling: DefaultLimitCeiling, peakLevel: tt.peak}
+			if got := e.limited(); got != tt.want {
+				t.Errorf("limited() = %v, want %v", got, tt.want)
+			}
 		})
-		if err != nil {
-			t.Fatalf("Failed to create encoder: %v", err)
-		}
-		defer enc.Close()
+	}
+}
 
-		if err := enc.Initialize(); err != nil {
-			t.Fatalf("Failed to initialize encoder: %v", err)
-		}
+// TestEncode24BitWAV_Integration encodes a generated 24-bit WAV, with and
+// without dither, and checks each output is a valid MP3 of the same length.
+func TestEncode24BitWAV_Integration(t *testing.T) {
+	if _, err := exec.LookPath("ffprobe"); err != nil {
+		t.Skip("ffprobe not available")
+	}
 
-		err = enc.Encode(nil)
-		if err != nil {
-			t.Fatalf("Encode (stereo) failed: %v", err)
-		}
+	inputPath := filepath.Join(t.TempDir(), "tone-24bit.wav")
+	writeTestWAV24(t, inputPath, 44100, 2)
 
-		info, err := os.Stat(stereoOutput)
-		if err != nil {
-			t.Fatalf("Stereo output file not created: %v", err)
-		}
+	for _, dither := range []bool{false, true} {
+		t.Run("dither="+strconv.FormatBool(dither), func(t *testing.T) {
+			outputPath := filepath.Join(t.TempDir(), "tone.mp3")
+			enc, err := New(Config{InputPath: inputPath, OutputPath: outputPath, Dither: dither})
+			if err != nil {
+				t.Fatalf("Failed to create encoder: %v", err)
+			}
+			defer enc.Close()
 
-		// Stereo file should be larger than mono (approximately 192/112 ratio)
-		if info.Size() < 1024 {
-			t.Errorf("Stereo output file too small: %d bytes", info.Size())
-		}
+			if err := enc.Initialize(); err != nil {
+				t.Fatalf("Failed to initialize encoder: %v", err)
+			}
+			if _, err := enc.Encode(nil); err != nil {
+				t.Fatalf("Encode failed: %v", err)
+			}
+
+			streams := probeStreams(t, outputPath)
+			if len(streams) != 1 || streams[0].CodecType != "audio" {
+				t.Fatalf("output streams = %+v, want a single audio stream", streams)
+			}
+			if got := probeDurationSecs(t, outputPath); got < 1.9 || got > 2.1 {
+				t.Errorf("output duration = %.2fs, want about 2s", got)
+			}
+		})
+	}
+}
+
+// TestEncodePreview_Integration verifies --preview ends the encode at the
+// preview length, reports it as the progress total, and drops chapters after
+// it.
+func TestEncodePreview_Integration(t *testing.T) {
+	inputPath := filepath.Join(t.TempDir(), "tone.wav")
+	writeTestWAV24(t, inputPath, 44100, 4)
 
-		t.Logf("Created stereo MP3: %s (%d bytes)", stereoOutput, info.Size())
+	enc, err := New(Config{
+		InputPath:  inputPath,
+		OutputPath: filepath.Join(t.TempDir(), "tone.mp3"),
+		Preview:    1500 * time.Millisecond,
+		Chapters:   []Chapter{{Start: 0, Title: "Intro"}, {Start: 3 * time.Second, Title: "Later"}},
 	})
+	if err != nil {
+		t.Fatalf("Failed to create encoder: %v", err)
+	}
+	defer enc.Close()
+
+	if err := enc.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize encoder: %v", err)
+	}
+	var lastDone, lastTotal int64
+	result, err := enc.Encode(func(done, total int64) { lastDone, lastTotal = done, total })
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+
+	if lastTotal != 66150 || lastDone != lastTotal {
+		t.Errorf("last progress = %d/%d, want 66150/66150", lastDone, lastTotal)
+	}
+	// One MP3 frame of slack for the encoder's padding.
+	if got := result.AudioDuration; got < 1500*time.Millisecond-30*time.Millisecond || got > 1500*time.Millisecond+30*time.Millisecond {
+		t.Errorf("encoded duration = %s, want 1.5s", got)
+	}
+	if len(enc.chapters) != 1 {
+		t.Errorf("got %d chapters, want only the one inside the preview", len(enc.chapters))
+	}
+}
+
+func TestCheckInputLength(t *testing.T) {
+	tests := []struct {
+		name       string
+		samples    int64
+		sampleRate int
+		errMatch   string
+	}{
+		{name: "empty", samples: 0, sampleRate: 44100, errMatch: "input contains no audio: the input is empty"},
+		{name: "too short", samples: 4410, sampleRate: 44100, errMatch: "only 100ms decoded, under the 1s minimum"},
+		{name: "exactly the minimum", samples: 48000, sampleRate: 48000},
+		{name: "episode length", samples: 44100 * 3600, sampleRate: 44100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkInputLength(tt.samples, tt.sampleRate)
+			if tt.errMatch == "" {
+				if err != nil {
+					t.Errorf("checkInputLength() unexpected error: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrNoAudio) || !strings.Contains(err.Error(), tt.errMatch) {
+				t.Errorf("checkInputLength() error = %v, want ErrNoAudio containing %q", err, tt.errMatch)
+			}
+		})
+	}
+}
+
+// TestEncodeEmptyInput_Integration checks that a WAV with no samples fails
+// with ErrNoAudio instead of producing an empty MP3.
+func TestEncodeEmptyInput_Integration(t *testing.T) {
+	inputPath := filepath.Join(t.TempDir(), "empty.wav")
+	writeTestWAV24(t, inputPath, 44100, 0)
+
+	enc, err := New(Config{InputPath: inputPath, OutputPath: filepath.Join(t.TempDir(), "empty.mp3")})
+	if err != nil {
+		t.Fatalf("Failed to create encoder: %v", err)
+	}
+	defer enc.Close()
+
+	if err := enc.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize encoder: %v", err)
+	}
+	if _, err := enc.Encode(nil); !errors.Is(err, ErrNoAudio) {
+		t.Errorf("Encode() error = %v, want ErrNoAudio", err)
+	}
+}
+
+// writeTestWAV24 writes secs seconds of a full-scale 24-bit mono 440 Hz sine
+// wave, the kind of high bit depth master the dither option is for.
+func writeTestWAV24(t testing.TB, path string, sampleRate, secs int) {
+	t.Helper()
+
+	const bytesPerSample = 3
+	n := sampleRate * secs
+	data := make([]byte, 0, n*bytesPerSample)
+	for i := range n {
+		v := int32(math.Round(math.Sin(2*math.Pi*440*float64(i)/float64(sampleRate)) * (1<<23 - 1)))
+		data = append(data, byte(v), byte(v>>8), byte(v>>16))
+	}
+
+	var b bytes.Buffer
+	b.WriteString("RIFF")
+	binary.Write(&b, binary.LittleEndian, uint32(36+len(data)))
+	b.WriteString("WAVEfmt ")
+	binary.Write(&b, binary.LittleEndian, uint32(16))                        // fmt chunk size
+	binary.Write(&b, binary.LittleEndian, uint16(1))                         // PCM
+	binary.Write(&b, binary.LittleEndian, uint16(1))                         // mono
+	binary.Write(&b, binary.LittleEndian, uint32(sampleRate))                // sample rate
+	binary.Write(&b, binary.LittleEndian, uint32(sampleRate*bytesPerSample)) // byte rate
+	binary.Write(&b, binary.LittleEndian, uint16(bytesPerSample))            // block align
+	binary.Write(&b, binary.LittleEndian, uint16(8*bytesPerSample))          // bits per sample
+	b.WriteString("data")
+	binary.Write(&b, binary.LittleEndian, uint32(len(data)))
+	b.Write(data)
+
+	if err := os.WriteFile(path, b.Bytes(), 0o644); err != nil {
+		t.Fatalf("Failed to write WAV fixture: %v", err)
+	}
+}
+
+// writeTestAIFF16 writes a mono 16-bit AIFF file holding secs seconds of a
+// 440Hz tone at sampleRate.
+func writeTestAIFF16(t testing.TB, path string, sampleRate, secs int) {
+	t.Helper()
+
+	n := sampleRate * secs
+	data := make([]byte, 0, n*2)
+	for i := range n {
+		v := int16(math.Round(math.Sin(2*math.Pi*440*float64(i)/float64(sampleRate)) * (1<<15 - 1)))
+		data = binary.BigEndian.AppendUint16(data, uint16(v))
+	}
+
+	// The COMM chunk gives the sample rate as an 80-bit extended float:
+	// a biased exponent, then a 64-bit mantissa with an explicit leading 1.
+	exp := 0
+	for sampleRate>>(exp+1) > 0 {
+		exp++
+	}
+	rate := binary.BigEndian.AppendUint16(nil, uint16(16383+exp))
+	rate = binary.BigEndian.AppendUint64(rate, uint64(sampleRate)<<(63-exp))
+
+	var b bytes.Buffer
+	b.WriteString("FORM")
+	binary.Write(&b, binary.BigEndian, uint32(4+8+18+8+8+len(data)))
+	b.WriteString("AIFFCOMM")
+	binary.Write(&b, binary.BigEndian, uint32(18)) // COMM chunk size
+	binary.Write(&b, binary.BigEndian, uint16(1))  // mono
+	binary.Write(&b, binary.BigEndian, uint32(n))  // sample frames
+	binary.Write(&b, binary.BigEndian, uint16(16)) // bits per sample
+	b.Write(rate)
+	b.WriteString("SSND")
+	binary.Write(&b, binary.BigEndian, uint32(8+len(data)))
+	binary.Write(&b, binary.BigEndian, uint64(0)) // offset and block size
+	b.Write(data)
+
+	if err := os.WriteFile(path, b.Bytes(), 0o644); err != nil {
+		t.Fatalf("Failed to write AIFF fixture: %v", err)
+	}
+}
+
+// TestEncodeOtherInputs_Integration verifies AIFF and M4A sources decode
+// through the same pipeline as WAV and FLAC. The M4A source is itself an AAC
+// encode of a WAV tone.
+func TestEncodeOtherInputs_Integration(t *testing.T) {
+	dir := t.TempDir()
+
+	aiffPath := filepath.Join(dir, "tone.aiff")
+	writeTestAIFF16(t, aiffPath, 44100, 2)
+
+	wavPath := filepath.Join(dir, "tone.wav")
+	writeTestWAV24(t, wavPath, 48000, 2)
+	var m4a bytes.Buffer
+	if _, err := EncodeTo(&m4a, Config{InputPath: wavPath, Format: "aac"}, nil); err != nil {
+		t.Fatalf("Failed to encode the M4A source: %v", err)
+	}
+	m4aPath := filepath.Join(dir, "tone.m4a")
+	if err := os.WriteFile(m4aPath, m4a.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, inputPath := range []string{aiffPath, m4aPath} {
+		t.Run(filepath.Ext(inputPath), func(t *testing.T) {
+			var out bytes.Buffer
+			result, err := EncodeTo(&out, Config{InputPath: inputPath}, nil)
+			if err != nil {
+				t.Fatalf("EncodeTo failed: %v", err)
+			}
+			// AAC's priming and padding make its length a little less exact.
+			if got := result.AudioDuration; got < 2*time.Second-100*time.Millisecond || got > 2*time.Second+100*time.Millisecond {
+				t.Errorf("encoded duration = %s, want about 2s", got)
+			}
+		})
+	}
+}
+
+// TestEncodeUnsupportedInput_Integration verifies that input FFmpeg cannot
+// decode fails with ErrUnsupportedInput and names the supported formats.
+func TestEncodeUnsupportedInput_Integration(t *testing.T) {
+	inputPath := filepath.Join(t.TempDir(), "episode.wav")
+	if err := os.WriteFile(inputPath, []byte("these are show notes, not audio\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	enc, err := New(Config{InputPath: inputPath, OutputPath: filepath.Join(t.TempDir(), "episode.mp3")})
+	if err != nil {
+		t.Fatalf("Failed to create encoder: %v", err)
+	}
+	defer enc.Close()
+
+	err = enc.Initialize()
+	if !errors.Is(err, ErrUnsupportedInput) || !strings.Contains(err.Error(), "AIFF and M4A/MP4") {
+		t.Errorf("Initialize() error = %v, want ErrUnsupportedInput naming the supported formats", err)
+	}
 }
 
 // TestEncodeToM4A_Integration is an integration test that verifies the full
@@ -152,7 +567,7 @@ func TestEncodeToM4A_Integration(t *testing.T) {
 			t.Fatalf("Failed to initialize encoder: %v", err)
 		}
 
-		err = enc.Encode(nil)
+		_, err = enc.Encode(nil)
 		if err != nil {
 			t.Fatalf("Encode failed: %v", err)
 		}
@@ -189,7 +604,7 @@ func TestEncodeToM4A_Integration(t *testing.T) {
 			t.Fatalf("Failed to initialize encoder: %v", err)
 		}
 
-		err = enc.Encode(nil)
+		_, err = enc.Encode(nil)
 		if err != nil {
 			t.Fatalf("Encode (stereo) failed: %v", err)
 		}
@@ -236,7 +651,7 @@ func TestEncodeToOpus_Integration(t *testing.T) {
 			t.Fatalf("Failed to initialize encoder: %v", err)
 		}
 
-		err = enc.Encode(nil)
+		_, err = enc.Encode(nil)
 		if err != nil {
 			t.Fatalf("Encode failed: %v", err)
 		}
@@ -273,7 +688,7 @@ func TestEncodeToOpus_Integration(t *testing.T) {
 			t.Fatalf("Failed to initialize encoder: %v", err)
 		}
 
-		err = enc.Encode(nil)
+		_, err = enc.Encode(nil)
 		if err != nil {
 			t.Fatalf("Encode (stereo) failed: %v", err)
 		}
@@ -288,16 +703,150 @@ func TestEncodeToOpus_Integration(t *testing.T) {
 			t.Errorf("Stereo output file too small: %d bytes", info.Size())
 		}
 
-		t.Logf("Created stereo Opus: %s (%d bytes)", stereoOutput, info.Size())
-	})
+		t.Logf("Created stereo Opus: %s (%d bytes)", stereoOutput, info.Size())
+	})
+}
+
+// TestEncodeMP3Metadata_Integration encodes an MP3 with populated Metadata and
+// asserts the muxer-native tags survive into the ID3v2 frames, probed via
+// ffprobe. This proves the AVDictionary path independent of any other tagging.
+// TDRC (date) and COMM (comment) are the highest-risk frames, so both are
+// asserted explicitly alongside title/artist/album/track.
+func TestEncodeMP3Metadata_Integration(t *testing.T) {
+	inputPath := "../../testdata/LMP0.flac"
+	if _, err := os.Stat(inputPath); os.IsNotExist(err) {
+		t.Skipf("Test file not found: %s", inputPath)
+	}
+	if _, err := exec.LookPath("ffprobe"); err != nil {
+		t.Skip("ffprobe not available")
+	}
+
+	tmpDir := t.TempDir()
+	outputPath := filepath.Join(tmpDir, "meta.mp3")
+
+	enc, err := New(Config{
+		InputPath:  inputPath,
+		OutputPath: outputPath,
+		Stereo:     false,
+		Metadata: Metadata{
+			EpisodeNumber: "67",
+			Title:         "Panache, for men",
+			Artist:        "Linux Matters",
+			Album:         "Linux Matters Podcast",
+			AlbumArtist:   "Linux Matters",
+			Date:          "2025-10",
+			Comments:      []string{"A test comment"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create encoder: %v", err)
+	}
+	defer enc.Close()
+
+	if err := enc.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize encoder: %v", err)
+	}
+	if _, err := enc.Encode(nil); err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+
+	tags := probeFormatTags(t, outputPath)
+
+	// ffprobe reports the TPE2 frame as album_artist.
+	want := map[string]string{
+		"title":        "67: Panache, for men",
+		"artist":       "Linux Matters",
+		"album_artist": "Linux Matters",
+		"album":        "Linux Matters Podcast",
+		"date":         "2025-10",
+		"comment":      "A test comment",
+		"track":        "67",
+	}
+	for key, value := range want {
+		got, ok := tags[key]
+		if !ok {
+			t.Errorf("missing %s tag in ffprobe output", key)
+			continue
+		}
+		if got != value {
+			t.Errorf("%s tag: got %q, want %q", key, got, value)
+		}
+	}
+}
+
+// TestEncodeMP3Comments_Integration encodes several comments and reads the
+// frames back: each must be a COMM frame carrying its description, not the
+// TXXX frame FFmpeg's muxer writes for a comment key.
+func TestEncodeMP3Comments_Integration(t *testing.T) {
+	inputPath := "../../testdata/LMP0.flac"
+	if _, err := os.Stat(inputPath); os.IsNotExist(err) {
+		t.Skipf("Test file not found: %s", inputPath)
+	}
+
+	outputPath := filepath.Join(t.TempDir(), "comments.mp3")
+	enc, err := New(Config{
+		InputPath:  inputPath,
+		OutputPath: outputPath,
+		Metadata: Metadata{
+			EpisodeNumber: "67",
+			Title:         "Panache, for men",
+			Comments:      []string{"https://linuxmatters.sh/67", "Show notes", "More notes"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create encoder: %v", err)
+	}
+	defer enc.Close()
+
+	if err := enc.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize encoder: %v", err)
+	}
+	if _, err := enc.Encode(nil); err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+
+	data, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	if len(data) < 10 || string(data[:3]) != "ID3" {
+		t.Fatal("output does not start with an ID3v2 header")
+	}
+	size := synchsafe(data[6:10])
+	if 10+size > len(data) {
+		t.Fatalf("tag size %d overruns the file", size)
+	}
+
+	// description -> text of each COMM frame
+	got := make(map[string]string)
+	for _, f := range readFrames(t, data[10:10+size], data[3]) {
+		switch f.id {
+		case "TXXX":
+			t.Errorf("TXXX frame left in the tag: %q", f.body)
+		case "COMM":
+			if len(f.body) < 4 || string(f.body[1:4]) != DefaultCommentLanguage {
+				t.Errorf("COMM frame without language %s: %q", DefaultCommentLanguage, f.body)
+				continue
+			}
+			description, text, ok := splitID3Text(f.body[4:], f.body[0])
+			if !ok {
+				t.Errorf("COMM frame without a description: %q", f.body)
+				continue
+			}
+			got[decodeID3Text(description, f.body[0])] = strings.TrimRight(decodeID3Text(text, f.body[0]), "\x00")
+		}
+	}
+
+	want := map[string]string{"": "https://linuxmatters.sh/67", "Notes": "Show notes", "Notes 2": "More notes"}
+	if !maps.Equal(got, want) {
+		t.Errorf("COMM frames = %q, want %q", got, want)
+	}
 }
 
-// TestEncodeMP3Metadata_Integration encodes an MP3 with populated Metadata and
-// asserts the muxer-native tags survive into the ID3v2 frames, probed via
-// ffprobe. This proves the AVDictionary path independent of any other tagging.
-// TDRC (date) and COMM (comment) are the highest-risk frames, so both are
-// asserted explicitly alongside title/artist/album/track.
-func TestEncodeMP3Metadata_Integration(t *testing.T) {
+// TestEncodeMP3ID3v23_Integration encodes with ID3Version 3 and reads the tag
+// back: the header must declare v2.3, and the full date must survive the
+// muxer's TDRC -> TYER/TDAT split.
+func TestEncodeMP3ID3v23_Integration(t *testing.T) {
 	inputPath := "../../testdata/LMP0.flac"
 	if _, err := os.Stat(inputPath); os.IsNotExist(err) {
 		t.Skipf("Test file not found: %s", inputPath)
@@ -306,20 +855,16 @@ func TestEncodeMP3Metadata_Integration(t *testing.T) {
 		t.Skip("ffprobe not available")
 	}
 
-	tmpDir := t.TempDir()
-	outputPath := filepath.Join(tmpDir, "meta.mp3")
+	outputPath := filepath.Join(t.TempDir(), "v23.mp3")
 
 	enc, err := New(Config{
 		InputPath:  inputPath,
 		OutputPath: outputPath,
-		Stereo:     false,
+		ID3Version: 3,
 		Metadata: Metadata{
 			EpisodeNumber: "67",
 			Title:         "Panache, for men",
-			Artist:        "Linux Matters",
-			Album:         "Linux Matters Podcast",
-			Date:          "2025-10",
-			Comment:       "A test comment",
+			Date:          "2025-10-14",
 		},
 	})
 	if err != nil {
@@ -330,30 +875,243 @@ func TestEncodeMP3Metadata_Integration(t *testing.T) {
 	if err := enc.Initialize(); err != nil {
 		t.Fatalf("Failed to initialize encoder: %v", err)
 	}
-	if err := enc.Encode(nil); err != nil {
+	if _, err := enc.Encode(nil); err != nil {
 		t.Fatalf("Encode failed: %v", err)
 	}
 
+	// The ID3v2 header is "ID3" followed by the major version byte.
+	data, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	if len(data) < 4 || string(data[:3]) != "ID3" {
+		t.Fatal("output does not start with an ID3v2 header")
+	}
+	if data[3] != 3 {
+		t.Errorf("ID3v2 major version: got %d, want 3", data[3])
+	}
+
 	tags := probeFormatTags(t, outputPath)
+	if got := tags["title"]; got != "67: Panache, for men" {
+		t.Errorf("title tag: got %q, want %q", got, "67: Panache, for men")
+	}
+	if got := tags["date"]; got != "2025-10-14" {
+		t.Errorf("date tag: got %q, want %q", got, "2025-10-14")
+	}
+}
 
-	want := map[string]string{
-		"title":   "67: Panache, for men",
-		"artist":  "Linux Matters",
-		"album":   "Linux Matters Podcast",
-		"date":    "2025-10",
-		"comment": "A test comment",
-		"track":   "67",
+func TestMP3MuxerOptions(t *testing.T) {
+	tests := []struct {
+		name       string
+		id3Version int
+		gapless    bool
+		wantID3    string
+		wantXing   string
+	}{
+		{name: "gapless v2.4", id3Version: 4, gapless: true, wantID3: "4", wantXing: "1"},
+		{name: "no gapless v2.3", id3Version: 3, gapless: false, wantID3: "3", wantXing: "0"},
 	}
-	for key, value := range want {
-		got, ok := tags[key]
-		if !ok {
-			t.Errorf("missing %s tag in ffprobe output", key)
-			continue
-		}
-		if got != value {
-			t.Errorf("%s tag: got %q, want %q", key, got, value)
-		}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := mp3MuxerOptions(tt.id3Version, tt.gapless)
+			if got := opts["id3v2_version"]; got != tt.wantID3 {
+				t.Errorf("id3v2_version = %q, want %q", got, tt.wantID3)
+			}
+			if got := opts["write_xing"]; got != tt.wantXing {
+				t.Errorf("write_xing = %q, want %q", got, tt.wantXing)
+			}
+		})
+	}
+}
+
+// TestEncodeMP3Gapless_Integration checks that MP3 output starts with a valid
+// Xing/LAME info frame whose frame count, encoder delay and padding account
+// for the encoded samples, and that NoGapless leaves the frame out.
+func TestEncodeMP3Gapless_Integration(t *testing.T) {
+	inputPath := "../../testdata/LMP0.flac"
+	if _, err := os.Stat(inputPath); os.IsNotExist(err) {
+		t.Skipf("Test file not found: %s", inputPath)
+	}
+
+	tests := []struct {
+		name      string
+		noGapless bool
+	}{
+		{name: "gapless", noGapless: false},
+		{name: "no gapless", noGapless: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputPath := filepath.Join(t.TempDir(), "gapless.mp3")
+			enc, err := New(Config{InputPath: inputPath, OutputPath: outputPath, NoGapless: tt.noGapless})
+			if err != nil {
+				t.Fatalf("Failed to create encoder: %v", err)
+			}
+			defer enc.Close()
+
+			if err := enc.Initialize(); err != nil {
+				t.Fatalf("Failed to initialize encoder: %v", err)
+			}
+			result, err := enc.Encode(nil)
+			if err != nil {
+				t.Fatalf("Encode failed: %v", err)
+			}
+
+			data, err := os.ReadFile(outputPath)
+			if err != nil {
+				t.Fatalf("Failed to read output: %v", err)
+			}
+			xing, ok := readXingHeader(t, data)
+			if tt.noGapless {
+				if ok {
+					t.Error("output has a Xing/LAME header with NoGapless set")
+				}
+				return
+			}
+			if !ok {
+				t.Fatal("output has no Xing/LAME header")
+			}
+
+			if xing.delay <= 0 || xing.delay > 4095 || xing.padding < 0 || xing.padding > 4095 {
+				t.Errorf("encoder delay %d and padding %d out of range", xing.delay, xing.padding)
+			}
+			// MPEG-1 Layer III frames hold 1152 samples; trimming the delay and
+			// padding must leave the samples that were encoded.
+			samples := int64(xing.frames)*1152 - int64(xing.delay) - int64(xing.padding)
+			encoded := int64(math.Round(result.AudioDuration.Seconds() * 44100))
+			if diff := samples - encoded; diff < -1152 || diff > 1152 {
+				t.Errorf("Xing header accounts for %d samples, encoded %d", samples, encoded)
+			}
+		})
+	}
+}
+
+// TestEncodeMP3InfoHeaderDuration_Integration checks that CBR MP3 output
+// carries an Info frame, and that reading the file back gives a duration
+// within one frame of what the encoder reports, so players need not estimate
+// it from the bitrate.
+func TestEncodeMP3InfoHeaderDuration_Integration(t *testing.T) {
+	inputPath := "../../testdata/LMP0.flac"
+	if _, err := os.Stat(inputPath); os.IsNotExist(err) {
+		t.Skipf("Test file not found: %s", inputPath)
+	}
+
+	// One MPEG-1 Layer III frame at 44.1kHz.
+	const frame = 1152.0 / 44100
+
+	for _, stereo := range []bool{false, true} {
+		t.Run(fmt.Sprintf("stereo=%v", stereo), func(t *testing.T) {
+			outputPath := filepath.Join(t.TempDir(), "info.mp3")
+			enc, err := New(Config{InputPath: inputPath, OutputPath: outputPath, Stereo: stereo})
+			if err != nil {
+				t.Fatalf("Failed to create encoder: %v", err)
+			}
+			defer enc.Close()
+
+			if err := enc.Initialize(); err != nil {
+				t.Fatalf("Failed to initialize encoder: %v", err)
+			}
+			result, err := enc.Encode(nil)
+			if err != nil {
+				t.Fatalf("Encode failed: %v", err)
+			}
+
+			data, err := os.ReadFile(outputPath)
+			if err != nil {
+				t.Fatalf("Failed to read output: %v", err)
+			}
+			if xing, ok := readXingHeader(t, data); !ok || xing.tag != "Info" {
+				t.Fatalf("CBR output has no Info frame (found %q)", xing.tag)
+			}
+
+			info, err := ProbeInput(outputPath, 0)
+			if err != nil {
+				t.Fatalf("ProbeInput failed: %v", err)
+			}
+			probed := info.Duration.Seconds()
+			if diff := math.Abs(probed - result.AudioDuration.Seconds()); diff > frame {
+				t.Errorf("read-back duration %.3fs, encoded %.3fs", probed, result.AudioDuration.Seconds())
+			}
+			// GetDurationSecs rounds to the second.
+			if diff := math.Abs(probed - float64(enc.GetDurationSecs())); diff > 0.5+frame {
+				t.Errorf("read-back duration %.3fs, GetDurationSecs %d", probed, enc.GetDurationSecs())
+			}
+		})
+	}
+}
+
+// xingHeader is the gapless information in an MP3 file's Xing/LAME info frame.
+type xingHeader struct {
+	tag     string // "Info" for CBR, "Xing" for VBR
+	frames  uint32
+	delay   int
+	padding int
+}
+
+// readXingHeader finds the Xing or Info tag in the first MPEG audio frame after
+// the ID3v2 tag and reads its frame count and the LAME extension's encoder
+// delay and padding. It reports false when the first frame has no such tag.
+func readXingHeader(t *testing.T, data []byte) (xingHeader, bool) {
+	t.Helper()
+
+	offset := 0
+	if len(data) >= 10 && string(data[:3]) == "ID3" {
+		// The tag size is four 7-bit bytes and excludes the 10-byte header.
+		size := int(data[6])<<21 | int(data[7])<<14 | int(data[8])<<7 | int(data[9])
+		offset = 10 + size
+	}
+	if offset+4 > len(data) || data[offset] != 0xff || data[offset+1]&0xe0 != 0xe0 {
+		t.Fatalf("no MPEG frame sync at offset %d", offset)
+	}
+
+	// The tag follows the side information, whose length depends on the MPEG
+	// version and whether the frame is mono.
+	mpeg1 := data[offset+1]>>3&0x03 == 0x03
+	mono := data[offset+3]>>6 == 0x03
+	sideInfo := 32
+	switch {
+	case mpeg1 && mono:
+		sideInfo = 17
+	case !mpeg1 && mono:
+		sideInfo = 9
+	case !mpeg1:
+		sideInfo = 17
+	}
+	pos := offset + 4 + sideInfo
+	if pos+8 > len(data) {
+		return xingHeader{}, false
+	}
+	header := xingHeader{tag: string(data[pos : pos+4])}
+	if header.tag != "Xing" && header.tag != "Info" {
+		return xingHeader{}, false
+	}
+
+	flags := binary.BigEndian.Uint32(data[pos+4:])
+	pos += 8
+	if flags&0x01 != 0 {
+		header.frames = binary.BigEndian.Uint32(data[pos:])
+		pos += 4
+	}
+	if flags&0x02 != 0 {
+		pos += 4 // byte count
+	}
+	if flags&0x04 != 0 {
+		pos += 100 // seek table
+	}
+	if flags&0x08 != 0 {
+		pos += 4 // quality
+	}
+
+	// The LAME extension puts the 12-bit delay and padding 21 bytes in.
+	if pos+24 > len(data) {
+		t.Fatal("Xing header is truncated before the LAME extension")
 	}
+	gapless := data[pos+21:]
+	header.delay = int(gapless[0])<<4 | int(gapless[1])>>4
+	header.padding = int(gapless[1]&0x0f)<<8 | int(gapless[2])
+	return header, true
 }
 
 // probeFormatTags runs ffprobe and returns the format-level tag map.
@@ -379,9 +1137,9 @@ func probeFormatTags(t *testing.T, path string) map[string]string {
 }
 
 // TestEncodeCoverArt_Integration encodes with scaled cover bytes and asserts
-// the attached-picture stream behaviour per format: MP3 and AAC are
-// cover-capable and must carry an attached-picture video stream, while Opus is
-// not cover-capable and must stay audio-only. The cover bytes come from
+// the attached-picture stream behaviour per format: MP3 and AAC carry an
+// attached-picture video stream, and Opus carries a METADATA_BLOCK_PICTURE
+// comment that ffprobe surfaces as an attached picture. The cover bytes come from
 // id3.ScaleCoverArt on a real testdata PNG fixture.
 func TestEncodeCoverArt_Integration(t *testing.T) {
 	inputPath := "../../testdata/LMP0.flac"
@@ -412,7 +1170,7 @@ func TestEncodeCoverArt_Integration(t *testing.T) {
 	}{
 		{name: "mp3 carries attached picture", format: "mp3", ext: "mp3", wantCover: true},
 		{name: "aac carries attached picture", format: "aac", ext: "m4a", wantCover: true},
-		{name: "opus has no attached picture", format: "opus", ext: "opus", wantCover: false},
+		{name: "opus carries picture block", format: "opus", ext: "opus", wantCover: true},
 	}
 
 	for _, tt := range tests {
@@ -434,7 +1192,7 @@ func TestEncodeCoverArt_Integration(t *testing.T) {
 			if err := enc.Initialize(); err != nil {
 				t.Fatalf("Failed to initialize encoder: %v", err)
 			}
-			if err := enc.Encode(nil); err != nil {
+			if _, err := enc.Encode(nil); err != nil {
 				t.Fatalf("Encode failed: %v", err)
 			}
 
@@ -462,11 +1220,70 @@ func TestEncodeCoverArt_Integration(t *testing.T) {
 	}
 }
 
+// TestEncodeBackCover_Integration encodes an MP3 with a front and a back cover
+// and asserts both land as attached pictures, in order, with their APIC
+// picture types, and that the front cover description round-trips.
+func TestEncodeBackCover_Integration(t *testing.T) {
+	inputPath := "../../testdata/LMP0.flac"
+	if _, err := os.Stat(inputPath); os.IsNotExist(err) {
+		t.Skipf("Test file not found: %s", inputPath)
+	}
+	if _, err := exec.LookPath("ffprobe"); err != nil {
+		t.Skip("ffprobe not available")
+	}
+
+	coverPath := "../../testdata/linuxmatters-3000x3000.png"
+	if _, err := os.Stat(coverPath); os.IsNotExist(err) {
+		t.Skipf("Cover fixture not found: %s", coverPath)
+	}
+	cover, err := id3.ScaleCoverArt(coverPath)
+	if err != nil {
+		t.Fatalf("ScaleCoverArt failed: %v", err)
+	}
+
+	outputPath := filepath.Join(t.TempDir(), "covers.mp3")
+	enc, err := New(Config{
+		InputPath:        inputPath,
+		OutputPath:       outputPath,
+		CoverArt:         cover,
+		Pictures:         []Picture{{Type: PictureBackCover, Data: cover}},
+		CoverDescription: "Episode 67 artwork",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create encoder: %v", err)
+	}
+	defer enc.Close()
+
+	if err := enc.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize encoder: %v", err)
+	}
+	if _, err := enc.Encode(nil); err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+
+	var comments, titles []string
+	for _, s := range probeStreams(t, outputPath) {
+		if s.CodecType == "video" && s.Disposition["attached_pic"] == 1 {
+			comments = append(comments, s.Tags["comment"])
+			titles = append(titles, s.Tags["title"])
+		}
+	}
+
+	want := []string{"Cover (front)", "Cover (back)"}
+	if !slices.Equal(comments, want) {
+		t.Errorf("attached picture types = %q, want %q", comments, want)
+	}
+	if want := []string{"Episode 67 artwork", ""}; !slices.Equal(titles, want) {
+		t.Errorf("attached picture descriptions = %q, want %q", titles, want)
+	}
+}
+
 // probeStream is a minimal ffprobe stream record covering the fields the cover
-// test asserts.
+// tests assert.
 type probeStream struct {
-	CodecType   string         `json:"codec_type"`
-	Disposition map[string]int `json:"disposition"`
+	CodecType   string            `json:"codec_type"`
+	Disposition map[string]int    `json:"disposition"`
+	Tags        map[string]string `json:"tags"`
 }
 
 // probeStreams runs ffprobe -show_streams and returns the decoded stream list.
@@ -567,7 +1384,7 @@ func TestEncoder_OutputExists(t *testing.T) {
 		t.Fatalf("Failed to initialize encoder: %v", err)
 	}
 
-	if err := enc1.Encode(nil); err != nil {
+	if _, err := enc1.Encode(nil); err != nil {
 		t.Fatalf("Initial encoding failed: %v", err)
 	}
 
@@ -591,7 +1408,7 @@ func TestEncoder_OutputExists(t *testing.T) {
 		t.Fatalf("Failed to initialize second encoder: %v", err)
 	}
 
-	if err := enc2.Encode(nil); err != nil {
+	if _, err := enc2.Encode(nil); err != nil {
 		t.Fatalf("Second encoding failed: %v", err)
 	}
 
@@ -735,7 +1552,7 @@ func TestEncoder_ProgressCallback(t *testing.T) {
 		}{samplesProcessed, totalSamples})
 	}
 
-	if err := enc.Encode(progressCb); err != nil {
+	if _, err := enc.Encode(progressCb); err != nil {
 		t.Fatalf("Encoding failed: %v", err)
 	}
 
@@ -828,7 +1645,7 @@ func TestEncoder_CancelMidStream(t *testing.T) {
 		}
 	}
 
-	err = enc.Encode(cb)
+	_, err = enc.Encode(cb)
 	if !errors.Is(err, ErrCancelled) {
 		t.Fatalf("Encode after Cancel: got %v, want ErrCancelled", err)
 	}
@@ -867,7 +1684,7 @@ func TestEncoder_CancelBeforeEncode(t *testing.T) {
 
 	enc.Cancel()
 
-	if err := enc.Encode(nil); !errors.Is(err, ErrCancelled) {
+	if _, err := enc.Encode(nil); !errors.Is(err, ErrCancelled) {
 		t.Fatalf("Encode after early Cancel: got %v, want ErrCancelled", err)
 	}
 
@@ -908,7 +1725,7 @@ func TestEncoder_GetDurationSecs(t *testing.T) {
 		t.Errorf("GetDurationSecs before Encode: got %d, want 0", dur)
 	}
 
-	if err := enc.Encode(nil); err != nil {
+	if _, err := enc.Encode(nil); err != nil {
 		t.Fatalf("Encoding failed: %v", err)
 	}
 
@@ -925,3 +1742,146 @@ func TestEncoder_GetDurationSecs(t *testing.T) {
 
 	t.Logf("Encoded duration: %d seconds", duration)
 }
+
+// TestEncoder_GetDurationSecsMatchesProbe encodes to MP3 (no resample) and
+// Opus (44.1kHz resampled to 48kHz) and asserts the encoder-reported duration
+// agrees with an independent ffprobe of the written file to within a second.
+func TestEncoder_GetDurationSecsMatchesProbe(t *testing.T) {
+	inputPath := "../../testdata/LMP0.flac"
+	if _, err := os.Stat(inputPath); os.IsNotExist(err) {
+		t.Skipf("Test file not found: %s", inputPath)
+	}
+	if _, err := exec.LookPath("ffprobe"); err != nil {
+		t.Skip("ffprobe not available")
+	}
+
+	for _, format := range []string{"mp3", "opus"} {
+		t.Run(format, func(t *testing.T) {
+			outputPath := filepath.Join(t.TempDir(), "test."+format)
+
+			enc, err := New(Config{
+				InputPath:  inputPath,
+				OutputPath: outputPath,
+				Format:     format,
+			})
+			if err != nil {
+				t.Fatalf("Failed to create encoder: %v", err)
+			}
+			if err := enc.Initialize(); err != nil {
+				enc.Close()
+				t.Fatalf("Failed to initialize encoder: %v", err)
+			}
+			if _, err := enc.Encode(nil); err != nil {
+				enc.Close()
+				t.Fatalf("Encoding failed: %v", err)
+			}
+			duration := enc.GetDurationSecs()
+			enc.Close()
+
+			probed := probeDurationSecs(t, outputPath)
+			if diff := float64(duration) - probed; diff < -1 || diff > 1 {
+				t.Errorf("GetDurationSecs: got %d, ffprobe reports %.3f", duration, probed)
+			}
+		})
+	}
+}
+
+// probeDurationSecs runs ffprobe and returns the container duration in seconds.
+func probeDurationSecs(t *testing.T, path string) float64 {
+	t.Helper()
+
+	cmd := exec.CommandContext(t.Context(), "ffprobe", "-show_format", "-of", "json", path)
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("ffprobe failed: %v", err)
+	}
+
+	var probe struct {
+		Format struct {
+			Duration string `json:"duration"`
+		} `json:"format"`
+	}
+	if err := json.Unmarshal(out, &probe); err != nil {
+		t.Fatalf("failed to parse ffprobe output: %v", err)
+	}
+
+	secs, err := strconv.ParseFloat(probe.Format.Duration, 64)
+	if err != nil {
+		t.Fatalf("failed to parse ffprobe duration %q: %v", probe.Format.Duration, err)
+	}
+	return secs
+}
+
+// TestEncoder_EncodeResult verifies that the result returned by Encode agrees
+// with an independent look at the written file: its path and size on disk and
+// the duration ffprobe reports.
+func TestEncoder_EncodeResult(t *testing.T) {
+	inputPath := "../../testdata/LMP0.flac"
+	if _, err := os.Stat(inputPath); os.IsNotExist(err) {
+		t.Skipf("Test file not found: %s", inputPath)
+	}
+	if _, err := exec.LookPath("ffprobe"); err != nil {
+		t.Skip("ffprobe not available")
+	}
+
+	outputPath := filepath.Join(t.TempDir(), "result.mp3")
+	enc, err := New(Config{InputPath: inputPath, OutputPath: outputPath})
+	if err != nil {
+		t.Fatalf("Failed to create encoder: %v", err)
+	}
+	if err := enc.Initialize(); err != nil {
+		enc.Close()
+		t.Fatalf("Failed to initialize encoder: %v", err)
+	}
+	result, err := enc.Encode(nil)
+	enc.Close()
+	if err != nil {
+		t.Fatalf("Encoding failed: %v", err)
+	}
+
+	if result.OutputPath != outputPath {
+		t.Errorf("OutputPath = %q, want %q", result.OutputPath, outputPath)
+	}
+
+	info, err := os.Stat(outputPath)
+	if err != nil {
+		t.Fatalf("failed to stat output: %v", err)
+	}
+	if result.BytesWritten != info.Size() {
+		t.Errorf("BytesWritten = %d, file size %d", result.BytesWritten, info.Size())
+	}
+
+	probed := probeDurationSecs(t, outputPath)
+	if diff := float64(result.DurationSecs) - probed; diff < -1 || diff > 1 {
+		t.Errorf("DurationSecs = %d, ffprobe reports %.3f", result.DurationSecs, probed)
+	}
+	if want := int64(probed * 44100); result.SamplesProcessed < want-44100 || result.SamplesProcessed > want+44100 {
+		t.Errorf("SamplesProcessed = %d, want about %d", result.SamplesProcessed, want)
+	}
+	if diff := result.AudioDuration.Seconds() - probed; diff < -1 || diff > 1 {
+		t.Errorf("AudioDuration = %v, ffprobe reports %.3f", result.AudioDuration, probed)
+	}
+	if result.Elapsed <= 0 || result.RealtimeFactor() <= 0 {
+		t.Errorf("Elapsed = %v, RealtimeFactor = %v; want both positive", result.Elapsed, result.RealtimeFactor())
+	}
+}
+
+func TestEncodeResultRealtimeFactor(t *testing.T) {
+	tests := []struct {
+		name   string
+		result EncodeResult
+		want   float64
+	}{
+		{name: "faster than realtime", result: EncodeResult{AudioDuration: time.Hour, Elapsed: 30 * time.Second}, want: 120},
+		{name: "slower than realtime", result: EncodeResult{AudioDuration: time.Second, Elapsed: 2 * time.Second}, want: 0.5},
+		{name: "no elapsed time", result: EncodeResult{AudioDuration: time.Minute}, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.result.RealtimeFactor(); got != tt.want {
+				t.Errorf("RealtimeFactor() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package encoder
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/linuxmatters/ffmpeg-statigo"
+)
+
+// ffmpegLog buffers FFmpeg log lines captured by the log callback so the
+// caller can print them outside the Bubbletea UI. FFmpeg emits a line in
+// several pieces, so partial text is held until its newline arrives.
+type ffmpegLog struct {
+	mu      sync.Mutex
+	pending strings.Builder
+	lines   []string
+}
+
+// add appends a log fragment, splitting completed lines off the pending text.
+func (l *ffmpegLog) add(msg string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.pending.WriteString(msg)
+	text := l.pending.String()
+	idx := strings.LastIndexByte(text, '\n')
+	if idx < 0 {
+		return
+	}
+
+	for line := range strings.SplitSeq(text[:idx], "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			l.lines = append(l.lines, line)
+		}
+	}
+	l.pending.Reset()
+	l.pending.WriteString(text[idx+1:])
+}
+
+// drain returns the completed lines and clears them.
+func (l *ffmpegLog) drain() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	lines := l.lines
+	l.lines = nil
+	return lines
+}
+
+// debugLog writes the raw FFmpeg trace to a file for bug reports. The log
+// callback may fire from any FFmpeg thread, so writes are serialised.
+type debugLog struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// openDebugLog rotates any previous log at path to path.1 and opens a fresh
+// file, so the trace from the run before is kept for comparison.
+func openDebugLog(path string) (*debugLog, error) {
+	if _, err := os.Stat(path); err == nil {
+		if err := os.Rename(path, path+".1"); err != nil {
+			return nil, fmt.Errorf("failed to rotate debug log: %w", err)
+		}
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create debug log: %w", err)
+	}
+	return &debugLog{file: file}, nil
+}
+
+// write appends msg verbatim; FFmpeg supplies its own newlines. Writes after
+// close are dropped, since the FFmpeg callback outlives the encoder.
+func (d *debugLog) write(msg string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.file == nil {
+		return
+	}
+	_, _ = d.file.WriteString(msg)
+}
+
+// printf appends a formatted jivedrop line, marked so it stands apart from
+// FFmpeg's own output.
+func (d *debugLog) printf(format string, args ...any) {
+	d.write("[jivedrop] " + fmt.Sprintf(format, args...) + "\n")
+}
+
+// close flushes and closes the file. It is safe to call more than once.
+func (d *debugLog) close() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.file != nil {
+		_ = d.file.Close()
+		d.file = nil
+	}
+}
+
+// configureLogging sets the FFmpeg log level for this encode. Quiet mode only
+// lets errors through to stderr. Verbose mode raises the level to info and
+// captures messages via a log callback instead of writing them to stderr.
+// Debug mode raises the level to debug and sends the full trace to the debug
+// log file, keeping stderr clean; verbose capture still applies alongside it.
+func (e *Encoder) configureLogging() error {
+	if !e.verbose && e.debugLogPath == "" {
+		// Keep stderr quiet: only surface FFmpeg errors, not its info/warning spam.
+		ffmpeg.AVLogSetLevel(ffmpeg.AVLogError)
+		return nil
+	}
+
+	if e.debugLogPath != "" {
+		debug, err := openDebugLog(e.debugLogPath)
+		if err != nil {
+			return err
+		}
+		e.debug = debug
+		e.writeDebugHeader()
+		ffmpeg.AVLogSetLevel(ffmpeg.AVLogDebug)
+	} else {
+		ffmpeg.AVLogSetLevel(ffmpeg.AVLogInfo)
+	}
+
+	ffmpeg.AVLogSetCallback(func(_ *ffmpeg.LogCtx, level int, msg string) {
+		if e.debug != nil {
+			e.debug.write(msg)
+		}
+		// A custom callback sees every message, so apply the level filter that
+		// FFmpeg's default callback would. The low byte carries the level.
+		if e.verbose && level&0xff <= ffmpeg.AVLogInfo {
+			e.log.add(msg)
+		}
+	})
+	return nil
+}
+
+// writeDebugHeader records the encode configuration at the top of the debug
+// log so a bug report carries the context needed to reproduce it.
+func (e *Encoder) writeDebugHeader() {
+	e.debug.printf("debug log started %s", time.Now().Format(time.RFC3339))
+	e.debug.printf("input: %s", e.inputPath)
+	e.debug.printf("output: %s", e.outputPath)
+	e.debug.printf("format: %s (muxer %s, %d Hz, %s %dkbps, vbr=%t)",
+		e.preset.name, e.preset.muxer, e.preset.sampleRate, e.ChannelMode(), e.Bitrate(), e.preset.vbr)
+	if e.preset.name == "mp3" {
+		e.debug.printf("id3v2 version: %d", e.id3Version)
+	}
+	if len(e.pictures) == 0 {
+		e.debug.printf("cover art: none")
+	}
+	for _, pic := range e.pictures {
+		e.debug.printf("picture: %s, %d bytes", pictureTypeNames[pic.Type], len(pic.Data))
+	}
+}
+
+// debugf writes a jivedrop line to the debug log when one is open.
+func (e *Encoder) debugf(format string, args ...any) {
+	if e.debug != nil {
+		e.debug.printf(format, args...)
+	}
+}
+
+// LogMessages returns the FFmpeg log lines captured since the last call. It is
+// always empty unless Config.Verbose is set.
+func (e *Encoder) LogMessages() []string {
+	return e.log.drain()
+}
@@ -0,0 +1,68 @@
+package encoder
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// TestFFmpegLogAdd verifies that fragments are joined into whole lines, blank
+// lines are dropped, and drain clears the buffer.
+func TestFFmpegLogAdd(t *testing.T) {
+	var l ffmpegLog
+
+	l.add("[mp3 @ 0x1] ")
+	if got := l.drain(); len(got) != 0 {
+		t.Fatalf("drain before newline: got %q, want none", got)
+	}
+
+	l.add("Estimating duration from bitrate\n\n")
+	l.add("clipping detected\npartial")
+
+	want := []string{"[mp3 @ 0x1] Estimating duration from bitrate", "clipping detected"}
+	if got := l.drain(); !slices.Equal(got, want) {
+		t.Errorf("drain: got %q, want %q", got, want)
+	}
+	if got := l.drain(); len(got) != 0 {
+		t.Errorf("second drain: got %q, want none", got)
+	}
+
+	l.add(" tail\n")
+	if got := l.drain(); !slices.Equal(got, []string{"partial tail"}) {
+		t.Errorf("drain after completing partial: got %q", got)
+	}
+}
+
+// TestOpenDebugLogRotates verifies that an existing debug log is kept as
+// path.1 and a fresh file is started, and that writes after close are dropped.
+func TestOpenDebugLogRotates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jivedrop-debug.log")
+	if err := os.WriteFile(path, []byte("previous run\n"), 0o644); err != nil {
+		t.Fatalf("failed to seed debug log: %v", err)
+	}
+
+	debug, err := openDebugLog(path)
+	if err != nil {
+		t.Fatalf("openDebugLog: %v", err)
+	}
+	debug.printf("filter spec: %s", "aresample=44100")
+	debug.close()
+	debug.write("after close\n")
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read debug log: %v", err)
+	}
+	if want := "[jivedrop] filter spec: aresample=44100\n"; string(got) != want {
+		t.Errorf("debug log: got %q, want %q", got, want)
+	}
+
+	rotated, err := os.ReadFile(path + ".1")
+	if err != nil {
+		t.Fatalf("failed to read rotated log: %v", err)
+	}
+	if string(rotated) != "previous run\n" {
+		t.Errorf("rotated log: got %q, want %q", rotated, "previous run\n")
+	}
+}
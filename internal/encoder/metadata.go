@@ -2,13 +2,17 @@ package encoder
 
 import (
 	"fmt"
+	"image"
 	"os"
 	"path/filepath"
 	"slices"
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
+	"github.com/linuxmatters/jivedrop/internal/textdiff"
 	"gopkg.in/yaml.v3"
 )
 
@@ -29,6 +33,71 @@ func ParseEpisodeNumber(s string) (string, error) {
 	return s, nil
 }
 
+// DefaultTitleFormat is the title tag template used when none is configured.
+const DefaultTitleFormat = "{num}: {title}"
+
+// ValidateTitleFormat checks a title tag template. It must contain the {title}
+// placeholder; {num} is optional, so "{title}" alone drops the number prefix.
+func ValidateTitleFormat(format string) error {
+	if !strings.Contains(format, "{title}") {
+		return fmt.Errorf("invalid title format %q: must contain {title}", format)
+	}
+	return nil
+}
+
+// RenderTitle expands the {num} and {title} placeholders in format, falling
+// back to DefaultTitleFormat when format is empty. A title that already starts
+// with the episode number, such as "67 - Foo", is returned as it is, so the
+// number is not written twice.
+func RenderTitle(format, num, title string) string {
+	if format == "" {
+		format = DefaultTitleFormat
+	}
+	if strings.Contains(format, "{num}") && titleHasNumber(title, num) {
+		return title
+	}
+	return strings.NewReplacer("{num}", num, "{title}", title).Replace(format)
+}
+
+// titleHasNumber reports whether title begins with the episode number num,
+// ignoring leading zeros, followed by the end of the title, a space or
+// punctuation. "67: Foo" and "067 - Foo" qualify; "670 Foo" and "67th Street"
+// do not.
+func titleHasNumber(title, num string) bool {
+	want, err := strconv.Atoi(num)
+	if err != nil {
+		return false
+	}
+
+	title = strings.TrimSpace(title)
+	end := strings.IndexFunc(title, func(r rune) bool { return r < '0' || r > '9' })
+	if end == -1 {
+		end = len(title)
+	}
+	if got, err := strconv.Atoi(title[:end]); err != nil || got != want {
+		return false
+	}
+	if end == len(title) {
+		return true
+	}
+	next, _ := utf8.DecodeRuneInString(title[end:])
+	return unicode.IsSpace(next) || unicode.IsPunct(next)
+}
+
+// trackNumber returns the track tag value for an episode number: a numeric
+// value loses its leading zeros, so "007" is written as "7" and players sort
+// and display it as a number. Anything that is not a non-negative integer is
+// returned unchanged. Filenames and the title keep the number as given.
+func trackNumber(num string) string {
+	if num == "" || strings.Trim(num, "0123456789") != "" {
+		return num
+	}
+	if trimmed := strings.TrimLeft(num, "0"); trimmed != "" {
+		return trimmed
+	}
+	return "0"
+}
+
 // muxerTag pairs a standard muxer metadata key with its value. Ordered pairs
 // keep tag emission deterministic across the title/artist/album/date/comment/track set.
 type muxerTag struct {
@@ -37,8 +106,11 @@ type muxerTag struct {
 }
 
 // buildMuxerTags renders the muxer metadata key/value set from the episode
-// fields, skipping empty values. The title preserves the "{EpisodeNumber}: {Title}"
-// format. The track key carries the episode number, matching the previous TRCK frame.
+// fields, skipping empty values. The title is rendered from TitleFormat, which
+// defaults to "{num}: {title}"; the filename and track key are unaffected.
+// The track key carries the episode number as a plain integer (see
+// trackNumber). Comments beyond the first are keyed by commentKey so none
+// collide.
 func buildMuxerTags(m Metadata) []muxerTag {
 	var tags []muxerTag
 
@@ -49,17 +121,63 @@ func buildMuxerTags(m Metadata) []muxerTag {
 	}
 
 	if m.Title != "" {
-		add("title", fmt.Sprintf("%s: %s", m.EpisodeNumber, m.Title))
+		add("title", RenderTitle(m.TitleFormat, m.EpisodeNumber, m.Title))
 	}
 	add("artist", m.Artist)
+	add("album_artist", m.AlbumArtist)
 	add("album", m.Album)
 	add("date", m.Date)
-	add("comment", m.Comment)
-	add("track", m.EpisodeNumber)
+	for i, comment := range m.Comments {
+		add(commentKey(i, m.Comments), comment)
+	}
+	add("track", trackNumber(m.EpisodeNumber))
+	if m.Explicit {
+		add(explicitTagKey, explicitTagValue)
+	}
 
 	return tags
 }
 
+// The iTunes advisory marker: "1" flags the episode explicit. Not-explicit is
+// the default, so nothing is written in that case. MP3 carries it as a TXXX
+// frame and Opus as a Vorbis comment; the ipod muxer has no mapping for it.
+const (
+	explicitTagKey   = "ITUNESADVISORY"
+	explicitTagValue = "1"
+)
+
+// commentKey returns the muxer key for comments[i]. The first comment keeps
+// the plain "comment" key, so a single comment is written exactly as before.
+// Each further comment needs a distinct key, otherwise it would replace the
+// first in the tag dictionary, so it is keyed by a description: "Link" for a
+// URL, "Notes" otherwise, numbered when a description repeats ("Notes 2").
+func commentKey(i int, comments []string) string {
+	if i == 0 {
+		return "comment"
+	}
+
+	description := commentDescription(comments[i])
+	n := 1
+	for _, earlier := range comments[1:i] {
+		if commentDescription(earlier) == description {
+			n++
+		}
+	}
+	if n > 1 {
+		description = fmt.Sprintf("%s %d", description, n)
+	}
+
+	return "comment-" + description
+}
+
+// commentDescription classifies a comment as a "Link" or free-text "Notes".
+func commentDescription(comment string) string {
+	if strings.HasPrefix(comment, "http://") || strings.HasPrefix(comment, "https://") {
+		return "Link"
+	}
+	return "Notes"
+}
+
 // EpisodeMetadata holds parsed episode information from Hugo frontmatter
 type EpisodeMetadata struct {
 	Episode         string    `yaml:"episode"`
@@ -68,6 +186,21 @@ type EpisodeMetadata struct {
 	EpisodeImage    string    `yaml:"episode_image"`
 	PodcastDuration string    `yaml:"podcast_duration"`
 	PodcastBytes    int64     `yaml:"podcast_bytes"`
+	// Explicit holds the raw explicit key, which may be a YAML bool or a
+	// Castanet-style string such as "yes" or "clean"; see IsExplicit.
+	Explicit string `yaml:"explicit"`
+}
+
+// IsExplicit reports whether the frontmatter marks the episode explicit:
+// true, yes, or explicit (case-insensitive). Anything else, including
+// "clean", "no", or a missing key, is not explicit.
+func (m *EpisodeMetadata) IsExplicit() bool {
+	switch strings.ToLower(strings.TrimSpace(m.Explicit)) {
+	case "true", "yes", "explicit":
+		return true
+	default:
+		return false
+	}
 }
 
 // UnmarshalYAML decodes EpisodeMetadata while accepting either the capitalised
@@ -99,14 +232,18 @@ func (m *EpisodeMetadata) UnmarshalYAML(value *yaml.Node) error {
 	return nil
 }
 
-// ParseEpisodeMetadata extracts metadata from a Hugo markdown file
+// ParseEpisodeMetadata extracts metadata from a Hugo markdown file. The
+// episode and title fields are required, with the first H1 heading of the
+// body standing in for a missing title; episode_image is optional here, as
+// --cover can supply the artwork instead, and the caller checks that one of
+// them is set.
 func ParseEpisodeMetadata(markdownPath string) (*EpisodeMetadata, error) {
 	content, err := os.ReadFile(markdownPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read episode file: %w", err)
 	}
 
-	frontmatter, err := extractFrontmatter(string(content))
+	frontmatter, err := extractFrontmatter(strings.TrimPrefix(string(content), utf8BOM))
 	if err != nil {
 		return nil, err
 	}
@@ -122,19 +259,74 @@ func ParseEpisodeMetadata(markdownPath string) (*EpisodeMetadata, error) {
 	if _, err := ParseEpisodeNumber(meta.Episode); err != nil {
 		return nil, fmt.Errorf("invalid episode field: %w", err)
 	}
+	if meta.Title == "" {
+		meta.Title = firstHeading(strings.TrimPrefix(string(content), utf8BOM))
+	}
 	if meta.Title == "" {
 		return nil, fmt.Errorf("missing required field: title")
 	}
-	if meta.EpisodeImage == "" {
-		return nil, fmt.Errorf("missing required field: episode_image")
+	return &meta, nil
+}
+
+// firstHeading returns the text of the first "# " heading in the body after
+// the frontmatter, for older posts that carry their title only there. Fenced
+// code blocks are skipped, so a shell comment in an example is not taken for
+// a heading, and any closing #s are trimmed.
+func firstHeading(content string) string {
+	lines, _ := splitLines(content)
+	_, end, err := findFrontmatterBounds(lines)
+	if err != nil {
+		return ""
 	}
 
-	return &meta, nil
+	fenced := false
+	for _, line := range lines[end+1:] {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fenced = !fenced
+			continue
+		}
+		if fenced {
+			continue
+		}
+		if heading, ok := strings.CutPrefix(trimmed, "# "); ok {
+			// A closing run of #s counts only after a space, as in
+			// CommonMark, so "# Learning C#" keeps its #.
+			heading = strings.TrimSpace(heading)
+			if open := strings.TrimRight(heading, "#"); open == "" || strings.HasSuffix(open, " ") || strings.HasSuffix(open, "\t") {
+				heading = strings.TrimSpace(open)
+			}
+			return heading
+		}
+	}
+	return ""
+}
+
+// utf8BOM is the byte-order mark some editors write at the start of UTF-8
+// files. It would hide the opening --- delimiter, so it is set aside while
+// parsing and restored on write-back.
+const utf8BOM = "\uFEFF"
+
+// splitLines splits markdown content into lines with any carriage returns
+// removed, and reports the file's line ending so a rewrite can restore it.
+// The first line break decides the style: "\r\n" for Windows files, "\n"
+// otherwise.
+func splitLines(content string) (lines []string, eol string) {
+	eol = "\n"
+	if idx := strings.IndexByte(content, '\n'); idx > 0 && content[idx-1] == '\r' {
+		eol = "\r\n"
+	}
+
+	lines = strings.Split(content, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	return lines, eol
 }
 
 // extractFrontmatter extracts YAML content between --- delimiters
 func extractFrontmatter(content string) (string, error) {
-	lines := strings.Split(content, "\n")
+	lines, _ := splitLines(content)
 
 	start, end, err := findFrontmatterBounds(lines)
 	if err != nil {
@@ -146,62 +338,132 @@ func extractFrontmatter(content string) (string, error) {
 
 // findFrontmatterBounds locates the start and end indices of frontmatter content.
 // Returns the line index after the opening --- and the line index of the closing ---.
+// The opening delimiter must be the first non-empty line and the closing one is the
+// next --- after it, so a horizontal rule in the body is never taken for a delimiter,
+// and a file without frontmatter is rejected even if its body contains rules.
 func findFrontmatterBounds(lines []string) (start, end int, err error) {
-	delimiterCount := 0
+	open := slices.IndexFunc(lines, func(line string) bool {
+		return strings.TrimSpace(line) != ""
+	})
+	if open < 0 || strings.TrimSpace(lines[open]) != "---" {
+		return 0, 0, fmt.Errorf("invalid frontmatter: file must start with a '---' delimiter")
+	}
 
-	for i, line := range lines {
-		if strings.TrimSpace(line) == "---" {
-			delimiterCount++
-			switch delimiterCount {
-			case 1:
-				start = i + 1
-			case 2:
-				end = i
-				return start, end, nil
-			}
+	start = open + 1
+	for i := start; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == "---" {
+			return start, i, nil
 		}
 	}
 
-	return 0, 0, fmt.Errorf("invalid frontmatter: expected two '---' delimiters, found %d", delimiterCount)
+	return 0, 0, fmt.Errorf("invalid frontmatter: no closing '---' delimiter")
 }
 
 // ResolveCoverArtPath resolves the episode_image path to an absolute path
-// The episode_image in frontmatter is relative to the markdown file
+// The episode_image in frontmatter is relative to the markdown file. A
+// leading slash is tried first as an absolute path on disk, for setups that
+// store one, and otherwise taken as rooted at the Hugo site's static/
+// directory. When a higher resolution variant sits beside the image, it is
+// used instead; see preferCoverVariant.
 func ResolveCoverArtPath(markdownPath, episodeImage string) (string, error) {
 	markdownDir := filepath.Dir(markdownPath)
 
 	// A "./" prefix means the image sits beside the markdown file.
 	if after, ok := strings.CutPrefix(episodeImage, "./"); ok {
-		coverPath := filepath.Join(markdownDir, after)
-		coverPath, err := filepath.Abs(coverPath)
+		coverPath, err := filepath.Abs(filepath.Join(markdownDir, after))
 		if err != nil {
 			return "", fmt.Errorf("failed to resolve cover art path: %w", err)
 		}
-
-		if _, err := os.Stat(coverPath); err != nil {
-			return "", fmt.Errorf("cover art not found: %s", coverPath)
+		if !isFile(coverPath) {
+			return "", coverNotFound(episodeImage, "beside the markdown file at "+coverPath)
 		}
+		return preferCoverVariant(coverPath), nil
+	}
 
-		return coverPath, nil
+	// An absolute path to an existing file is used as it is.
+	var tried []string
+	if filepath.IsAbs(episodeImage) {
+		coverPath := filepath.Clean(episodeImage)
+		if isFile(coverPath) {
+			return preferCoverVariant(coverPath), nil
+		}
+		tried = append(tried, "as a file path at "+coverPath)
 	}
 
 	// Otherwise the path is rooted at the Hugo site, served from static/.
 	projectRoot, err := findProjectRoot(markdownDir)
 	if err != nil {
+		if len(tried) > 0 {
+			return "", fmt.Errorf("%w, and %w", coverNotFound(episodeImage, tried...), err)
+		}
 		return "", err
 	}
 
-	coverPath := filepath.Join(projectRoot, "static", strings.TrimPrefix(episodeImage, "/"))
-	coverPath, err = filepath.Abs(coverPath)
+	coverPath, err := filepath.Abs(filepath.Join(projectRoot, "static", strings.TrimPrefix(episodeImage, "/")))
 	if err != nil {
 		return "", fmt.Errorf("failed to resolve cover art path: %w", err)
 	}
+	if !isFile(coverPath) {
+		tried = append(tried, "under the site's static/ directory at "+coverPath)
+		return "", coverNotFound(episodeImage, tried...)
+	}
+
+	return preferCoverVariant(coverPath), nil
+}
+
+// isFile reports whether path names an existing regular file.
+func isFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular()
+}
+
+// coverNotFound reports an episode_image that resolved to no file, listing
+// where each lookup looked, so a misconfigured site shows which
+// interpretation of the path was used.
+func coverNotFound(episodeImage string, tried ...string) error {
+	return fmt.Errorf("cover art not found for episode_image %q: looked %s", episodeImage, strings.Join(tried, "; then "))
+}
 
-	if _, err := os.Stat(coverPath); err != nil {
-		return "", fmt.Errorf("cover art not found: %s", coverPath)
+// coverVariantSuffixes are the name suffixes a theme uses for larger copies
+// of an image, e.g. image@2x.png beside image.png.
+var coverVariantSuffixes = []string{"@2x", "@3x", "-large"}
+
+// maxCoverVariantSize is the largest edge length, in pixels, worth picking a
+// variant for: the Apple Podcasts maximum that cover art is scaled to.
+const maxCoverVariantSize = 3000
+
+// preferCoverVariant returns the largest square image among coverPath and its
+// sibling variants that is no more than maxCoverVariantSize pixels across, so
+// a small image is not upscaled when a sharper source exists beside it.
+// coverPath itself is returned when no variant is larger or it cannot be read.
+func preferCoverVariant(coverPath string) string {
+	ext := filepath.Ext(coverPath)
+	stem := strings.TrimSuffix(coverPath, ext)
+
+	best, bestSize := coverPath, squareImageSize(coverPath)
+	for _, suffix := range coverVariantSuffixes {
+		variant := stem + suffix + ext
+		if size := squareImageSize(variant); size > bestSize && size <= maxCoverVariantSize {
+			best, bestSize = variant, size
+		}
+	}
+	return best
+}
+
+// squareImageSize returns the edge length of the square image at path, or 0
+// when it is missing, not a decodable image, or not square.
+func squareImageSize(path string) int {
+	f, err := os.Open(path) //nolint:gosec // path is derived from the episode_image frontmatter
+	if err != nil {
+		return 0
 	}
+	defer f.Close()
 
-	return coverPath, nil
+	cfg, _, err := image.DecodeConfig(f)
+	if err != nil || cfg.Width != cfg.Height {
+		return 0
+	}
+	return cfg.Width
 }
 
 // findProjectRoot walks up the directory tree to find the Hugo project root
@@ -231,20 +493,197 @@ func FormatDateForID3(t time.Time) string {
 	return t.Format("2006-01")
 }
 
-// UpdateFrontmatter updates podcast_duration and podcast_bytes in the markdown file
+// FormatFullDateForID3 formats a time.Time to "YYYY-MM-DD" format for ID3 TDRC
+// tag, keeping the release day for apps that display it.
+func FormatFullDateForID3(t time.Time) string {
+	return t.Format("2006-01-02")
+}
+
+// FormatTimestampForID3 formats a time.Time to the ID3v2.4 timestamp form
+// "YYYY-MM-DDTHH:MM:SS". TDRC carries no zone, so the wall-clock time is
+// written as given.
+func FormatTimestampForID3(t time.Time) string {
+	return t.Format("2006-01-02T15:04:05")
+}
+
+// FormatReleaseDate renders t for the date tag. By default it truncates to
+// "YYYY-MM"; with full it keeps the day, and the time of day too when t has one
+// (a Hugo Date of "2025-10-14T09:30:00Z" becomes "2025-10-14T09:30:00").
+func FormatReleaseDate(t time.Time, full bool) string {
+	if !full {
+		return FormatDateForID3(t)
+	}
+	if t.Hour() != 0 || t.Minute() != 0 || t.Second() != 0 {
+		return FormatTimestampForID3(t)
+	}
+	return FormatFullDateForID3(t)
+}
+
+// releaseDateLayouts lists the accepted release date input layouts, most
+// specific first so a full date is never matched by a shorter layout.
+var releaseDateLayouts = []string{time.RFC3339, "2006-01-02", "2006-01", "2006"}
+
+// ParseReleaseDate parses a release date in YYYY, YYYY-MM, YYYY-MM-DD, or
+// RFC3339 form. Missing parts default to the start of the period, so "2025-10"
+// is 1 October 2025 at midnight UTC.
+func ParseReleaseDate(s string) (time.Time, error) {
+	t, _, err := parseReleaseDate(s)
+	return t, err
+}
+
+// NormaliseReleaseDate parses a release date in YYYY, YYYY-MM, YYYY-MM-DD, or
+// RFC3339 form and renders it with FormatReleaseDate. The output never claims
+// more precision than the input: a bare year stays "YYYY" and a year-month
+// stays "YYYY-MM". Unparseable input is rejected so a malformed value never
+// reaches the date tag.
+func NormaliseReleaseDate(s string, full bool) (string, error) {
+	t, layout, err := parseReleaseDate(s)
+	if err != nil {
+		return "", err
+	}
+
+	switch layout {
+	case "2006", "2006-01":
+		return t.Format(layout), nil
+	}
+	return FormatReleaseDate(t, full), nil
+}
+
+// parseReleaseDate parses s with the first of releaseDateLayouts that fits,
+// returning the time and the layout it matched.
+func parseReleaseDate(s string) (time.Time, string, error) {
+	for _, layout := range releaseDateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, layout, nil
+		}
+	}
+	return time.Time{}, "", fmt.Errorf("invalid release date %q: expected YYYY, YYYY-MM, YYYY-MM-DD, or RFC3339", s)
+}
+
+// frontmatterValueQuote returns the quote character wrapping a top-level
+// "key: value" line's value, or "" when the value is bare or the line is not a
+// top-level scalar.
+func frontmatterValueQuote(line string) string {
+	if line == "" || line[0] == ' ' || line[0] == '\t' || line[0] == '#' {
+		return ""
+	}
+	_, value, ok := strings.Cut(line, ":")
+	if !ok {
+		return ""
+	}
+	value = strings.TrimSpace(value)
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[:1]
+	}
+	return ""
+}
+
+// durationQuote picks the quoting for podcast_duration so it matches the
+// file. An existing podcast_duration keeps its own style; otherwise the
+// duration is quoted in whichever style the sibling fields mostly use, and
+// left bare only when no sibling is quoted.
+func durationQuote(lines []string) string {
+	doubles, singles := 0, 0
+	for _, line := range lines {
+		quote := frontmatterValueQuote(line)
+		if strings.HasPrefix(line, "podcast_duration:") {
+			return quote
+		}
+		switch quote {
+		case `"`:
+			doubles++
+		case "'":
+			singles++
+		}
+	}
+
+	switch {
+	case singles > doubles:
+		return "'"
+	case doubles > 0:
+		return `"`
+	}
+	return ""
+}
+
+// UpdateFrontmatter updates podcast_duration and podcast_bytes in the markdown
+// file. The duration is quoted to match the surrounding frontmatter, which
+// also keeps its colons unambiguous to YAML linters. Only the two keys change:
+// the file keeps its permission bits and its trailing newline, or lack of
+// one, so a git-tracked episode shows no spurious diff.
 func UpdateFrontmatter(markdownPath, duration string, bytes int64) error {
+	info, err := os.Stat(markdownPath)
+	if err != nil {
+		return fmt.Errorf("failed to stat file: %w", err)
+	}
+
 	content, err := os.ReadFile(markdownPath)
 	if err != nil {
 		return fmt.Errorf("failed to read file: %w", err)
 	}
 
-	lines := strings.Split(string(content), "\n")
+	output, err := updatedFrontmatter(string(content), duration, bytes)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(markdownPath, []byte(output), info.Mode().Perm()); err != nil { //nolint:gosec // markdownPath is user-provided input path, not tainted
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+
+	return nil
+}
+
+// FrontmatterDiff returns the change UpdateFrontmatter would make to the
+// markdown file as a unified diff, without writing it, or "" when the file
+// already holds these values.
+func FrontmatterDiff(markdownPath, duration string, bytes int64) (string, error) {
+	content, err := os.ReadFile(markdownPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+
+	output, err := updatedFrontmatter(string(content), duration, bytes)
+	if err != nil {
+		return "", err
+	}
+	before, _ := splitLines(strings.TrimPrefix(string(content), utf8BOM))
+	after, _ := splitLines(strings.TrimPrefix(output, utf8BOM))
+	// A final newline leaves an empty last line that is not a line of text.
+	if len(before) > 0 && before[len(before)-1] == "" && len(after) > 0 && after[len(after)-1] == "" {
+		before, after = before[:len(before)-1], after[:len(after)-1]
+	}
+
+	// Only the frontmatter changes, and the body can be a whole transcript,
+	// so the diff covers the frontmatter and the context lines around it.
+	start, end, err := findFrontmatterBounds(before)
+	if err != nil {
+		return "", fmt.Errorf("invalid frontmatter format: %w", err)
+	}
+	_, afterEnd, err := findFrontmatterBounds(after)
+	if err != nil {
+		return "", fmt.Errorf("invalid frontmatter format: %w", err)
+	}
+	from := max(start-1-textdiff.Context, 0) // start-1 is the opening ---
+	before = before[from:min(end+1+textdiff.Context, len(before))]
+	after = after[from:min(afterEnd+1+textdiff.Context, len(after))]
+	return textdiff.Unified(markdownPath, markdownPath+" (updated)", before, after, from), nil
+}
+
+// updatedFrontmatter returns content with podcast_duration and podcast_bytes
+// set, rewriting the keys in place or adding them before the closing
+// delimiter.
+func updatedFrontmatter(content, duration string, bytes int64) (string, error) {
+	text, hasBOM := strings.CutPrefix(content, utf8BOM)
+	lines, eol := splitLines(text)
 
 	start, end, err := findFrontmatterBounds(lines)
 	if err != nil {
-		return fmt.Errorf("invalid frontmatter format: %w", err)
+		return "", fmt.Errorf("invalid frontmatter format: %w", err)
 	}
 
+	quote := durationQuote(lines[start:end])
+	durationLine := fmt.Sprintf("podcast_duration: %s%s%s", quote, duration, quote)
+
 	// Rewrite existing keys in place, tracking which were present.
 	updated := false
 	bytesUpdated := false
@@ -253,7 +692,7 @@ func UpdateFrontmatter(markdownPath, duration string, bytes int64) error {
 		line := lines[i]
 
 		if strings.HasPrefix(strings.TrimSpace(line), "podcast_duration:") {
-			lines[i] = fmt.Sprintf("podcast_duration: %s", duration)
+			lines[i] = durationLine
 			updated = true
 		}
 
@@ -267,7 +706,7 @@ func UpdateFrontmatter(markdownPath, duration string, bytes int64) error {
 	if !updated || !bytesUpdated {
 		var insertLines []string
 		if !updated {
-			insertLines = append(insertLines, fmt.Sprintf("podcast_duration: %s", duration))
+			insertLines = append(insertLines, durationLine)
 		}
 		if !bytesUpdated {
 			insertLines = append(insertLines, fmt.Sprintf("podcast_bytes: %d", bytes))
@@ -276,10 +715,12 @@ func UpdateFrontmatter(markdownPath, duration string, bytes int64) error {
 		lines = slices.Insert(lines, end, insertLines...)
 	}
 
-	output := strings.Join(lines, "\n")
-	if err := os.WriteFile(markdownPath, []byte(output), 0o644); err != nil { //nolint:gosec // markdownPath is user-provided input path, not tainted
-		return fmt.Errorf("failed to write file: %w", err)
+	// Splitting and re-joining round-trips a final newline exactly: a trailing
+	// newline leaves an empty last element that the join restores, in the
+	// file's own line ending.
+	output := strings.Join(lines, eol)
+	if hasBOM {
+		output = utf8BOM + output
 	}
-
-	return nil
+	return output, nil
 }
@@ -1,10 +1,13 @@
 package encoder
 
 import (
+	"image"
+	"image/png"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestParseEpisodeMetadata(t *testing.T) {
@@ -50,15 +53,14 @@ episode_image: "/img/test.png"
 			errContains: "missing required field: title",
 		},
 		{
-			name: "missing episode_image field",
+			name: "missing episode_image field is allowed",
 			content: `---
 episode: "67"
 title: "Test Episode"
 Date: 2025-11-09T00:00:00Z
 ---
 `,
-			wantErr:     true,
-			errContains: "missing required field: episode_image",
+			wantErr: false,
 		},
 		{
 			name: "invalid YAML",
@@ -91,6 +93,17 @@ No closing delimiter
 			wantErr:     true,
 			errContains: "invalid frontmatter",
 		},
+		{
+			name:        "body rules without frontmatter",
+			content:     "Intro text\n\n---\nepisode: \"67\"\ntitle: \"Test\"\nepisode_image: \"/img/test.png\"\n---\n",
+			wantErr:     true,
+			errContains: "must start with",
+		},
+		{
+			name:    "blank lines before opening delimiter",
+			content: "\n\n---\nepisode: \"67\"\ntitle: \"Test\"\nepisode_image: \"/img/test.png\"\n---\n",
+			wantErr: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -126,6 +139,71 @@ No closing delimiter
 	}
 }
 
+// TestParseEpisodeMetadata_HeadingTitle verifies the first H1 of the body
+// stands in for a missing title, and never replaces a frontmatter one.
+func TestParseEpisodeMetadata_HeadingTitle(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "frontmatter title wins",
+			content: "---\nepisode: \"12\"\ntitle: \"From Frontmatter\"\n---\n\n# From Heading\n",
+			want:    "From Frontmatter",
+		},
+		{
+			name:    "heading fallback",
+			content: "---\nepisode: \"12\"\n---\n\nIntro.\n\n# Back Catalogue Title\n\n# Second Heading\n",
+			want:    "Back Catalogue Title",
+		},
+		{
+			name:    "closing hashes trimmed",
+			content: "---\nepisode: \"12\"\n---\n# Closed Heading ##\n",
+			want:    "Closed Heading",
+		},
+		{
+			name:    "trailing hash in the title kept",
+			content: "---\nepisode: \"12\"\n---\n# Learning C#\n",
+			want:    "Learning C#",
+		},
+		{
+			name:    "fenced code skipped",
+			content: "---\nepisode: \"12\"\n---\n```bash\n# not a title\n```\n# Real Title\n",
+			want:    "Real Title",
+		},
+		{
+			name:    "H2 is not a title",
+			content: "---\nepisode: \"12\"\n---\n## Show Notes\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "episode.md")
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			meta, err := ParseEpisodeMetadata(path)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "missing required field: title") {
+					t.Errorf("ParseEpisodeMetadata() error = %v, want missing title", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseEpisodeMetadata() error: %v", err)
+			}
+			if meta.Title != tt.want {
+				t.Errorf("Title = %q, want %q", meta.Title, tt.want)
+			}
+		})
+	}
+}
+
 func TestParseEpisodeMetadata_ValidFields(t *testing.T) {
 	content := `---
 episode: "67"
@@ -232,6 +310,42 @@ episode_image: "/img/test.png"
 	}
 }
 
+// TestParseEpisodeMetadata_Explicit verifies the explicit key accepts a YAML
+// bool or Castanet-style strings, and defaults to not explicit.
+func TestParseEpisodeMetadata_Explicit(t *testing.T) {
+	tests := []struct {
+		name  string
+		line  string
+		wantE bool
+	}{
+		{name: "bool true", line: "explicit: true\n", wantE: true},
+		{name: "bool false", line: "explicit: false\n", wantE: false},
+		{name: "string yes", line: "explicit: \"yes\"\n", wantE: true},
+		{name: "string Explicit", line: "explicit: \"Explicit\"\n", wantE: true},
+		{name: "string clean", line: "explicit: \"clean\"\n", wantE: false},
+		{name: "missing key", line: "", wantE: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := "---\nepisode: \"67\"\ntitle: \"Test\"\nepisode_image: \"/img/test.png\"\n" + tt.line + "---\n"
+
+			tmpFile := filepath.Join(t.TempDir(), "test.md")
+			if err := os.WriteFile(tmpFile, []byte(content), 0o644); err != nil {
+				t.Fatalf("Failed to create test file: %v", err)
+			}
+
+			meta, err := ParseEpisodeMetadata(tmpFile)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got := meta.IsExplicit(); got != tt.wantE {
+				t.Errorf("IsExplicit() = %v, want %v (explicit=%q)", got, tt.wantE, meta.Explicit)
+			}
+		})
+	}
+}
+
 func TestParseEpisodeNumber(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -265,6 +379,107 @@ func TestParseEpisodeNumber(t *testing.T) {
 	}
 }
 
+// TestFormatReleaseDate tests truncation by default and day/time preservation
+// with full precision.
+func TestFormatReleaseDate(t *testing.T) {
+	withTime := time.Date(2025, 10, 14, 9, 30, 15, 0, time.UTC)
+	midnight := time.Date(2025, 10, 14, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		t    time.Time
+		full bool
+		want string
+	}{
+		{name: "truncated by default", t: withTime, want: "2025-10"},
+		{name: "full keeps time of day", t: withTime, full: true, want: "2025-10-14T09:30:15"},
+		{name: "full at midnight keeps day only", t: midnight, full: true, want: "2025-10-14"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatReleaseDate(tt.t, tt.full); got != tt.want {
+				t.Errorf("FormatReleaseDate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestNormaliseReleaseDate tests the accepted date layouts, precision handling,
+// and rejection of unparseable input.
+func TestNormaliseReleaseDate(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		full    bool
+		want    string
+		wantErr bool
+	}{
+		{name: "year only", input: "2025", want: "2025"},
+		{name: "year-month", input: "2025-10", want: "2025-10"},
+		{name: "year-month with full", input: "2025-10", full: true, want: "2025-10"},
+		{name: "full date truncated", input: "2025-10-14", want: "2025-10"},
+		{name: "full date kept", input: "2025-10-14", full: true, want: "2025-10-14"},
+		{name: "RFC3339 truncated", input: "2025-10-14T09:30:00Z", want: "2025-10"},
+		{name: "RFC3339 with offset keeps time", input: "2025-10-14T09:30:00+01:00", full: true, want: "2025-10-14T09:30:00"},
+		{name: "RFC3339 midnight", input: "2025-10-14T00:00:00Z", full: true, want: "2025-10-14"},
+		{name: "empty", input: "", wantErr: true},
+		{name: "garbage", input: "next tuesday", wantErr: true},
+		{name: "invalid month", input: "2025-13", wantErr: true},
+		{name: "day-first", input: "14/10/2025", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormaliseReleaseDate(tt.input, tt.full)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected error for input %q, got %q", tt.input, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error for input %q: %v", tt.input, err)
+			}
+			if got != tt.want {
+				t.Errorf("NormaliseReleaseDate(%q, %v) = %q, want %q", tt.input, tt.full, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseReleaseDate(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    time.Time
+		wantErr bool
+	}{
+		{input: "2025", want: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{input: "2025-10", want: time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC)},
+		{input: "2025-10-14", want: time.Date(2025, 10, 14, 0, 0, 0, 0, time.UTC)},
+		{input: "2025-10-14T09:30:00+01:00", want: time.Date(2025, 10, 14, 8, 30, 0, 0, time.UTC)},
+		{input: "next tuesday", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseReleaseDate(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected error for input %q, got %v", tt.input, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error for input %q: %v", tt.input, err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("ParseReleaseDate(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
 // TestUpdateFrontmatter_InsertBothFields tests inserting missing podcast_duration and podcast_bytes
 func TestUpdateFrontmatter_InsertBothFields(t *testing.T) {
 	content := `---
@@ -297,7 +512,7 @@ More content.
 	updatedContent := string(updated)
 
 	// Verify fields were added before closing delimiter
-	if !strings.Contains(updatedContent, "podcast_duration: 01:23:45") {
+	if !strings.Contains(updatedContent, `podcast_duration: "01:23:45"`) {
 		t.Error("podcast_duration field not found in updated frontmatter")
 	}
 	if !strings.Contains(updatedContent, "podcast_bytes: 5555555") {
@@ -375,7 +590,7 @@ Episode content.
 	updatedContent := string(updated)
 
 	// Verify fields were updated with new values
-	if !strings.Contains(updatedContent, "podcast_duration: 01:23:45") {
+	if !strings.Contains(updatedContent, `podcast_duration: "01:23:45"`) {
 		t.Error("podcast_duration not updated correctly")
 	}
 	if !strings.Contains(updatedContent, "podcast_bytes: 5555555") {
@@ -383,7 +598,7 @@ Episode content.
 	}
 
 	// Verify old values are gone
-	if strings.Contains(updatedContent, "podcast_duration: 00:10:00") {
+	if strings.Contains(updatedContent, `podcast_duration: "00:10:00"`) {
 		t.Error("Old podcast_duration value still present")
 	}
 	if strings.Contains(updatedContent, "podcast_bytes: 1000000") {
@@ -423,7 +638,7 @@ Episode content.
 	updatedContent := string(updated)
 
 	// Verify both fields are now correct
-	if !strings.Contains(updatedContent, "podcast_duration: 00:15:00") {
+	if !strings.Contains(updatedContent, `podcast_duration: "00:15:00"`) {
 		t.Error("podcast_duration not updated")
 	}
 	if !strings.Contains(updatedContent, "podcast_bytes: 3333333") {
@@ -518,6 +733,11 @@ Some content without proper closing
 `,
 			wantErr: true,
 		},
+		{
+			name:    "body rules without frontmatter",
+			content: "Intro text\n\n---\n\nMore text\n\n---\n",
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -572,7 +792,7 @@ Episode content.
 
 	updatedContent := string(updated)
 
-	if !strings.Contains(updatedContent, "podcast_duration: 54:32:10") {
+	if !strings.Contains(updatedContent, `podcast_duration: "54:32:10"`) {
 		t.Error("Duration not updated correctly")
 	}
 	if !strings.Contains(updatedContent, "podcast_bytes: 104857600") {
@@ -611,7 +831,7 @@ Episode content.
 
 	updatedContent := string(updated)
 
-	if !strings.Contains(updatedContent, "podcast_duration: 00:00:00") {
+	if !strings.Contains(updatedContent, `podcast_duration: "00:00:00"`) {
 		t.Error("Zero duration not handled correctly")
 	}
 	if !strings.Contains(updatedContent, "podcast_bytes: 0") {
@@ -647,7 +867,7 @@ episode_image: "/img/test.png"
 	updatedContent := string(updated)
 
 	// Verify fields were added
-	if !strings.Contains(updatedContent, "podcast_duration: 00:10:00") {
+	if !strings.Contains(updatedContent, `podcast_duration: "00:10:00"`) {
 		t.Error("podcast_duration not added")
 	}
 	if !strings.Contains(updatedContent, "podcast_bytes: 1000000") {
@@ -655,6 +875,228 @@ episode_image: "/img/test.png"
 	}
 }
 
+// TestUpdateFrontmatter_QuotingStyle tests that podcast_duration follows the
+// quoting style already used in the frontmatter
+func TestUpdateFrontmatter_QuotingStyle(t *testing.T) {
+	tests := []struct {
+		name         string
+		frontmatter  string
+		wantDuration string
+	}{
+		{
+			name:         "quoted siblings",
+			frontmatter:  "episode: \"67\"\ntitle: \"Test Episode\"\n",
+			wantDuration: `podcast_duration: "01:23:45"`,
+		},
+		{
+			name:         "single-quoted siblings",
+			frontmatter:  "episode: '67'\ntitle: 'Test Episode'\n",
+			wantDuration: `podcast_duration: '01:23:45'`,
+		},
+		{
+			name:         "bare siblings",
+			frontmatter:  "episode: 67\ntitle: Test Episode\n",
+			wantDuration: "podcast_duration: 01:23:45",
+		},
+		{
+			name:         "existing bare duration keeps its style",
+			frontmatter:  "episode: \"67\"\npodcast_duration: 0:00:27\n",
+			wantDuration: "podcast_duration: 01:23:45",
+		},
+		{
+			name:         "existing quoted duration keeps its style",
+			frontmatter:  "episode: 67\npodcast_duration: \"0:00:27\"\n",
+			wantDuration: `podcast_duration: "01:23:45"`,
+		},
+		{
+			name:         "nested quoted values are ignored",
+			frontmatter:  "episode: 67\nhosts:\n  - \"apope\"\n",
+			wantDuration: "podcast_duration: 01:23:45",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpFile := filepath.Join(t.TempDir(), "test.md")
+			if err := os.WriteFile(tmpFile, []byte("---\n"+tt.frontmatter+"---\n\nBody\n"), 0o644); err != nil {
+				t.Fatalf("Failed to create test file: %v", err)
+			}
+
+			if err := UpdateFrontmatter(tmpFile, "01:23:45", 5555555); err != nil {
+				t.Fatalf("UpdateFrontmatter failed: %v", err)
+			}
+
+			updated, err := os.ReadFile(tmpFile)
+			if err != nil {
+				t.Fatalf("Failed to read updated file: %v", err)
+			}
+			if !strings.Contains(string(updated)+"\n", tt.wantDuration+"\n") {
+				t.Errorf("want line %q in:\n%s", tt.wantDuration, updated)
+			}
+		})
+	}
+}
+
+// TestUpdateFrontmatter_PreservesFileShape tests that the file mode and the
+// presence or absence of a trailing newline survive the rewrite
+func TestUpdateFrontmatter_PreservesFileShape(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		mode    os.FileMode
+	}{
+		{name: "trailing newline", content: "---\nepisode: 67\n---\n\nBody\n", mode: 0o600},
+		{name: "no trailing newline", content: "---\nepisode: 67\n---\n\nBody", mode: 0o640},
+		{name: "frontmatter only without newline", content: "---\nepisode: 67\n---", mode: 0o644},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpFile := filepath.Join(t.TempDir(), "test.md")
+			if err := os.WriteFile(tmpFile, []byte(tt.content), tt.mode); err != nil {
+				t.Fatalf("Failed to create test file: %v", err)
+			}
+			// WriteFile's mode is filtered by the umask, so set it explicitly.
+			if err := os.Chmod(tmpFile, tt.mode); err != nil {
+				t.Fatalf("Failed to chmod test file: %v", err)
+			}
+
+			if err := UpdateFrontmatter(tmpFile, "01:23:45", 5555555); err != nil {
+				t.Fatalf("UpdateFrontmatter failed: %v", err)
+			}
+
+			info, err := os.Stat(tmpFile)
+			if err != nil {
+				t.Fatalf("Failed to stat updated file: %v", err)
+			}
+			if info.Mode().Perm() != tt.mode {
+				t.Errorf("mode = %v, want %v", info.Mode().Perm(), tt.mode)
+			}
+
+			updated, err := os.ReadFile(tmpFile)
+			if err != nil {
+				t.Fatalf("Failed to read updated file: %v", err)
+			}
+			hadNewline := strings.HasSuffix(tt.content, "\n")
+			if hasNewline := strings.HasSuffix(string(updated), "\n"); hasNewline != hadNewline {
+				t.Errorf("trailing newline = %v, want %v:\n%q", hasNewline, hadNewline, updated)
+			}
+		})
+	}
+}
+
+// TestFrontmatter_CRLFRoundTrip tests that a Windows CRLF file parses and that
+// updating it keeps CRLF on every line and the body byte-for-byte
+func TestFrontmatter_CRLFRoundTrip(t *testing.T) {
+	content := strings.ReplaceAll(`---
+episode: "67"
+title: "Mirrors, Motors and Makefiles"
+episode_image: "/img/episode/linuxmatters-3000x3000.png"
+podcast_duration: "00:10:00"
+---
+
+Episode content.
+Second line.
+`, "\n", "\r\n")
+
+	tmpFile := filepath.Join(t.TempDir(), "test.md")
+	if err := os.WriteFile(tmpFile, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	meta, err := ParseEpisodeMetadata(tmpFile)
+	if err != nil {
+		t.Fatalf("ParseEpisodeMetadata failed: %v", err)
+	}
+	if meta.Episode != "67" || meta.Title != "Mirrors, Motors and Makefiles" || meta.PodcastDuration != "00:10:00" {
+		t.Errorf("unexpected metadata: %+v", meta)
+	}
+
+	if err := UpdateFrontmatter(tmpFile, "01:23:45", 5555555); err != nil {
+		t.Fatalf("UpdateFrontmatter failed: %v", err)
+	}
+
+	updated, err := os.ReadFile(tmpFile)
+	if err != nil {
+		t.Fatalf("Failed to read updated file: %v", err)
+	}
+	want := strings.ReplaceAll(`---
+episode: "67"
+title: "Mirrors, Motors and Makefiles"
+episode_image: "/img/episode/linuxmatters-3000x3000.png"
+podcast_duration: "01:23:45"
+podcast_bytes: 5555555
+---
+
+Episode content.
+Second line.
+`, "\n", "\r\n")
+	if string(updated) != want {
+		t.Errorf("CRLF round trip:\ngot  %q\nwant %q", updated, want)
+	}
+}
+
+// TestFrontmatter_BOM tests that a UTF-8 byte-order mark before the opening
+// delimiter is skipped when parsing and kept when updating
+func TestFrontmatter_BOM(t *testing.T) {
+	content := "\uFEFF---\nepisode: \"67\"\ntitle: \"Test Episode\"\nepisode_image: \"/img/test.png\"\n---\n\nBody\n"
+
+	tmpFile := filepath.Join(t.TempDir(), "test.md")
+	if err := os.WriteFile(tmpFile, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	meta, err := ParseEpisodeMetadata(tmpFile)
+	if err != nil {
+		t.Fatalf("ParseEpisodeMetadata failed: %v", err)
+	}
+	if meta.Episode != "67" {
+		t.Errorf("Expected episode '67', got '%s'", meta.Episode)
+	}
+
+	if err := UpdateFrontmatter(tmpFile, "01:23:45", 5555555); err != nil {
+		t.Fatalf("UpdateFrontmatter failed: %v", err)
+	}
+
+	updated, err := os.ReadFile(tmpFile)
+	if err != nil {
+		t.Fatalf("Failed to read updated file: %v", err)
+	}
+	if !strings.HasPrefix(string(updated), "\uFEFF---\n") {
+		t.Errorf("BOM not preserved: %q", updated)
+	}
+	if strings.Count(string(updated), "\uFEFF") != 1 {
+		t.Errorf("expected exactly one BOM: %q", updated)
+	}
+	if !strings.Contains(string(updated), "podcast_bytes: 5555555\n---\n") {
+		t.Errorf("podcast_bytes not inserted before the closing delimiter: %q", updated)
+	}
+}
+
+// TestUpdateFrontmatter_BodyHorizontalRule tests that a --- rule in the body
+// does not move where the fields are inserted
+func TestUpdateFrontmatter_BodyHorizontalRule(t *testing.T) {
+	content := "---\nepisode: 67\n---\n\nShow notes\n\n---\n\nCredits\n"
+
+	tmpFile := filepath.Join(t.TempDir(), "test.md")
+	if err := os.WriteFile(tmpFile, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	if err := UpdateFrontmatter(tmpFile, "00:10:00", 1000000); err != nil {
+		t.Fatalf("UpdateFrontmatter failed: %v", err)
+	}
+
+	updated, err := os.ReadFile(tmpFile)
+	if err != nil {
+		t.Fatalf("Failed to read updated file: %v", err)
+	}
+	want := "---\nepisode: 67\npodcast_duration: 00:10:00\npodcast_bytes: 1000000\n---\n\nShow notes\n\n---\n\nCredits\n"
+	if string(updated) != want {
+		t.Errorf("got %q, want %q", updated, want)
+	}
+}
+
 // TestUpdateFrontmatter_MultilineFieldValues tests with multiline YAML fields
 func TestUpdateFrontmatter_MultilineFieldValues(t *testing.T) {
 	content := `---
@@ -690,7 +1132,7 @@ Episode content.
 	updatedContent := string(updated)
 
 	// Verify fields were added and multiline content preserved
-	if !strings.Contains(updatedContent, "podcast_duration: 00:25:00") {
+	if !strings.Contains(updatedContent, `podcast_duration: "00:25:00"`) {
 		t.Error("podcast_duration not added")
 	}
 	if !strings.Contains(updatedContent, "This is a multiline") {
@@ -873,6 +1315,55 @@ episode_image: "/media/podcasts/cover.png"
 	}
 }
 
+// TestResolveCoverArtPath_FilesystemPath verifies a leading-slash
+// episode_image naming a file on disk is used directly, while one that only
+// exists under static/ still resolves there.
+func TestResolveCoverArtPath_FilesystemPath(t *testing.T) {
+	site := t.TempDir()
+	for _, dir := range []string{"static/img", "content/episodes"} {
+		if err := os.MkdirAll(filepath.Join(site, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	staticCover := filepath.Join(site, "static", "img", "cover.png")
+	artDir := t.TempDir()
+	diskCover := filepath.Join(artDir, "ep67.png")
+	for _, path := range []string{staticCover, diskCover} {
+		if err := os.WriteFile(path, []byte("fake png"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	markdownPath := filepath.Join(site, "content", "episodes", "67.md")
+
+	tests := []struct {
+		name  string
+		image string
+		want  string
+	}{
+		{name: "absolute file on disk", image: diskCover, want: diskCover},
+		{name: "site-root path", image: "/img/cover.png", want: staticCover},
+		{name: "directory on disk falls back to static", image: artDir, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveCoverArtPath(markdownPath, tt.image)
+			if tt.want == "" {
+				if err == nil || !strings.Contains(err.Error(), "cover art not found") {
+					t.Errorf("ResolveCoverArtPath(%q) = %q, %v; want cover art not found", tt.image, got, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResolveCoverArtPath(%q) error: %v", tt.image, err)
+			}
+			if got != tt.want {
+				t.Errorf("ResolveCoverArtPath(%q) = %q, want %q", tt.image, got, tt.want)
+			}
+		})
+	}
+}
+
 func TestResolveCoverArtPath_FileNotFound_Relative(t *testing.T) {
 	// Test error when relative path file does not exist
 	tmpDir := t.TempDir()
@@ -890,11 +1381,14 @@ episode_image: "./missing.png"
 
 	_, err := ResolveCoverArtPath(markdownPath, "./missing.png")
 	if err == nil {
-		t.Error("Expected error for missing file, got nil")
+		t.Fatal("Expected error for missing file, got nil")
 	}
 	if !strings.Contains(err.Error(), "cover art not found") {
 		t.Errorf("Expected 'cover art not found' error, got: %v", err)
 	}
+	if want := "beside the markdown file at " + filepath.Join(tmpDir, "missing.png"); !strings.Contains(err.Error(), want) {
+		t.Errorf("error %q does not mention %q", err, want)
+	}
 }
 
 func TestResolveCoverArtPath_FileNotFound_Absolute(t *testing.T) {
@@ -920,11 +1414,17 @@ episode_image: "/img/missing.png"
 
 	_, err := ResolveCoverArtPath(markdownPath, "/img/missing.png")
 	if err == nil {
-		t.Error("Expected error for missing file, got nil")
+		t.Fatal("Expected error for missing file, got nil")
 	}
 	if !strings.Contains(err.Error(), "cover art not found") {
 		t.Errorf("Expected 'cover art not found' error, got: %v", err)
 	}
+	// Both interpretations of the leading slash are reported.
+	for _, want := range []string{"as a file path at " + filepath.FromSlash("/img/missing.png"), "static/ directory at " + filepath.Join(tmpDir, "static", "img", "missing.png")} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
 }
 
 func TestResolveCoverArtPath_NoProjectRoot(t *testing.T) {
@@ -958,8 +1458,9 @@ func TestBuildMuxerTags(t *testing.T) {
 		Title:         "Foo",
 		Artist:        "Linux Matters",
 		Album:         "Linux Matters",
+		AlbumArtist:   "Linux Matters",
 		Date:          "2026-06",
-		Comment:       "A comment",
+		Comments:      []string{"A comment"},
 	})
 
 	got := make(map[string]string, len(tags))
@@ -973,13 +1474,54 @@ func TestBuildMuxerTags(t *testing.T) {
 	if got["track"] != "67" {
 		t.Errorf("track = %q, want %q", got["track"], "67")
 	}
-	for _, key := range []string{"artist", "album", "date", "comment"} {
+	for _, key := range []string{"artist", "album_artist", "album", "date", "comment"} {
 		if got[key] == "" {
 			t.Errorf("expected %q to be present", key)
 		}
 	}
 }
 
+func TestTrackNumber(t *testing.T) {
+	tests := []struct {
+		num  string
+		want string
+	}{
+		{num: "67", want: "67"},
+		{num: "007", want: "7"},
+		{num: "0", want: "0"},
+		{num: "000", want: "0"},
+		{num: "S01E07", want: "S01E07"},
+		{num: "-07", want: "-07"},
+		{num: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.num, func(t *testing.T) {
+			if got := trackNumber(tt.num); got != tt.want {
+				t.Errorf("trackNumber(%q) = %q, want %q", tt.num, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestBuildMuxerTagsPaddedNumber tests that a zero-padded episode number is
+// written to the track tag as a plain integer but kept in the title.
+func TestBuildMuxerTagsPaddedNumber(t *testing.T) {
+	tags := buildMuxerTags(Metadata{EpisodeNumber: "007", Title: "Foo"})
+
+	got := make(map[string]string, len(tags))
+	for _, tag := range tags {
+		got[tag.Key] = tag.Value
+	}
+
+	if got["track"] != "7" {
+		t.Errorf("track = %q, want %q", got["track"], "7")
+	}
+	if got["title"] != "007: Foo" {
+		t.Errorf("title = %q, want %q", got["title"], "007: Foo")
+	}
+}
+
 func TestBuildMuxerTagsSkipsEmpty(t *testing.T) {
 	tags := buildMuxerTags(Metadata{EpisodeNumber: "67", Title: "Foo"})
 
@@ -994,9 +1536,217 @@ func TestBuildMuxerTagsSkipsEmpty(t *testing.T) {
 	if got["track"] != "67" {
 		t.Errorf("track = %q, want %q", got["track"], "67")
 	}
-	for _, key := range []string{"artist", "album", "date", "comment"} {
+	for _, key := range []string{"artist", "album_artist", "album", "date", "comment"} {
 		if _, ok := got[key]; ok {
 			t.Errorf("expected %q to be skipped, got %q", key, got[key])
 		}
 	}
 }
+
+func TestBuildMuxerTagsMultipleComments(t *testing.T) {
+	tags := buildMuxerTags(Metadata{
+		EpisodeNumber: "67",
+		Title:         "Foo",
+		Comments: []string{
+			"https://linuxmatters.sh/67",
+			"Show notes",
+			"https://example.com/transcript",
+			"More notes",
+		},
+	})
+
+	got := make(map[string]string, len(tags))
+	for _, tag := range tags {
+		if _, dup := got[tag.Key]; dup {
+			t.Errorf("duplicate muxer key %q", tag.Key)
+		}
+		got[tag.Key] = tag.Value
+	}
+
+	want := map[string]string{
+		"comment":         "https://linuxmatters.sh/67",
+		"comment-Notes":   "Show notes",
+		"comment-Link":    "https://example.com/transcript",
+		"comment-Notes 2": "More notes",
+	}
+	for key, value := range want {
+		if got[key] != value {
+			t.Errorf("%s = %q, want %q", key, got[key], value)
+		}
+	}
+}
+
+func TestBuildMuxerTagsTitleFormat(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		title  string // "Foo" when empty
+		want   string
+	}{
+		{name: "default", format: "", want: "67: Foo"},
+		{name: "bare title", format: "{title}", want: "Foo"},
+		{name: "suffix", format: "{title} (Ep {num})", want: "Foo (Ep 67)"},
+		{name: "numbered title", format: "", title: "67 - Foo", want: "67 - Foo"},
+		{name: "numbered title with suffix format", format: "{title} (Ep {num})", title: "67: Foo", want: "67: Foo"},
+		{name: "numbered title bare format", format: "{title}", title: "67 - Foo", want: "67 - Foo"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			title := tt.title
+			if title == "" {
+				title = "Foo"
+			}
+			tags := buildMuxerTags(Metadata{EpisodeNumber: "67", Title: title, TitleFormat: tt.format})
+
+			got := make(map[string]string, len(tags))
+			for _, tag := range tags {
+				got[tag.Key] = tag.Value
+			}
+
+			if got["title"] != tt.want {
+				t.Errorf("title = %q, want %q", got["title"], tt.want)
+			}
+			// The template only shapes the title; track keeps the bare number.
+			if got["track"] != "67" {
+				t.Errorf("track = %q, want %q", got["track"], "67")
+			}
+		})
+	}
+}
+
+func TestTitleHasNumber(t *testing.T) {
+	tests := []struct {
+		name  string
+		title string
+		num   string
+		want  bool
+	}{
+		{name: "colon", title: "67: Foo", num: "67", want: true},
+		{name: "hyphen", title: "67 - Foo", num: "67", want: true},
+		{name: "leading zeros", title: "067. Foo", num: "67", want: true},
+		{name: "number only", title: "67", num: "67", want: true},
+		{name: "no number", title: "Foo", num: "67", want: false},
+		{name: "other number", title: "66: Foo", num: "67", want: false},
+		{name: "longer number", title: "670 Foo", num: "67", want: false},
+		{name: "ordinal", title: "67th Street", num: "67", want: false},
+		{name: "number later", title: "Foo 67", num: "67", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := titleHasNumber(tt.title, tt.num); got != tt.want {
+				t.Errorf("titleHasNumber(%q, %q) = %v, want %v", tt.title, tt.num, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildMuxerTagsExplicit(t *testing.T) {
+	find := func(tags []muxerTag) (string, bool) {
+		for _, tag := range tags {
+			if tag.Key == "ITUNESADVISORY" {
+				return tag.Value, true
+			}
+		}
+		return "", false
+	}
+
+	if value, ok := find(buildMuxerTags(Metadata{EpisodeNumber: "67", Title: "Foo", Explicit: true})); !ok || value != "1" {
+		t.Errorf("explicit episode: ITUNESADVISORY = %q (present %v), want %q", value, ok, "1")
+	}
+	if value, ok := find(buildMuxerTags(Metadata{EpisodeNumber: "67", Title: "Foo"})); ok {
+		t.Errorf("clean episode: expected no ITUNESADVISORY tag, got %q", value)
+	}
+}
+
+func TestPreferCoverVariant(t *testing.T) {
+	// writePNG writes a blank w x h PNG named name into dir.
+	writePNG := func(t *testing.T, dir, name string, w, h int) {
+		t.Helper()
+		f, err := os.Create(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		if err := png.Encode(f, image.NewGray(image.Rect(0, 0, w, h))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		variants map[string][2]int // file name -> width, height, beside cover.png at 700x700
+		want     string
+	}{
+		{name: "no variants", want: "cover.png"},
+		{name: "@2x is larger", variants: map[string][2]int{"cover@2x.png": {1400, 1400}}, want: "cover@2x.png"},
+		{name: "largest wins", variants: map[string][2]int{"cover@2x.png": {1400, 1400}, "cover-large.png": {2100, 2100}}, want: "cover-large.png"},
+		{name: "non-square skipped", variants: map[string][2]int{"cover@2x.png": {1400, 1000}}, want: "cover.png"},
+		{name: "over the maximum skipped", variants: map[string][2]int{"cover@3x.png": {3001, 3001}}, want: "cover.png"},
+		{name: "smaller skipped", variants: map[string][2]int{"cover-large.png": {500, 500}}, want: "cover.png"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writePNG(t, dir, "cover.png", 700, 700)
+			for name, size := range tt.variants {
+				writePNG(t, dir, name, size[0], size[1])
+			}
+
+			got := preferCoverVariant(filepath.Join(dir, "cover.png"))
+			if want := filepath.Join(dir, tt.want); got != want {
+				t.Errorf("preferCoverVariant() = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+// TestFrontmatterDiff verifies the diff shows exactly the lines
+// UpdateFrontmatter would change, and leaves the file untouched.
+func TestFrontmatterDiff(t *testing.T) {
+	const content = "---\ntitle: Test\npodcast_duration: \"0:00:27\"\nepisode: \"1\"\n---\n\nBody\n"
+	path := filepath.Join(t.TempDir(), "1.md")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	diff, err := FrontmatterDiff(path, "0:01:00", 1000)
+	if err != nil {
+		t.Fatalf("FrontmatterDiff() error: %v", err)
+	}
+	want := "--- " + path + "\n+++ " + path + " (updated)\n" +
+		"@@ -1,7 +1,8 @@\n ---\n title: Test\n-podcast_duration: \"0:00:27\"\n+podcast_duration: \"0:01:00\"\n episode: \"1\"\n+podcast_bytes: 1000\n ---\n \n Body\n"
+	if diff != want {
+		t.Errorf("FrontmatterDiff():\ngot  %q\nwant %q", diff, want)
+	}
+
+	if got, _ := os.ReadFile(path); string(got) != content {
+		t.Errorf("FrontmatterDiff() changed the file:\n%s", got)
+	}
+
+	if err := UpdateFrontmatter(path, "0:01:00", 1000); err != nil {
+		t.Fatal(err)
+	}
+	if diff, err := FrontmatterDiff(path, "0:01:00", 1000); err != nil || diff != "" {
+		t.Errorf("FrontmatterDiff() after the update = %q, %v; want no diff", diff, err)
+	}
+
+	// Only the frontmatter and its context are diffed, with the hunk still
+	// numbered by its place in the file.
+	long := "---\ntitle: Test\nepisode: \"1\"\ndate: 2024-01-01\nauthor: Martin\nepisode_image: cover.png\npodcast_bytes: 1\n---\n\n" +
+		strings.Repeat("Transcript line.\n", 50_000)
+	if err := os.WriteFile(path, []byte(long), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	diff, err = FrontmatterDiff(path, "0:01:00", 1000)
+	if err != nil {
+		t.Fatalf("FrontmatterDiff() error: %v", err)
+	}
+	want = "--- " + path + "\n+++ " + path + " (updated)\n" +
+		"@@ -4,7 +4,8 @@\n date: 2024-01-01\n author: Martin\n episode_image: cover.png\n-podcast_bytes: 1\n+podcast_bytes: 1000\n+podcast_duration: \"0:01:00\"\n ---\n \n Transcript line.\n"
+	if diff != want {
+		t.Errorf("FrontmatterDiff() with a long body:\ngot  %q\nwant %q", diff, want)
+	}
+}
@@ -0,0 +1,118 @@
+package encoder
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"image/color"
+	"image/png"
+)
+
+// pictureBlockKey is the Vorbis comment that carries embedded cover art in
+// Ogg containers. Ogg has no attached-picture stream, so the cover travels as
+// a base64-encoded FLAC METADATA_BLOCK_PICTURE inside the comment header.
+const pictureBlockKey = "METADATA_BLOCK_PICTURE"
+
+// PictureType is the picture type of an embedded image, numbered as in the
+// ID3v2 APIC frame and the FLAC picture block.
+type PictureType int
+
+// The picture types jivedrop embeds.
+const (
+	PictureFrontCover PictureType = 3
+	PictureBackCover  PictureType = 4
+	PictureBandLogo   PictureType = 19
+)
+
+// pictureTypeNames are FFmpeg's names for the picture types. The mp3 muxer
+// matches an attached-picture stream's "comment" metadata against these to
+// pick the APIC picture type, and falls back to "Other" without one.
+var pictureTypeNames = map[PictureType]string{
+	PictureFrontCover: "Cover (front)",
+	PictureBackCover:  "Cover (back)",
+	PictureBandLogo:   "Band/artist logotype",
+}
+
+// Picture is an image embedded in the output alongside the audio.
+type Picture struct {
+	Type        PictureType
+	Description string // APIC and picture block description; empty leaves it blank
+	Data        []byte // PNG bytes, as produced by id3.ScaleCoverArt
+}
+
+// pictureStreamTags returns the attached-picture stream metadata for pic. The
+// mp3 muxer picks the APIC picture type from "comment" and writes "title" as
+// the APIC description.
+func pictureStreamTags(pic Picture) []muxerTag {
+	tags := []muxerTag{{Key: "comment", Value: pictureTypeNames[pic.Type]}}
+	if pic.Description != "" {
+		tags = append(tags, muxerTag{Key: "title", Value: pic.Description})
+	}
+	return tags
+}
+
+// flacPictureBlock builds a FLAC METADATA_BLOCK_PICTURE body for a PNG image:
+// picture type, MIME type, description, dimensions, colour depth, palette
+// size, then the image data. Every integer is a big-endian uint32 and every
+// string is length-prefixed, as the FLAC format specifies.
+func flacPictureBlock(pic Picture) ([]byte, error) {
+	pngData := pic.Data
+	cfg, err := png.DecodeConfig(bytes.NewReader(pngData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cover dimensions: %w", err)
+	}
+
+	depth, colours := pictureDepth(cfg.ColorModel)
+	const mime = "image/png"
+
+	var b bytes.Buffer
+	for _, v := range []uint32{uint32(pic.Type), uint32(len(mime))} {
+		_ = binary.Write(&b, binary.BigEndian, v)
+	}
+	b.WriteString(mime)
+	_ = binary.Write(&b, binary.BigEndian, uint32(len(pic.Description)))
+	b.WriteString(pic.Description)
+	for _, v := range []uint32{
+		uint32(cfg.Width),
+		uint32(cfg.Height),
+		depth,
+		colours,
+		uint32(len(pngData)),
+	} {
+		_ = binary.Write(&b, binary.BigEndian, v)
+	}
+	b.Write(pngData)
+
+	return b.Bytes(), nil
+}
+
+// pictureDepth returns the bits per pixel and, for indexed images, the palette
+// size that the picture block records for a PNG colour model.
+func pictureDepth(model color.Model) (depth, colours uint32) {
+	if palette, ok := model.(color.Palette); ok {
+		return 8, uint32(len(palette))
+	}
+
+	switch model {
+	case color.GrayModel:
+		return 8, 0
+	case color.Gray16Model:
+		return 16, 0
+	case color.RGBA64Model, color.NRGBA64Model:
+		return 64, 0
+	case color.RGBAModel, color.NRGBAModel:
+		return 32, 0
+	default:
+		return 24, 0
+	}
+}
+
+// pictureBlockTag returns the muxer tag embedding pic in an Ogg container.
+func pictureBlockTag(pic Picture) (muxerTag, error) {
+	block, err := flacPictureBlock(pic)
+	if err != nil {
+		return muxerTag{}, err
+	}
+	return muxerTag{Key: pictureBlockKey, Value: base64.StdEncoding.EncodeToString(block)}, nil
+}
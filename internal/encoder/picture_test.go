@@ -0,0 +1,129 @@
+package encoder
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/png"
+	"slices"
+	"testing"
+)
+
+// TestFlacPictureBlock decodes the block field by field and checks it matches
+// the FLAC METADATA_BLOCK_PICTURE layout for a small RGBA PNG.
+func TestFlacPictureBlock(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 3, 2))
+	img.Set(1, 1, color.NRGBA{R: 255, A: 128})
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("failed to encode fixture PNG: %v", err)
+	}
+	pngData := buf.Bytes()
+
+	block, err := flacPictureBlock(Picture{Type: PictureBackCover, Description: "Episode 67 artwork", Data: pngData})
+	if err != nil {
+		t.Fatalf("flacPictureBlock: %v", err)
+	}
+
+	r := bytes.NewReader(block)
+	readU32 := func(field string) uint32 {
+		t.Helper()
+		var v uint32
+		if err := binary.Read(r, binary.BigEndian, &v); err != nil {
+			t.Fatalf("failed to read %s: %v", field, err)
+		}
+		return v
+	}
+	readBytes := func(field string, n uint32) []byte {
+		t.Helper()
+		b := make([]byte, n)
+		if _, err := r.Read(b); err != nil && n > 0 {
+			t.Fatalf("failed to read %s: %v", field, err)
+		}
+		return b
+	}
+
+	if got := readU32("picture type"); got != uint32(PictureBackCover) {
+		t.Errorf("picture type = %d, want %d", got, PictureBackCover)
+	}
+	if got := string(readBytes("mime", readU32("mime length"))); got != "image/png" {
+		t.Errorf("mime = %q, want image/png", got)
+	}
+	if got := string(readBytes("description", readU32("description length"))); got != "Episode 67 artwork" {
+		t.Errorf("description = %q, want %q", got, "Episode 67 artwork")
+	}
+	if w, h := readU32("width"), readU32("height"); w != 3 || h != 2 {
+		t.Errorf("dimensions = %dx%d, want 3x2", w, h)
+	}
+	if got := readU32("depth"); got != 32 {
+		t.Errorf("depth = %d, want 32", got)
+	}
+	if got := readU32("colours"); got != 0 {
+		t.Errorf("colours = %d, want 0", got)
+	}
+	if got := readBytes("data", readU32("data length")); !bytes.Equal(got, pngData) {
+		t.Error("picture data does not match the input PNG")
+	}
+	if r.Len() != 0 {
+		t.Errorf("%d trailing bytes after picture data", r.Len())
+	}
+}
+
+// TestPictureBlockTag verifies the tag key and that the value is the base64
+// encoding of the picture block, and that a non-PNG cover is rejected.
+func TestPictureBlockTag(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatalf("failed to encode fixture PNG: %v", err)
+	}
+
+	tag, err := pictureBlockTag(Picture{Type: PictureFrontCover, Data: buf.Bytes()})
+	if err != nil {
+		t.Fatalf("pictureBlockTag: %v", err)
+	}
+	if tag.Key != pictureBlockKey {
+		t.Errorf("Key = %q, want %q", tag.Key, pictureBlockKey)
+	}
+	want, _ := flacPictureBlock(Picture{Type: PictureFrontCover, Data: buf.Bytes()})
+	if tag.Value != base64.StdEncoding.EncodeToString(want) {
+		t.Error("Value is not the base64-encoded picture block")
+	}
+
+	if _, err := pictureBlockTag(Picture{Type: PictureFrontCover, Data: []byte("not a png")}); err == nil {
+		t.Error("pictureBlockTag on non-PNG data: expected error, got nil")
+	}
+}
+
+// TestPictureStreamTags verifies the picture type name is always set and the
+// description only when one is given.
+func TestPictureStreamTags(t *testing.T) {
+	tests := []struct {
+		name string
+		pic  Picture
+		want []muxerTag
+	}{
+		{
+			name: "no description",
+			pic:  Picture{Type: PictureFrontCover},
+			want: []muxerTag{{Key: "comment", Value: "Cover (front)"}},
+		},
+		{
+			name: "with description",
+			pic:  Picture{Type: PictureBackCover, Description: "Episode 67 artwork"},
+			want: []muxerTag{
+				{Key: "comment", Value: "Cover (back)"},
+				{Key: "title", Value: "Episode 67 artwork"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pictureStreamTags(tt.pic); !slices.Equal(got, tt.want) {
+				t.Errorf("pictureStreamTags() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
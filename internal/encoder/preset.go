@@ -1,12 +1,15 @@
 package encoder
 
 import (
+	"maps"
+	"slices"
+
 	"github.com/linuxmatters/ffmpeg-statigo"
 )
 
 // formatPreset describes how a single output format is encoded and muxed. The
 // table below is the single source of truth for codec, bitrate, sample format,
-// muxer, extension, lowpass policy, and cover capability, so the encoder reads
+// muxer, extension, MIME type, lowpass policy, and cover capability, so the encoder reads
 // the preset rather than branching on the format name.
 type formatPreset struct {
 	// name is the lowercase format identifier (mp3, aac, opus).
@@ -16,6 +19,9 @@ type formatPreset struct {
 	// encoderName, when set, names a specific encoder to try before falling
 	// back to the codec ID (e.g. libopus).
 	encoderName string
+	// library names the FFmpeg encoder that must be compiled in, for
+	// capability errors (e.g. libmp3lame).
+	library string
 	// monoBitrate and stereoBitrate are the constant bitrates in bits per
 	// second for each channel mode.
 	monoBitrate   int
@@ -28,35 +34,49 @@ type formatPreset struct {
 	// sampleRate is the output sample rate in Hz. MP3 and AAC use 44.1 kHz;
 	// libopus rejects 44.1 kHz at open, so Opus uses 48 kHz.
 	sampleRate int
+	// lowerRates are the sample rates below sampleRate the encoder accepts,
+	// for Config.MatchInputRate.
+	lowerRates []int
 	// muxer is the output format name for AVFormatAllocOutputContext2.
 	muxer string
 	// extension is the output file extension including the leading dot.
 	extension string
+	// mimeType is the media type for the file, as used in RSS enclosures.
+	mimeType string
 	// lowpassHz is the lowpass cutoff in Hz, or 0 for no lowpass.
 	lowpassHz int
 	// coverCapable reports whether the format embeds an attached-picture cover.
 	coverCapable bool
+	// pictureTag embeds the cover as a METADATA_BLOCK_PICTURE Vorbis comment
+	// instead, for Ogg containers that have no attached-picture stream.
+	pictureTag bool
 	// encoderOpts are extra encoder options passed via AVDictionary.
 	encoderOpts map[string]string
 }
 
-// formatPresets maps each supported format name to its preset. MP3 and AAC use
-// 44.1 kHz; Opus uses 48 kHz (libopus rejects 44.1 kHz at open). MP3 is CBR with
-// a 20.5 kHz lowpass and LAME compression level 3; AAC-LC is CBR with no lowpass;
-// Opus is VBR with no lowpass.
+// formatPresets maps each supported format name to its preset. MP3, AAC and
+// M4B use 44.1 kHz; Opus uses 48 kHz (libopus rejects 44.1 kHz at open). MP3 is
+// CBR with a 20.5 kHz lowpass and LAME compression level 3; AAC-LC is CBR with
+// no lowpass. M4B is the same AAC-LC in an audiobook-style .m4b container that
+// listening apps treat as bookmarkable, usually paired with chapters. Opus is
+// VBR with no lowpass and carries its cover as a picture-block comment.
 var formatPresets = map[string]formatPreset{
 	"mp3": {
 		name:          "mp3",
 		codecID:       ffmpeg.AVCodecIdMp3,
+		library:       "libmp3lame",
 		monoBitrate:   MonoBitrate,
 		stereoBitrate: StereoBitrate,
 		vbr:           false,
 		sampleFmt:     ffmpeg.AVSampleFmtS16P,
 		sampleRate:    44100,
+		lowerRates:    []int{8000, 11025, 12000, 16000, 22050, 24000, 32000},
 		muxer:         "mp3",
 		extension:     ".mp3",
+		mimeType:      "audio/mpeg",
 		lowpassHz:     20500,
 		coverCapable:  true,
+		pictureTag:    false,
 		encoderOpts: map[string]string{
 			"compression_level": "3",
 			"cutoff":            "20500",
@@ -65,30 +85,56 @@ var formatPresets = map[string]formatPreset{
 	"aac": {
 		name:          "aac",
 		codecID:       ffmpeg.AVCodecIdAac,
+		library:       "aac",
 		monoBitrate:   64000,
 		stereoBitrate: 128000,
 		vbr:           false,
 		sampleFmt:     ffmpeg.AVSampleFmtFltp,
 		sampleRate:    44100,
+		lowerRates:    aacLowerRates,
 		muxer:         "ipod",
 		extension:     ".m4a",
+		mimeType:      "audio/x-m4a",
+		lowpassHz:     0,
+		coverCapable:  true,
+		pictureTag:    false,
+		encoderOpts:   nil,
+	},
+	"m4b": {
+		name:          "m4b",
+		codecID:       ffmpeg.AVCodecIdAac,
+		library:       "aac",
+		monoBitrate:   64000,
+		stereoBitrate: 128000,
+		vbr:           false,
+		sampleFmt:     ffmpeg.AVSampleFmtFltp,
+		sampleRate:    44100,
+		lowerRates:    aacLowerRates,
+		muxer:         "ipod",
+		extension:     ".m4b",
+		mimeType:      "audio/x-m4b",
 		lowpassHz:     0,
 		coverCapable:  true,
+		pictureTag:    false,
 		encoderOpts:   nil,
 	},
 	"opus": {
 		name:          "opus",
 		codecID:       ffmpeg.AVCodecIdOpus,
 		encoderName:   "libopus",
+		library:       "libopus",
 		monoBitrate:   32000,
 		stereoBitrate: 48000,
 		vbr:           true,
 		sampleFmt:     ffmpeg.AVSampleFmtFlt,
 		sampleRate:    48000,
+		lowerRates:    []int{8000, 12000, 16000, 24000},
 		muxer:         "opus",
 		extension:     ".opus",
+		mimeType:      "audio/ogg",
 		lowpassHz:     0,
 		coverCapable:  false,
+		pictureTag:    true,
 		encoderOpts: map[string]string{
 			"vbr":               "on",
 			"compression_level": "10",
@@ -96,19 +142,77 @@ var formatPresets = map[string]formatPreset{
 	},
 }
 
-// presetFor resolves a format name to its preset. The second return value is
-// false when the name is unknown.
+// aacLowerRates are the AAC sample rates below 44.1 kHz.
+var aacLowerRates = []int{8000, 11025, 12000, 16000, 22050, 24000, 32000}
+
+// atInputRate returns the preset adjusted to encode at inputRate, for
+// Config.MatchInputRate, and whether it was. Only a rate below the preset's
+// that the encoder accepts is used; anything else keeps the preset. MPEG audio
+// caps the bitrate at lower rates, so MP3 bitrates are clamped to fit, and the
+// fixed MP3 lowpass is dropped for LAME to choose one below the new Nyquist
+// frequency.
+func (p formatPreset) atInputRate(inputRate int) (formatPreset, bool) {
+	if inputRate >= p.sampleRate || !slices.Contains(p.lowerRates, inputRate) {
+		return p, false
+	}
+	p.sampleRate = inputRate
+	if p.name == "mp3" {
+		limit := mp3MaxBitrate(inputRate)
+		p.monoBitrate = min(p.monoBitrate, limit)
+		p.stereoBitrate = min(p.stereoBitrate, limit)
+		p.lowpassHz = 0
+		p.encoderOpts = maps.Clone(p.encoderOpts)
+		delete(p.encoderOpts, "cutoff")
+	}
+	return p, true
+}
+
+// mp3MaxBitrate is the highest bitrate MPEG audio allows at a sample rate, in
+// bits per second: 320kbps for MPEG-1 (32 kHz and up), 160kbps for MPEG-2
+// (16 to 24 kHz) and 64kbps for MPEG-2.5 (below 16 kHz).
+func mp3MaxBitrate(sampleRate int) int {
+	switch {
+	case sampleRate >= 32000:
+		return 320000
+	case sampleRate >= 16000:
+		return 160000
+	default:
+		return 64000
+	}
+}
+
+// formatAliases maps container names accepted on the command line to the
+// preset they select, so --format m4a means the same as --format aac.
+var formatAliases = map[string]string{
+	"m4a": "aac",
+}
+
+// presetFor resolves a format name or container alias to its preset. The
+// second return value is false when the name is unknown.
 func presetFor(name string) (formatPreset, bool) {
+	if canonical, ok := formatAliases[name]; ok {
+		name = canonical
+	}
 	preset, ok := formatPresets[name]
 	return preset, ok
 }
 
 // ExtensionFor returns the output file extension (including the leading dot)
-// for the given format name. Unknown formats return an empty string.
+// for the given format name or alias. Unknown formats return an empty string.
 func ExtensionFor(format string) string {
-	preset, ok := formatPresets[format]
+	preset, ok := presetFor(format)
 	if !ok {
 		return ""
 	}
 	return preset.extension
 }
+
+// MIMETypeFor returns the media type of the output file for the given format
+// name or alias, for RSS enclosures. Unknown formats return an empty string.
+func MIMETypeFor(format string) string {
+	preset, ok := presetFor(format)
+	if !ok {
+		return ""
+	}
+	return preset.mimeType
+}
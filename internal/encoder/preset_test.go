@@ -14,6 +14,7 @@ func TestFormatPreset(t *testing.T) {
 		stereoBitrate int
 		sampleFmt     ffmpeg.AVSampleFormat
 		extension     string
+		mimeType      string
 		lowpassHz     int
 		coverCapable  bool
 	}{
@@ -24,6 +25,7 @@ func TestFormatPreset(t *testing.T) {
 			stereoBitrate: 192000,
 			sampleFmt:     ffmpeg.AVSampleFmtS16P,
 			extension:     ".mp3",
+			mimeType:      "audio/mpeg",
 			lowpassHz:     20500,
 			coverCapable:  true,
 		},
@@ -34,6 +36,18 @@ func TestFormatPreset(t *testing.T) {
 			stereoBitrate: 128000,
 			sampleFmt:     ffmpeg.AVSampleFmtFltp,
 			extension:     ".m4a",
+			mimeType:      "audio/x-m4a",
+			lowpassHz:     0,
+			coverCapable:  true,
+		},
+		{
+			name:          "m4b",
+			codecID:       ffmpeg.AVCodecIdAac,
+			monoBitrate:   64000,
+			stereoBitrate: 128000,
+			sampleFmt:     ffmpeg.AVSampleFmtFltp,
+			extension:     ".m4b",
+			mimeType:      "audio/x-m4b",
 			lowpassHz:     0,
 			coverCapable:  true,
 		},
@@ -44,6 +58,7 @@ func TestFormatPreset(t *testing.T) {
 			stereoBitrate: 48000,
 			sampleFmt:     ffmpeg.AVSampleFmtFlt,
 			extension:     ".opus",
+			mimeType:      "audio/ogg",
 			lowpassHz:     0,
 			coverCapable:  false,
 		},
@@ -70,6 +85,9 @@ func TestFormatPreset(t *testing.T) {
 			if preset.extension != tt.extension {
 				t.Errorf("extension = %q, want %q", preset.extension, tt.extension)
 			}
+			if got := MIMETypeFor(tt.name); got != tt.mimeType {
+				t.Errorf("MIMETypeFor = %q, want %q", got, tt.mimeType)
+			}
 			if preset.lowpassHz != tt.lowpassHz {
 				t.Errorf("lowpassHz = %d, want %d", preset.lowpassHz, tt.lowpassHz)
 			}
@@ -83,3 +101,72 @@ func TestFormatPreset(t *testing.T) {
 		t.Error("presetFor(\"flac\") returned found, want not-found")
 	}
 }
+
+// TestFormatAliases verifies that container aliases resolve to their preset
+// and its extension, so the filename always follows the chosen format.
+func TestFormatAliases(t *testing.T) {
+	preset, ok := presetFor("m4a")
+	if !ok || preset.name != "aac" {
+		t.Fatalf("presetFor(\"m4a\") = %q, %v, want aac preset", preset.name, ok)
+	}
+
+	tests := map[string]string{
+		"mp3":  ".mp3",
+		"aac":  ".m4a",
+		"m4a":  ".m4a",
+		"m4b":  ".m4b",
+		"opus": ".opus",
+		"flac": "",
+	}
+	for format, want := range tests {
+		if got := ExtensionFor(format); got != want {
+			t.Errorf("ExtensionFor(%q) = %q, want %q", format, got, want)
+		}
+	}
+	if got := MIMETypeFor("m4a"); got != "audio/x-m4a" {
+		t.Errorf("MIMETypeFor(\"m4a\") = %q, want audio/x-m4a", got)
+	}
+}
+
+// TestAtInputRate verifies --match-input-rate lowers the rate only to one the
+// codec supports, capping MP3 bitrates to what the lower rate allows.
+func TestAtInputRate(t *testing.T) {
+	tests := []struct {
+		format        string
+		inputRate     int
+		wantOK        bool
+		sampleRate    int
+		monoBitrate   int
+		stereoBitrate int
+	}{
+		{"mp3", 22050, true, 22050, 112000, 160000},
+		{"mp3", 11025, true, 11025, 64000, 64000},
+		{"mp3", 32000, true, 32000, 112000, 192000},
+		{"mp3", 48000, false, 44100, 112000, 192000},
+		{"mp3", 44100, false, 44100, 112000, 192000},
+		{"aac", 24000, true, 24000, 64000, 128000},
+		{"opus", 16000, true, 16000, 32000, 48000},
+		{"opus", 22050, false, 48000, 32000, 48000},
+	}
+
+	for _, tt := range tests {
+		preset, _ := presetFor(tt.format)
+		got, ok := preset.atInputRate(tt.inputRate)
+		if ok != tt.wantOK || got.sampleRate != tt.sampleRate || got.monoBitrate != tt.monoBitrate || got.stereoBitrate != tt.stereoBitrate {
+			t.Errorf("%s at %dHz = %dHz %d/%d, %v; want %dHz %d/%d, %v", tt.format, tt.inputRate,
+				got.sampleRate, got.monoBitrate, got.stereoBitrate, ok,
+				tt.sampleRate, tt.monoBitrate, tt.stereoBitrate, tt.wantOK)
+		}
+		if ok && tt.format == "mp3" {
+			if _, cutoff := got.encoderOpts["cutoff"]; cutoff || got.lowpassHz != 0 {
+				t.Errorf("mp3 at %dHz keeps the 20.5kHz lowpass", tt.inputRate)
+			}
+		}
+	}
+
+	mp3, _ := presetFor("mp3")
+	mp3.atInputRate(22050)
+	if _, cutoff := mp3.encoderOpts["cutoff"]; !cutoff {
+		t.Error("atInputRate() changed the shared mp3 preset's options")
+	}
+}
@@ -0,0 +1,114 @@
+package encoder
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/linuxmatters/ffmpeg-statigo"
+)
+
+// readMetadata copies an FFmpeg metadata dictionary into a map. Keys are
+// lowercased so callers need not care whether the container stored "TITLE"
+// (Vorbis comments) or "title" (FFmpeg's generic mapping of RIFF INFO).
+func readMetadata(dict *ffmpeg.AVDictionary) map[string]string {
+	tags := make(map[string]string)
+
+	empty := ffmpeg.ToCStr("")
+	defer empty.Free()
+
+	var entry *ffmpeg.AVDictionaryEntry
+	for {
+		entry = ffmpeg.AVDictGet(dict, empty, entry, ffmpeg.AVDictIgnoreSuffix)
+		if entry == nil {
+			break
+		}
+		tags[strings.ToLower(entry.Key().String())] = entry.Value().String()
+	}
+
+	return tags
+}
+
+// ProbeInputMetadata opens path just long enough to read its container-level
+// tags (e.g. FLAC Vorbis comments or a WAV INFO chunk), so they can seed
+// metadata defaults before the encoder is configured.
+func ProbeInputMetadata(path string) (map[string]string, error) {
+	urlPtr := ffmpeg.ToCStr(path)
+	defer urlPtr.Free()
+
+	var ifmtCtx *ffmpeg.AVFormatContext
+	if _, err := ffmpeg.AVFormatOpenInput(&ifmtCtx, urlPtr, nil, nil); err != nil {
+		return nil, fmt.Errorf("cannot open input file: %w", err)
+	}
+	defer ffmpeg.AVFormatCloseInput(&ifmtCtx)
+
+	return readMetadata(ifmtCtx.Metadata()), nil
+}
+
+// InputMetadata returns the container-level tags read from the input during
+// Initialize, with lowercased keys. It is empty before Initialize.
+func (e *Encoder) InputMetadata() map[string]string {
+	return e.inputMetadata
+}
+
+// InputInfo describes an input file and the audio track that would be
+// encoded from it.
+type InputInfo struct {
+	Container  string // demuxer name, e.g. "flac" or "wav"
+	Codec      string
+	SampleRate int
+	Channels   int
+	BitDepth   int    // bits per sample; 0 when the codec has none, as for MP3
+	SampleFmt  string // decoded sample format, e.g. "s16", "s32" or "fltp"
+	Duration   time.Duration
+	Tracks     []AudioTrack
+	Metadata   map[string]string // container-level tags, lowercased keys
+}
+
+// DurationHMS formats the duration as HH:MM:SS, rounded to the second.
+func (i InputInfo) DurationHMS() string {
+	return formatDurationHMS(int64(i.Duration.Round(time.Second).Seconds()))
+}
+
+// ProbeInput opens path the way Initialize does, selecting the same audio
+// track (0 for the default), and describes it without creating any output.
+func ProbeInput(path string, audioTrack int) (InputInfo, error) {
+	e := &Encoder{inputPath: path, audioTrack: audioTrack, streamIndex: -1, outStreamIndex: -1}
+	defer e.Close()
+
+	if err := e.configureLogging(); err != nil {
+		return InputInfo{}, fmt.Errorf("failed to open debug log: %w", err)
+	}
+	if err := e.openInput(); err != nil {
+		return InputInfo{}, fmt.Errorf("failed to open input: %w", err)
+	}
+	return e.InputInfo(), nil
+}
+
+// InputInfo describes the input opened by Initialize or ProbeInput. It is
+// the zero value before the input is opened.
+func (e *Encoder) InputInfo() InputInfo {
+	if e.ifmtCtx == nil || e.decCtx == nil {
+		return InputInfo{}
+	}
+
+	sampleRate, channels, codec := e.GetInputInfo()
+	info := InputInfo{
+		Container:  e.ifmtCtx.Iformat().Name().String(),
+		Codec:      codec,
+		SampleRate: sampleRate,
+		Channels:   channels,
+		BitDepth:   e.decCtx.BitsPerRawSample(),
+		SampleFmt:  ffmpeg.AVGetSampleFmtName(e.decCtx.SampleFmt()).String(),
+		Duration:   e.inputDuration(),
+		Tracks:     e.audioTracks,
+		Metadata:   e.inputMetadata,
+	}
+	// PCM decoders leave the raw sample size unset; the stream records how
+	// many bits each sample was stored with.
+	if info.BitDepth == 0 {
+		stream := e.ifmtCtx.Streams().Get(uintptr(e.streamIndex)) //nolint:gosec // streamIndex is validated in openInput
+		info.BitDepth = stream.Codecpar().BitsPerCodedSample()
+	}
+	return info
+}
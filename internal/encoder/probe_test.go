@@ -0,0 +1,36 @@
+package encoder
+
+import (
+	"os"
+	"testing"
+)
+
+// TestProbeInput_Integration probes the FLAC fixture and checks the stream
+// details come back without an output file being needed.
+func TestProbeInput_Integration(t *testing.T) {
+	inputPath := "../../testdata/LMP0.flac"
+	if _, err := os.Stat(inputPath); os.IsNotExist(err) {
+		t.Skipf("Test file not found: %s", inputPath)
+	}
+
+	info, err := ProbeInput(inputPath, 0)
+	if err != nil {
+		t.Fatalf("ProbeInput failed: %v", err)
+	}
+
+	if info.Container != "flac" || info.Codec != "flac" {
+		t.Errorf("container/codec = %s/%s, want flac/flac", info.Container, info.Codec)
+	}
+	if info.SampleRate <= 0 || info.Channels <= 0 || info.BitDepth <= 0 {
+		t.Errorf("sample rate %d, channels %d, bit depth %d: want all positive", info.SampleRate, info.Channels, info.BitDepth)
+	}
+	if info.SampleFmt == "" {
+		t.Error("SampleFmt is empty, want the decoder's sample format")
+	}
+	if info.Duration <= 0 {
+		t.Errorf("Duration = %v, want positive", info.Duration)
+	}
+	if len(info.Tracks) != 1 {
+		t.Errorf("Tracks = %d, want 1", len(info.Tracks))
+	}
+}
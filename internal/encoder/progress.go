@@ -0,0 +1,48 @@
+package encoder
+
+import "time"
+
+// progressThrottle coalesces progress callbacks. A call is passed through only
+// once every configured threshold has been met since the last one passed: at
+// least interval has elapsed and the progress has advanced by at least step
+// percentage points. Zero thresholds are ignored, so the zero value passes
+// every call through.
+type progressThrottle struct {
+	interval time.Duration
+	step     float64
+	now      func() time.Time
+
+	lastTime    time.Time
+	lastPercent float64
+	emitted     bool // at least one call has passed through
+	pending     bool // a call was suppressed since the last one passed
+}
+
+// newProgressThrottle returns a throttle using the wall clock.
+func newProgressThrottle(interval time.Duration, step float64) *progressThrottle {
+	return &progressThrottle{interval: interval, step: step, now: time.Now}
+}
+
+// allow reports whether a callback for the given progress should fire now,
+// recording it as the last emitted call when it does.
+func (p *progressThrottle) allow(samplesProcessed, totalSamples int64) bool {
+	percent := float64(samplesProcessed) / float64(totalSamples) * 100
+	now := p.now()
+
+	if p.emitted {
+		if p.interval > 0 && now.Sub(p.lastTime) < p.interval {
+			p.pending = true
+			return false
+		}
+		if p.step > 0 && percent-p.lastPercent < p.step {
+			p.pending = true
+			return false
+		}
+	}
+
+	p.emitted = true
+	p.pending = false
+	p.lastTime = now
+	p.lastPercent = percent
+	return true
+}
@@ -0,0 +1,95 @@
+package encoder
+
+import (
+	"testing"
+	"time"
+)
+
+func TestProgressThrottle(t *testing.T) {
+	type step struct {
+		advance time.Duration
+		samples int64
+		want    bool
+	}
+
+	tests := []struct {
+		name     string
+		interval time.Duration
+		percent  float64
+		steps    []step
+	}{
+		{
+			name: "zero value passes every call",
+			steps: []step{
+				{0, 1, true},
+				{0, 2, true},
+				{0, 3, true},
+			},
+		},
+		{
+			name:     "interval limits call rate",
+			interval: 100 * time.Millisecond,
+			steps: []step{
+				{0, 1, true},
+				{50 * time.Millisecond, 2, false},
+				{50 * time.Millisecond, 3, true},
+				{10 * time.Millisecond, 4, false},
+			},
+		},
+		{
+			name:    "step requires percentage advance",
+			percent: 5,
+			steps: []step{
+				{0, 10, true},
+				{0, 40, false},
+				{0, 60, true},
+				{0, 109, false},
+			},
+		},
+		{
+			name:     "both limits must be met",
+			interval: 100 * time.Millisecond,
+			percent:  5,
+			steps: []step{
+				{0, 0, true},
+				{200 * time.Millisecond, 10, false},
+				{0, 100, true},
+				{10 * time.Millisecond, 200, false},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := time.Unix(0, 0)
+			throttle := &progressThrottle{
+				interval: tt.interval,
+				step:     tt.percent,
+				now:      func() time.Time { return clock },
+			}
+
+			for i, s := range tt.steps {
+				clock = clock.Add(s.advance)
+				// totalSamples of 1000 makes each 10 samples one percent.
+				if got := throttle.allow(s.samples, 1000); got != s.want {
+					t.Errorf("step %d: allow(%d) = %v, want %v", i, s.samples, got, s.want)
+				}
+			}
+		})
+	}
+}
+
+// TestProgressThrottlePending verifies that a suppressed call is recorded as
+// pending and cleared once a call passes through.
+func TestProgressThrottlePending(t *testing.T) {
+	throttle := newProgressThrottle(time.Hour, 0)
+
+	throttle.allow(1, 100)
+	if throttle.pending {
+		t.Error("pending after first call, want false")
+	}
+	throttle.allow(2, 100)
+	if !throttle.pending {
+		t.Error("pending after suppressed call = false, want true")
+	}
+}
@@ -0,0 +1,225 @@
+package encoder
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/linuxmatters/ffmpeg-statigo"
+)
+
+// ErrNotMP3 is returned by CheckMP3 and Retag when the input is not an MP3
+// file, which is the only format re-tagging can copy without re-encoding.
+var ErrNotMP3 = errors.New("not an MP3 file")
+
+// CheckMP3 opens path and reports whether it is an MP3 file with an MP3 audio
+// stream, so a re-tag can be refused before any metadata work.
+func CheckMP3(path string) error {
+	urlPtr := ffmpeg.ToCStr(path)
+	defer urlPtr.Free()
+
+	var ifmtCtx *ffmpeg.AVFormatContext
+	if _, err := ffmpeg.AVFormatOpenInput(&ifmtCtx, urlPtr, nil, nil); err != nil {
+		return fmt.Errorf("cannot open input file: %w", err)
+	}
+	defer ffmpeg.AVFormatCloseInput(&ifmtCtx)
+
+	if _, err := ffmpeg.AVFormatFindStreamInfo(ifmtCtx, nil); err != nil {
+		return fmt.Errorf("cannot find stream information: %w", err)
+	}
+
+	_, err := mp3StreamIndex(ifmtCtx)
+	return err
+}
+
+// mp3StreamIndex returns the index of the MP3 audio stream in an opened
+// input, or an error wrapping ErrNotMP3 when the container or codec is not MP3.
+func mp3StreamIndex(ifmtCtx *ffmpeg.AVFormatContext) (int, error) {
+	if name := ifmtCtx.Iformat().Name().String(); name != "mp3" {
+		return -1, fmt.Errorf("%w (detected %s)", ErrNotMP3, name)
+	}
+
+	streamIdx, err := ffmpeg.AVFindBestStream(ifmtCtx, ffmpeg.AVMediaTypeAudio, -1, -1, nil, 0)
+	if err != nil {
+		return -1, fmt.Errorf("cannot find audio stream: %w", err)
+	}
+
+	stream := ifmtCtx.Streams().Get(uintptr(streamIdx)) //nolint:gosec // streamIdx is validated by AVFindBestStream
+	if stream.Codecpar().CodecId() != ffmpeg.AVCodecIdMp3 {
+		return -1, fmt.Errorf("%w (audio is not MP3 encoded)", ErrNotMP3)
+	}
+	return streamIdx, nil
+}
+
+// Retag writes the MP3 audio of the input to the output unchanged, packet for
+// packet, with the configured tags, pictures and chapters in place of the
+// input's own. It is used instead of Initialize and Encode when the audio is
+// already encoded and only the metadata needs fixing. The input and output
+// must be different files.
+func (e *Encoder) Retag() (EncodeResult, error) {
+	if e.preset.name != "mp3" {
+		return EncodeResult{}, fmt.Errorf("re-tagging supports MP3 output only, not %s", e.preset.name)
+	}
+
+	if err := e.configureLogging(); err != nil {
+		return EncodeResult{}, fmt.Errorf("failed to open debug log: %w", err)
+	}
+
+	if err := e.openRetagInput(); err != nil {
+		return EncodeResult{}, fmt.Errorf("failed to open input: %w", err)
+	}
+
+	if err := e.openRetagOutput(); err != nil {
+		return EncodeResult{}, fmt.Errorf("failed to open output: %w", err)
+	}
+
+	if err := e.copyAudio(); err != nil {
+		return EncodeResult{}, err
+	}
+	if err := e.writeCommentLanguage(); err != nil {
+		return EncodeResult{}, err
+	}
+	if err := e.writeTranscript(); err != nil {
+		return EncodeResult{}, err
+	}
+
+	info, err := os.Stat(e.outputPath)
+	if err != nil {
+		return EncodeResult{}, fmt.Errorf("failed to stat re-tagged file: %w", err)
+	}
+
+	return EncodeResult{
+		OutputPath:   e.outputPath,
+		DurationSecs: int64(e.inputDuration().Round(time.Second).Seconds()),
+		BytesWritten: info.Size(),
+	}, nil
+}
+
+// openRetagInput opens the input and selects its MP3 audio stream. No decoder
+// is opened, as the packets are copied as they are.
+func (e *Encoder) openRetagInput() error {
+	urlPtr := ffmpeg.ToCStr(e.inputPath)
+	defer urlPtr.Free()
+
+	if _, err := ffmpeg.AVFormatOpenInput(&e.ifmtCtx, urlPtr, nil, nil); err != nil {
+		return fmt.Errorf("cannot open input file: %w", err)
+	}
+
+	if _, err := ffmpeg.AVFormatFindStreamInfo(e.ifmtCtx, nil); err != nil {
+		return fmt.Errorf("cannot find stream information: %w", err)
+	}
+
+	e.inputMetadata = readMetadata(e.ifmtCtx.Metadata())
+
+	streamIdx, err := mp3StreamIndex(e.ifmtCtx)
+	if err != nil {
+		return err
+	}
+	e.streamIndex = streamIdx
+	return nil
+}
+
+// openRetagOutput creates the output with one audio stream carrying the
+// input's codec parameters, then writes the header with the new metadata.
+func (e *Encoder) openRetagOutput() error {
+	namePtr := ffmpeg.ToCStr(e.outputPath)
+	defer namePtr.Free()
+
+	if _, err := ffmpeg.AVFormatAllocOutputContext2(&e.ofmtCtx, nil, nil, namePtr); err != nil {
+		return fmt.Errorf("failed to create output context: %w", err)
+	}
+
+	outStream := ffmpeg.AVFormatNewStream(e.ofmtCtx, nil)
+	if outStream == nil {
+		return fmt.Errorf("failed to create output stream")
+	}
+	e.outStreamIndex = outStream.Index()
+
+	inStream := e.ifmtCtx.Streams().Get(uintptr(e.streamIndex)) //nolint:gosec // streamIndex is validated by mp3StreamIndex
+	if _, err := ffmpeg.AVCodecParametersCopy(outStream.Codecpar(), inStream.Codecpar()); err != nil {
+		return fmt.Errorf("failed to copy codec parameters: %w", err)
+	}
+	// Let the muxer choose its own tag for the codec.
+	outStream.Codecpar().SetCodecTag(0)
+	outStream.SetTimeBase(inStream.TimeBase())
+
+	return e.writeOutputHeader()
+}
+
+// copyAudio copies every packet of the selected audio stream to the output
+// and writes the trailer. The input's own ID3 and picture data is not carried
+// over: pictures are separate streams and tags are read into the context, not
+// into the packets. With a re-tag range only the packets starting inside it
+// are copied, with timestamps moved to start from zero.
+func (e *Encoder) copyAudio() error {
+	packet := ffmpeg.AVPacketAlloc()
+	defer ffmpeg.AVPacketFree(&packet)
+
+	inStream := e.ifmtCtx.Streams().Get(uintptr(e.streamIndex))     //nolint:gosec // streamIndex is validated by mp3StreamIndex
+	outStream := e.ofmtCtx.Streams().Get(uintptr(e.outStreamIndex)) //nolint:gosec // outStreamIndex is set from AVFormatNewStream in openRetagOutput
+
+	timeBase := inStream.TimeBase()
+	offset := int64(-1) // pts of the first copied packet
+	for {
+		if e.cancelled.Load() {
+			return ErrCancelled
+		}
+
+		if _, err := ffmpeg.AVReadFrame(e.ifmtCtx, packet); err != nil {
+			if errors.Is(err, ffmpeg.AVErrorEOF) {
+				break
+			}
+			return fmt.Errorf("read frame failed: %w", err)
+		}
+
+		if packet.StreamIndex() != e.streamIndex {
+			ffmpeg.AVPacketUnref(packet)
+			continue
+		}
+
+		if e.retagStart > 0 || e.retagEnd > 0 {
+			at := time.Duration(float64(packet.Pts()) * float64(timeBase.Num()) / float64(timeBase.Den()) * float64(time.Second))
+			if at < e.retagStart {
+				ffmpeg.AVPacketUnref(packet)
+				continue
+			}
+			if e.retagEnd > 0 && at >= e.retagEnd {
+				ffmpeg.AVPacketUnref(packet)
+				break
+			}
+			if offset < 0 {
+				offset = packet.Pts()
+			}
+			packet.SetPts(packet.Pts() - offset)
+			packet.SetDts(packet.Dts() - offset)
+		}
+
+		ffmpeg.AVPacketRescaleTs(packet, inStream.TimeBase(), outStream.TimeBase())
+		packet.SetStreamIndex(e.outStreamIndex)
+		packet.SetPos(-1)
+
+		// AVInterleavedWriteFrame takes ownership of the packet's data and
+		// leaves it blank, so there is nothing to unref afterwards.
+		if _, err := ffmpeg.AVInterleavedWriteFrame(e.ofmtCtx, packet); err != nil {
+			return fmt.Errorf("failed to write packet: %w", err)
+		}
+	}
+
+	if _, err := ffmpeg.AVWriteTrailer(e.ofmtCtx); err != nil {
+		return fmt.Errorf("write trailer failed: %w", err)
+	}
+
+	return nil
+}
+
+// SourceBitrate returns the bitrate of the input audio stream in kbps, as
+// reported by the container, or 0 when unknown. After Retag it describes the
+// output too, as the audio is copied unchanged.
+func (e *Encoder) SourceBitrate() int {
+	if e.ifmtCtx == nil || e.streamIndex < 0 {
+		return 0
+	}
+	stream := e.ifmtCtx.Streams().Get(uintptr(e.streamIndex)) //nolint:gosec // streamIndex is validated when the input is opened
+	return int(stream.Codecpar().BitRate() / 1000)
+}
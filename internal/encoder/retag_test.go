@@ -0,0 +1,123 @@
+package encoder
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestRetag_Integration encodes an MP3, then re-tags it: the new tags must
+// replace the old ones while the audio keeps its duration and codec.
+func TestRetag_Integration(t *testing.T) {
+	inputPath := "../../testdata/LMP0.flac"
+	if _, err := os.Stat(inputPath); os.IsNotExist(err) {
+		t.Skipf("Test file not found: %s", inputPath)
+	}
+	if _, err := exec.LookPath("ffprobe"); err != nil {
+		t.Skip("ffprobe not available")
+	}
+
+	if err := CheckMP3(inputPath); !errors.Is(err, ErrNotMP3) {
+		t.Errorf("CheckMP3(flac) error = %v, want ErrNotMP3", err)
+	}
+
+	tmpDir := t.TempDir()
+	encodedPath := filepath.Join(tmpDir, "encoded.mp3")
+	enc, err := New(Config{
+		InputPath:  inputPath,
+		OutputPath: encodedPath,
+		Metadata:   Metadata{EpisodeNumber: "1", Title: "Wrong title"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create encoder: %v", err)
+	}
+	defer enc.Close()
+	if err := enc.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize encoder: %v", err)
+	}
+	encoded, err := enc.Encode(nil)
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+
+	if err := CheckMP3(encodedPath); err != nil {
+		t.Fatalf("CheckMP3(mp3) unexpected error: %v", err)
+	}
+
+	retaggedPath := filepath.Join(tmpDir, "retagged.mp3")
+	re, err := New(Config{
+		InputPath:  encodedPath,
+		OutputPath: retaggedPath,
+		Metadata:   Metadata{EpisodeNumber: "67", Title: "Panache, for men", Artist: "Linux Matters"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create encoder: %v", err)
+	}
+	defer re.Close()
+
+	result, err := re.Retag()
+	if err != nil {
+		t.Fatalf("Retag failed: %v", err)
+	}
+	if result.DurationSecs != encoded.DurationSecs {
+		t.Errorf("re-tagged duration = %ds, want %ds", result.DurationSecs, encoded.DurationSecs)
+	}
+
+	tags := probeFormatTags(t, retaggedPath)
+	if got, want := tags["title"], "67: Panache, for men"; got != want {
+		t.Errorf("title tag = %q, want %q", got, want)
+	}
+	if got, want := tags["artist"], "Linux Matters"; got != want {
+		t.Errorf("artist tag = %q, want %q", got, want)
+	}
+
+	streams := probeStreams(t, retaggedPath)
+	if len(streams) != 1 || streams[0].CodecType != "audio" {
+		t.Errorf("re-tagged streams = %+v, want a single audio stream", streams)
+	}
+}
+
+// TestRetagRange_Integration copies a generated MP3 out in two halves and
+// checks each half has its own share of the duration.
+func TestRetagRange_Integration(t *testing.T) {
+	if _, err := exec.LookPath("ffprobe"); err != nil {
+		t.Skip("ffprobe not available")
+	}
+
+	tmpDir := t.TempDir()
+	wavPath := filepath.Join(tmpDir, "tone.wav")
+	writeTestWAV24(t, wavPath, 44100, 4)
+
+	encodedPath := filepath.Join(tmpDir, "tone.mp3")
+	enc, err := New(Config{InputPath: wavPath, OutputPath: encodedPath})
+	if err != nil {
+		t.Fatalf("Failed to create encoder: %v", err)
+	}
+	defer enc.Close()
+	if err := enc.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize encoder: %v", err)
+	}
+	if _, err := enc.Encode(nil); err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+
+	for i, r := range [][2]time.Duration{{0, 2 * time.Second}, {2 * time.Second, 0}} {
+		partPath := filepath.Join(tmpDir, fmt.Sprintf("part%d.mp3", i+1))
+		part, err := New(Config{InputPath: encodedPath, OutputPath: partPath, RetagStart: r[0], RetagEnd: r[1]})
+		if err != nil {
+			t.Fatalf("Failed to create encoder: %v", err)
+		}
+		defer part.Close()
+
+		if _, err := part.Retag(); err != nil {
+			t.Fatalf("Retag of part %d failed: %v", i+1, err)
+		}
+		if got := probeDurationSecs(t, partPath); got < 1.9 || got > 2.1 {
+			t.Errorf("part %d duration = %.2fs, want about 2s", i+1, got)
+		}
+	}
+}
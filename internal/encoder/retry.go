@@ -0,0 +1,22 @@
+package encoder
+
+import "time"
+
+// retryBaseDelay is the wait before the first retry of an I/O operation; each
+// later retry waits twice as long as the one before.
+var retryBaseDelay = 250 * time.Millisecond
+
+// RetryIO runs op and, while it fails, runs it again up to retries more times
+// with exponential backoff. It is meant for operations on network filesystems
+// that fail transiently. It returns nil on the first success, or the last
+// error once the retries are spent; zero retries runs op once.
+func RetryIO(retries int, op func() error) error {
+	delay := retryBaseDelay
+	err := op()
+	for attempt := 0; err != nil && attempt < retries; attempt++ {
+		time.Sleep(delay)
+		delay *= 2
+		err = op()
+	}
+	return err
+}
@@ -0,0 +1,47 @@
+package encoder
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetryIO(t *testing.T) {
+	orig := retryBaseDelay
+	retryBaseDelay = time.Millisecond
+	t.Cleanup(func() { retryBaseDelay = orig })
+
+	errTransient := errors.New("transient")
+
+	tests := []struct {
+		name      string
+		retries   int
+		failures  int // calls that fail before op succeeds
+		wantCalls int
+		wantErr   bool
+	}{
+		{name: "no retries, success", retries: 0, failures: 0, wantCalls: 1},
+		{name: "no retries, failure", retries: 0, failures: 1, wantCalls: 1, wantErr: true},
+		{name: "recovers within retries", retries: 3, failures: 2, wantCalls: 3},
+		{name: "retries exhausted", retries: 2, failures: 5, wantCalls: 3, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			err := RetryIO(tt.retries, func() error {
+				calls++
+				if calls <= tt.failures {
+					return errTransient
+				}
+				return nil
+			})
+			if calls != tt.wantCalls {
+				t.Errorf("op called %d times, want %d", calls, tt.wantCalls)
+			}
+			if gotErr := errors.Is(err, errTransient); gotErr != tt.wantErr {
+				t.Errorf("RetryIO() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
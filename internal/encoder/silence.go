@@ -0,0 +1,167 @@
+package encoder
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/linuxmatters/ffmpeg-statigo"
+)
+
+// Default --auto-chapters settings: a pause is audio below -50dB for at least
+// two seconds, long enough to pass over breaths and short hesitations.
+const (
+	DefaultSilenceThreshold = -50.0
+	DefaultSilenceGap       = 2 * time.Second
+)
+
+// silenceEndKey is the frame metadata entry silencedetect sets, in seconds,
+// on the frame where a long enough silence ends.
+const silenceEndKey = "lavfi.silence_end"
+
+// minChapterLength keeps a silence at the very start of the audio from
+// adding a chapter right after the opening one.
+const minChapterLength = time.Second
+
+// DetectChapters decodes the input at path once through FFmpeg's silencedetect
+// filter and returns a chapter list with a chapter at the start and one
+// wherever the audio resumes after at least minGap below threshold dB. The
+// chapters are titled "Chapter 1", "Chapter 2" and so on, as a first pass to
+// refine in a chapter file. audioTrack picks the input track as for Config.
+func DetectChapters(path string, audioTrack int, threshold float64, minGap time.Duration) ([]Chapter, error) {
+	if threshold >= 0 {
+		return nil, fmt.Errorf("invalid silence threshold: %gdB (must be below 0dB)", threshold)
+	}
+	if minGap <= 0 {
+		return nil, fmt.Errorf("invalid silence gap: %s (must be positive)", minGap)
+	}
+	if ffmpeg.AVFilterGetByName(ffmpeg.GlobalCStr("silencedetect")) == nil {
+		return nil, missingComponentError("filter", "silencedetect")
+	}
+
+	e := &Encoder{inputPath: path, audioTrack: audioTrack, streamIndex: -1, outStreamIndex: -1}
+	defer e.Close()
+
+	if err := e.configureLogging(); err != nil {
+		return nil, fmt.Errorf("failed to open debug log: %w", err)
+	}
+	if err := e.openInput(); err != nil {
+		return nil, fmt.Errorf("failed to open input: %w", err)
+	}
+
+	e.decFrame = ffmpeg.AVFrameAlloc()
+	e.filteredFrame = ffmpeg.AVFrameAlloc()
+	spec := fmt.Sprintf("silencedetect=noise=%gdB:duration=%g", threshold, minGap.Seconds())
+	if err := e.buildFilterGraph(spec); err != nil {
+		return nil, fmt.Errorf("failed to initialize filter: %w", err)
+	}
+
+	var boundaries []time.Duration
+	if err := e.scanSilences(func(end time.Duration) { boundaries = append(boundaries, end) }); err != nil {
+		return nil, err
+	}
+	return chaptersAt(boundaries), nil
+}
+
+// chaptersAt returns numbered chapters starting at zero and at each boundary,
+// skipping any boundary too close to the chapter before it.
+func chaptersAt(boundaries []time.Duration) []Chapter {
+	chapters := []Chapter{{Start: 0, Title: "Chapter 1"}}
+	for _, start := range boundaries {
+		if start-chapters[len(chapters)-1].Start < minChapterLength {
+			continue
+		}
+		chapters = append(chapters, Chapter{Start: start, Title: fmt.Sprintf("Chapter %d", len(chapters)+1)})
+	}
+	return chapters
+}
+
+// scanSilences decodes the whole input through the filter graph and calls
+// found with the time each detected silence ends.
+func (e *Encoder) scanSilences(found func(end time.Duration)) error {
+	packet := ffmpeg.AVPacketAlloc()
+	defer ffmpeg.AVPacketFree(&packet)
+
+	drain := func() error {
+		for {
+			if _, err := ffmpeg.AVBuffersinkGetFrame(e.bufferSinkCtx, e.filteredFrame); err != nil {
+				if errors.Is(err, ffmpeg.EAgain) || errors.Is(err, ffmpeg.AVErrorEOF) {
+					return nil
+				}
+				return fmt.Errorf("failed to get filtered frame: %w", err)
+			}
+			if end, ok := silenceEnd(e.filteredFrame); ok {
+				found(end)
+			}
+			ffmpeg.AVFrameUnref(e.filteredFrame)
+		}
+	}
+
+	// receive feeds every frame the decoder has ready through the filter.
+	receive := func() error {
+		for {
+			if _, err := ffmpeg.AVCodecReceiveFrame(e.decCtx, e.decFrame); err != nil {
+				if errors.Is(err, ffmpeg.EAgain) || errors.Is(err, ffmpeg.AVErrorEOF) {
+					return nil
+				}
+				return fmt.Errorf("receive frame from decoder failed: %w", err)
+			}
+			if _, err := ffmpeg.AVBuffersrcAddFrameFlags(e.bufferSrcCtx, e.decFrame, ffmpeg.AVBuffersrcFlagKeepRef); err != nil {
+				return fmt.Errorf("failed to feed filter graph: %w", err)
+			}
+			ffmpeg.AVFrameUnref(e.decFrame)
+			if err := drain(); err != nil {
+				return err
+			}
+		}
+	}
+
+	for {
+		if _, err := ffmpeg.AVReadFrame(e.ifmtCtx, packet); err != nil {
+			if errors.Is(err, ffmpeg.AVErrorEOF) {
+				break
+			}
+			return fmt.Errorf("read frame failed: %w", err)
+		}
+		if packet.StreamIndex() != e.streamIndex {
+			ffmpeg.AVPacketUnref(packet)
+			continue
+		}
+		_, err := ffmpeg.AVCodecSendPacket(e.decCtx, packet)
+		ffmpeg.AVPacketUnref(packet)
+		if err != nil {
+			return fmt.Errorf("send packet to decoder failed: %w", err)
+		}
+		if err := receive(); err != nil {
+			return err
+		}
+	}
+
+	if _, err := ffmpeg.AVCodecSendPacket(e.decCtx, nil); err != nil {
+		return fmt.Errorf("flush decoder failed: %w", err)
+	}
+	if err := receive(); err != nil {
+		return err
+	}
+	if _, err := ffmpeg.AVBuffersrcAddFrameFlags(e.bufferSrcCtx, nil, 0); err != nil {
+		return fmt.Errorf("failed to flush filter graph: %w", err)
+	}
+	return drain()
+}
+
+// silenceEnd reports the end of a silence silencedetect marked on frame.
+func silenceEnd(frame *ffmpeg.AVFrame) (time.Duration, bool) {
+	keyPtr := ffmpeg.ToCStr(silenceEndKey)
+	defer keyPtr.Free()
+
+	entry := ffmpeg.AVDictGet(frame.Metadata(), keyPtr, nil, 0)
+	if entry == nil {
+		return 0, false
+	}
+	secs, err := strconv.ParseFloat(entry.Value().String(), 64)
+	if err != nil || secs < 0 {
+		return 0, false
+	}
+	return time.Duration(secs * float64(time.Second)), true
+}
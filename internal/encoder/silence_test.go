@@ -0,0 +1,101 @@
+package encoder
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestChaptersAt(t *testing.T) {
+	tests := []struct {
+		name       string
+		boundaries []time.Duration
+		want       []Chapter
+	}{
+		{
+			name: "no silences",
+			want: []Chapter{{Start: 0, Title: "Chapter 1"}},
+		},
+		{
+			name:       "two silences",
+			boundaries: []time.Duration{5 * time.Minute, 20 * time.Minute},
+			want: []Chapter{
+				{Start: 0, Title: "Chapter 1"},
+				{Start: 5 * time.Minute, Title: "Chapter 2"},
+				{Start: 20 * time.Minute, Title: "Chapter 3"},
+			},
+		},
+		{
+			name:       "leading silence",
+			boundaries: []time.Duration{500 * time.Millisecond, 10 * time.Minute},
+			want: []Chapter{
+				{Start: 0, Title: "Chapter 1"},
+				{Start: 10 * time.Minute, Title: "Chapter 2"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := chaptersAt(tt.boundaries); !slices.Equal(got, tt.want) {
+				t.Errorf("chaptersAt() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectChaptersErrors(t *testing.T) {
+	if _, err := DetectChapters("episode.flac", 0, 0, DefaultSilenceGap); err == nil {
+		t.Error("expected an error for a 0dB threshold")
+	}
+	if _, err := DetectChapters("episode.flac", 0, DefaultSilenceThreshold, 0); err == nil {
+		t.Error("expected an error for a zero gap")
+	}
+}
+
+// TestDetectChapters_Integration runs silence detection over a tone with a
+// three-second pause and expects a chapter where the tone resumes.
+func TestDetectChapters_Integration(t *testing.T) {
+	const sampleRate = 16000
+	var samples []int16
+	tone := func(secs int) {
+		for i := range secs * sampleRate {
+			samples = append(samples, int16(math.Sin(2*math.Pi*440*float64(i)/sampleRate)*16000))
+		}
+	}
+	tone(3)
+	samples = append(samples, make([]int16, 3*sampleRate)...)
+	tone(3)
+
+	var data bytes.Buffer
+	binary.Write(&data, binary.LittleEndian, samples)
+	var b bytes.Buffer
+	b.WriteString("RIFF")
+	binary.Write(&b, binary.LittleEndian, uint32(36+data.Len()))
+	b.WriteString("WAVEfmt ")
+	binary.Write(&b, binary.LittleEndian, []any{uint32(16), uint16(1), uint16(1), uint32(sampleRate), uint32(sampleRate * 2), uint16(2), uint16(16)})
+	b.WriteString("data")
+	binary.Write(&b, binary.LittleEndian, uint32(data.Len()))
+	b.Write(data.Bytes())
+
+	path := filepath.Join(t.TempDir(), "pause.wav")
+	if err := os.WriteFile(path, b.Bytes(), 0o644); err != nil {
+		t.Fatalf("Failed to write WAV fixture: %v", err)
+	}
+
+	chapters, err := DetectChapters(path, 0, DefaultSilenceThreshold, DefaultSilenceGap)
+	if err != nil {
+		t.Fatalf("DetectChapters failed: %v", err)
+	}
+	if len(chapters) != 2 {
+		t.Fatalf("got %d chapters, want 2: %v", len(chapters), chapters)
+	}
+	if got := chapters[1].Start; got < 5900*time.Millisecond || got > 6100*time.Millisecond {
+		t.Errorf("second chapter starts at %s, want about 6s", got)
+	}
+}
@@ -3,12 +3,16 @@ package encoder
 import (
 	"fmt"
 	"os"
+	"strings"
 )
 
 // FileStats holds podcast frontmatter statistics
 type FileStats struct {
-	DurationString string // HH:MM:SS format
-	FileSizeBytes  int64  // File size in bytes
+	DurationString  string  // HH:MM:SS format
+	DurationSecs    int64   // Whole seconds, as <itunes:duration> accepts
+	DurationISO8601 string  // ISO 8601 duration, e.g. PT54M9S
+	FileSizeBytes   int64   // File size in bytes
+	AvgBitrateKbps  float64 // Average bitrate over the whole file, tags included; 0 if duration is unknown
 }
 
 // GetFileStats returns file statistics using a pre-calculated duration.
@@ -22,11 +26,24 @@ func GetFileStats(outputPath string, durationSecs int64) (*FileStats, error) {
 	durationStr := formatDurationHMS(durationSecs)
 
 	return &FileStats{
-		DurationString: durationStr,
-		FileSizeBytes:  fileInfo.Size(),
+		DurationString:  durationStr,
+		DurationSecs:    durationSecs,
+		DurationISO8601: formatDurationISO8601(durationSecs),
+		FileSizeBytes:   fileInfo.Size(),
+		AvgBitrateKbps:  averageBitrateKbps(fileInfo.Size(), durationSecs),
 	}, nil
 }
 
+// averageBitrateKbps returns the achieved bitrate in kbps (1000 bits per
+// second), as a cross-check against the preset. It counts the whole file, so
+// cover art and tags nudge it above the audio bitrate on short encodes.
+func averageBitrateKbps(bytes, durationSecs int64) float64 {
+	if durationSecs <= 0 {
+		return 0
+	}
+	return float64(bytes) * 8 / float64(durationSecs) / 1000
+}
+
 // formatDurationHMS converts seconds to HH:MM:SS format
 func formatDurationHMS(seconds int64) string {
 	hours := seconds / 3600
@@ -35,3 +52,45 @@ func formatDurationHMS(seconds int64) string {
 
 	return fmt.Sprintf("%02d:%02d:%02d", hours, minutes, secs)
 }
+
+// formatDurationISO8601 converts seconds to an ISO 8601 duration such as
+// PT1H2M3S, omitting zero components; zero itself is PT0S
+func formatDurationISO8601(seconds int64) string {
+	if seconds <= 0 {
+		return "PT0S"
+	}
+
+	hours := seconds / 3600
+	minutes := (seconds / 60) % 60
+	secs := seconds % 60
+
+	var b strings.Builder
+	b.WriteString("PT")
+	if hours > 0 {
+		fmt.Fprintf(&b, "%dH", hours)
+	}
+	if minutes > 0 {
+		fmt.Fprintf(&b, "%dM", minutes)
+	}
+	if secs > 0 {
+		fmt.Fprintf(&b, "%dS", secs)
+	}
+	return b.String()
+}
+
+// FormatBytes renders a byte count in binary units with one decimal place,
+// e.g. 27357184 as "26.1 MiB", for on-screen sanity checks. Counts under
+// 1 KiB are shown exactly.
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit && exp < 5; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
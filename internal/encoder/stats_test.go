@@ -1,7 +1,9 @@
 package encoder
 
 import (
+	"bytes"
 	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -28,13 +30,91 @@ func TestFormatDurationHMS(t *testing.T) {
 	}
 }
 
-// TestGetFileStats is an integration test that requires an actual MP3 file
-// The MP3 is created by TestEncodeToMP3_Integration if it doesn't exist
-func TestGetFileStats(t *testing.T) {
-	testFile := "../../testdata/LMP0.mp3"
+func TestFormatDurationISO8601(t *testing.T) {
+	tests := []struct {
+		seconds  int64
+		expected string
+	}{
+		{0, "PT0S"},            // Zero
+		{27, "PT27S"},          // Under a minute
+		{600, "PT10M"},         // Exactly 10 minutes
+		{3249, "PT54M9S"},      // 54 minutes 9 seconds
+		{3661, "PT1H1M1S"},     // Over an hour
+		{36000, "PT10H"},       // Exactly 10 hours
+		{86399, "PT23H59M59S"}, // Max in a day
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			result := formatDurationISO8601(tt.seconds)
+			if result != tt.expected {
+				t.Errorf("formatDurationISO8601(%d) = %s; want %s", tt.seconds, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		bytes    int64
+		expected string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KiB"},
+		{1536, "1.5 KiB"},
+		{620544, "606.0 KiB"},
+		{27357184, "26.1 MiB"},
+		{5 * 1024 * 1024 * 1024, "5.0 GiB"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			result := FormatBytes(tt.bytes)
+			if result != tt.expected {
+				t.Errorf("FormatBytes(%d) = %s; want %s", tt.bytes, result, tt.expected)
+			}
+		})
+	}
+}
 
-	if _, err := os.Stat(testFile); os.IsNotExist(err) {
-		t.Skip("Test MP3 file not found - run tests to generate it via TestEncodeToMP3_Integration")
+func TestAverageBitrateKbps(t *testing.T) {
+	tests := []struct {
+		name         string
+		bytes        int64
+		durationSecs int64
+		expected     float64
+	}{
+		{"112 kbps CBR", 14000 * 60, 60, 112},
+		{"fractional", 1000, 3, 1000 * 8 / 3.0 / 1000},
+		{"zero duration", 1000, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := averageBitrateKbps(tt.bytes, tt.durationSecs)
+			if result != tt.expected {
+				t.Errorf("averageBitrateKbps(%d, %d) = %v; want %v", tt.bytes, tt.durationSecs, result, tt.expected)
+			}
+		})
+	}
+}
+
+// TestGetFileStats_Integration is an integration test that reads an MP3 encoded from the
+// test FLAC
+func TestGetFileStats_Integration(t *testing.T) {
+	inputPath := "../../testdata/LMP0.flac"
+	if _, err := os.Stat(inputPath); os.IsNotExist(err) {
+		t.Skipf("Test file not found: %s", inputPath)
+	}
+
+	var encoded bytes.Buffer
+	if _, err := EncodeTo(&encoded, Config{InputPath: inputPath}, nil); err != nil {
+		t.Fatalf("EncodeTo failed: %v", err)
+	}
+	testFile := filepath.Join(t.TempDir(), "LMP0.mp3")
+	if err := os.WriteFile(testFile, encoded.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
 	}
 
 	// Use a known duration for testing (the test file is ~27 seconds)
@@ -53,6 +133,10 @@ func TestGetFileStats(t *testing.T) {
 		t.Errorf("FileSizeBytes = %d; want > 0", stats.FileSizeBytes)
 	}
 
-	t.Logf("Stats for %s: duration=%s, size=%d bytes",
-		testFile, stats.DurationString, stats.FileSizeBytes)
+	if stats.AvgBitrateKbps <= 0 {
+		t.Errorf("AvgBitrateKbps = %v; want > 0", stats.AvgBitrateKbps)
+	}
+
+	t.Logf("Stats for %s: duration=%s, size=%d bytes, bitrate=%.1f kbps",
+		testFile, stats.DurationString, stats.FileSizeBytes, stats.AvgBitrateKbps)
 }
@@ -0,0 +1,101 @@
+package encoder
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/linuxmatters/ffmpeg-statigo"
+)
+
+// AudioTrack describes one audio stream in the input file. Number is the
+// 1-based position among the input's audio streams, which is what
+// --audio-track selects; StreamIndex is the container stream index.
+type AudioTrack struct {
+	Number      int
+	StreamIndex int
+	Codec       string
+	SampleRate  int
+	Channels    int
+	Language    string
+	Title       string
+	Default     bool
+}
+
+// String formats the track for listing, e.g.
+// `2: aac 48000㎐ stereo [eng] "System audio" (default)`.
+func (t AudioTrack) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d: %s %d㎐ %s", t.Number, t.Codec, t.SampleRate, FormatChannelMode(t.Channels))
+	if t.Language != "" {
+		fmt.Fprintf(&b, " [%s]", t.Language)
+	}
+	if t.Title != "" {
+		fmt.Fprintf(&b, " %q", t.Title)
+	}
+	if t.Default {
+		b.WriteString(" (default)")
+	}
+	return b.String()
+}
+
+// listAudioTracks enumerates the audio streams in the opened input, numbering
+// them from 1 in container order.
+func listAudioTracks(ifmtCtx *ffmpeg.AVFormatContext) []AudioTrack {
+	var tracks []AudioTrack
+	streams := ifmtCtx.Streams()
+	for i := uintptr(0); i < uintptr(ifmtCtx.NbStreams()); i++ {
+		stream := streams.Get(i)
+		codecPar := stream.Codecpar()
+		if codecPar.CodecType() != ffmpeg.AVMediaTypeAudio {
+			continue
+		}
+		tracks = append(tracks, AudioTrack{
+			Number:      len(tracks) + 1,
+			StreamIndex: stream.Index(),
+			Codec:       ffmpeg.AVCodecGetName(codecPar.CodecId()).String(),
+			SampleRate:  codecPar.SampleRate(),
+			Channels:    codecPar.ChLayout().NbChannels(),
+			Language:    streamTag(stream, "language"),
+			Title:       streamTag(stream, "title"),
+			Default:     stream.Disposition()&ffmpeg.AVDispositionDefault != 0,
+		})
+	}
+	return tracks
+}
+
+// streamTag reads a single metadata value from a stream, or "" when unset.
+func streamTag(stream *ffmpeg.AVStream, key string) string {
+	keyPtr := ffmpeg.ToCStr(key)
+	defer keyPtr.Free()
+
+	entry := ffmpeg.AVDictGet(stream.Metadata(), keyPtr, nil, 0)
+	if entry == nil {
+		return ""
+	}
+	return entry.Value().String()
+}
+
+// trackByNumber returns the audio track with the given 1-based number.
+func trackByNumber(tracks []AudioTrack, number int) (AudioTrack, error) {
+	if number < 1 || number > len(tracks) {
+		return AudioTrack{}, fmt.Errorf("audio track %d not found: input has %d audio track(s)", number, len(tracks))
+	}
+	return tracks[number-1], nil
+}
+
+// AudioTracks returns the audio streams found in the input. It is empty
+// before Initialize.
+func (e *Encoder) AudioTracks() []AudioTrack {
+	return e.audioTracks
+}
+
+// SelectedAudioTrack returns the number of the audio track being encoded, or
+// 0 before Initialize.
+func (e *Encoder) SelectedAudioTrack() int {
+	for _, track := range e.audioTracks {
+		if track.StreamIndex == e.streamIndex {
+			return track.Number
+		}
+	}
+	return 0
+}
@@ -0,0 +1,54 @@
+package encoder
+
+import "testing"
+
+func TestAudioTrackString(t *testing.T) {
+	tests := []struct {
+		name  string
+		track AudioTrack
+		want  string
+	}{
+		{
+			name:  "bare track",
+			track: AudioTrack{Number: 1, Codec: "flac", SampleRate: 44100, Channels: 1},
+			want:  "1: flac 44100㎐ mono",
+		},
+		{
+			name: "tagged default track",
+			track: AudioTrack{
+				Number: 2, Codec: "aac", SampleRate: 48000, Channels: 2,
+				Language: "eng", Title: "System audio", Default: true,
+			},
+			want: `2: aac 48000㎐ stereo [eng] "System audio" (default)`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.track.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTrackByNumber(t *testing.T) {
+	tracks := []AudioTrack{
+		{Number: 1, StreamIndex: 1},
+		{Number: 2, StreamIndex: 3},
+	}
+
+	track, err := trackByNumber(tracks, 2)
+	if err != nil {
+		t.Fatalf("trackByNumber(2): %v", err)
+	}
+	if track.StreamIndex != 3 {
+		t.Errorf("trackByNumber(2).StreamIndex = %d, want 3", track.StreamIndex)
+	}
+
+	for _, number := range []int{0, 3} {
+		if _, err := trackByNumber(tracks, number); err == nil {
+			t.Errorf("trackByNumber(%d): expected error, got nil", number)
+		}
+	}
+}
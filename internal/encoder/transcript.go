@@ -0,0 +1,156 @@
+package encoder
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// TranscriptLine is one line of a timed transcript: when it is spoken and
+// what is said. The transcript package reads them from caption files.
+type TranscriptLine struct {
+	Start time.Duration
+	Text  string
+}
+
+// Transcript size limits, for the SYLT frame holding it. An ID3 tag sits at
+// the front of the file, so players and podcast apps read all of it before
+// the first note of audio; a long episode's transcript runs to a few hundred
+// kilobytes, and beyond MaxTranscriptBytes it is better published as a
+// separate file.
+const (
+	MaxTranscriptBytes   = 1 << 20
+	LargeTranscriptBytes = 256 << 10
+)
+
+// SYLT frame header fields.
+const (
+	syltMilliseconds  = 2 // timestamp format: absolute milliseconds
+	syltTranscription = 2 // content type: text transcription
+)
+
+// TranscriptFrameSize returns the bytes the transcript adds to the ID3 tag of
+// an MP3 as a SYLT frame, for comparing with MaxTranscriptBytes and
+// LargeTranscriptBytes. id3Version is the tag's major version, 3 or 4 (0 for
+// DefaultID3Version); version 3 stores the text as UTF-16, which is larger
+// for mostly-ASCII text.
+func TranscriptFrameSize(transcript []TranscriptLine, id3Version int) int {
+	if id3Version == 0 {
+		id3Version = DefaultID3Version
+	}
+	return 10 + len(syltFrame(transcript, DefaultCommentLanguage, byte(id3Version))) //nolint:gosec // the version is 3 or 4
+}
+
+// syltFrame returns the body of a SYLT frame holding the transcript in lang,
+// timed in milliseconds. ID3v2.4 text is UTF-8; ID3v2.3 has no UTF-8, so the
+// text is UTF-16 with a byte order mark.
+func syltFrame(transcript []TranscriptLine, lang string, version byte) []byte {
+	enc := byte(id3UTF8)
+	if version == 3 {
+		enc = id3UTF16
+	}
+
+	body := append([]byte{enc}, lang...)
+	body = append(body, syltMilliseconds, syltTranscription)
+	body = append(body, encodeID3Text("", enc)...) // no content descriptor
+	for _, line := range transcript {
+		body = append(body, encodeID3Text(line.Text, enc)...)
+		body = binary.BigEndian.AppendUint32(body, uint32(line.Start.Milliseconds())) //nolint:gosec // episodes are far shorter than 49 days
+	}
+	return body
+}
+
+// writeTranscript adds the transcript to a finished MP3 as a SYLT frame.
+// FFmpeg's muxer has no way to write one, so the tag is extended on disk once
+// the trailer has been written.
+func (e *Encoder) writeTranscript() error {
+	if e.preset.name != "mp3" || len(e.transcript) == 0 {
+		return nil
+	}
+	if err := addSYLTFrame(e.outputPath, e.transcript, e.commentLanguage); err != nil {
+		return fmt.Errorf("failed to embed transcript: %w", err)
+	}
+	return nil
+}
+
+// addSYLTFrame appends a SYLT frame holding the transcript to the ID3v2 tag of
+// the MP3 at path. When the tag's padding has room the frame is written in
+// place; otherwise the file is rewritten with a larger tag, through a
+// temporary file beside it that replaces the original once complete. The audio
+// is copied unchanged either way.
+func addSYLTFrame(path string, transcript []TranscriptLine, lang string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open output: %w", err)
+	}
+	defer f.Close()
+
+	header := make([]byte, 10)
+	if _, err := io.ReadFull(f, header); err != nil {
+		return fmt.Errorf("failed to read ID3 header: %w", err)
+	}
+	version := header[3]
+	if string(header[:3]) != "ID3" || (version != 3 && version != 4) || header[5]&0xc0 != 0 {
+		return fmt.Errorf("%s has no ID3v2.3 or v2.4 tag to add it to", path)
+	}
+
+	tag := make([]byte, synchsafe(header[6:10]))
+	if _, err := io.ReadFull(f, tag); err != nil {
+		return fmt.Errorf("failed to read ID3 tag: %w", err)
+	}
+	end, ok := walkFrames(tag, version, nil)
+	if !ok {
+		return fmt.Errorf("%s has an ID3 tag that cannot be parsed", path)
+	}
+	frame := appendFrame(nil, "SYLT", syltFrame(transcript, strings.ToLower(lang), version), version)
+
+	if end+len(frame) <= len(tag) {
+		f.Close()
+		return writeInPadding(path, int64(len(header)+end), frame)
+	}
+
+	tag = append(tag[:end], frame...)
+	copy(header[6:10], toSynchsafe(len(tag)))
+
+	tmp, err := CreateReplacement(path, ".jivedrop-sylt-*.mp3")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if _, err := tmp.Write(append(header, tag...)); err != nil {
+		return fmt.Errorf("failed to write ID3 tag: %w", err)
+	}
+	if _, err := io.Copy(tmp, f); err != nil {
+		return fmt.Errorf("failed to copy audio: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	f.Close()
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace output: %w", err)
+	}
+	return nil
+}
+
+// writeInPadding writes frame into the file at path at offset, over padding.
+func writeInPadding(path string, offset int64, frame []byte) error {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return fmt.Errorf("failed to open output: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.WriteAt(frame, offset); err != nil {
+		return fmt.Errorf("failed to write ID3 tag: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write ID3 tag: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package encoder
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSYLTFrame(t *testing.T) {
+	transcript := []TranscriptLine{{Start: 0, Text: "Hi"}, {Start: 1500 * time.Millisecond, Text: "Grüße"}}
+
+	want := []byte("\x03deu\x02\x02\x00Hi\x00\x00\x00\x00\x00Grüße\x00\x00\x00\x05\xdc")
+	if got := syltFrame(transcript, "deu", 4); !bytes.Equal(got, want) {
+		t.Errorf("syltFrame(v2.4) = %q, want %q", got, want)
+	}
+
+	v3 := syltFrame(transcript, "deu", 3)
+	if !bytes.HasPrefix(v3, []byte("\x01deu\x02\x02\xff\xfe\x00\x00")) {
+		t.Errorf("syltFrame(v2.3) header = %q, want UTF-16 text", v3[:10])
+	}
+	if TranscriptFrameSize(transcript, 3) <= TranscriptFrameSize(transcript, 0) {
+		t.Error("TranscriptFrameSize() for v2.3 is not larger than for v2.4")
+	}
+}
+
+// TestAddSYLTFrame verifies the frame lands in the tag's padding when it fits
+// and the tag grows when it does not, with the audio after it and the file's
+// permissions untouched.
+func TestAddSYLTFrame(t *testing.T) {
+	transcript := []TranscriptLine{{Start: 0, Text: "Hello"}, {Start: time.Second, Text: "World"}}
+	audio := []byte("\xff\xfbaudio frames")
+	frames := appendFrame(nil, "TIT2", []byte("\x03Episode 67"), 4)
+
+	for _, padding := range []int{256, 0} {
+		tag := append(bytes.Clone(frames), make([]byte, padding)...)
+		header := append([]byte("ID3\x04\x00\x00"), toSynchsafe(len(tag))...)
+		data := append(append(header, tag...), audio...)
+
+		path := filepath.Join(t.TempDir(), "episode.mp3")
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := addSYLTFrame(path, transcript, "ENG"); err != nil {
+			t.Fatalf("addSYLTFrame() with %d bytes of padding: %v", padding, err)
+		}
+
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.HasSuffix(got, audio) {
+			t.Fatalf("audio changed with %d bytes of padding: %q", padding, got)
+		}
+		if grew := len(got) > len(data); grew != (padding == 0) {
+			t.Errorf("with %d bytes of padding the file grew = %v", padding, grew)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mode := info.Mode().Perm(); mode != 0o644 {
+			t.Errorf("with %d bytes of padding the file mode = %v, want -rw-r--r--", padding, mode)
+		}
+		size := synchsafe(got[6:10])
+		if 10+size+len(audio) != len(got) {
+			t.Fatalf("tag size %d does not reach the audio", size)
+		}
+		f := readFrames(t, got[10:10+size], 4)
+		if len(f) != 2 || f[0].id != "TIT2" || f[1].id != "SYLT" || !bytes.Equal(f[1].body, syltFrame(transcript, "eng", 4)) {
+			t.Errorf("frames with %d bytes of padding = %q", padding, f)
+		}
+	}
+
+	noTag := filepath.Join(t.TempDir(), "plain.mp3")
+	if err := os.WriteFile(noTag, audio, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := addSYLTFrame(noTag, transcript, "eng"); err == nil {
+		t.Error("addSYLTFrame() on an untagged file: expected error, got nil")
+	}
+}
@@ -0,0 +1,80 @@
+package encoder
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// CreateReplacement creates an empty temporary file beside path, for writing
+// a new version of path to rename over it once complete. os.CreateTemp makes
+// the file private, so it is given path's own permissions, which the rename
+// keeps. pattern is as for os.CreateTemp.
+func CreateReplacement(path, pattern string) (*os.File, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), pattern)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	if err := tmp.Chmod(info.Mode().Perm()); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return nil, fmt.Errorf("failed to set temporary file permissions: %w", err)
+	}
+	return tmp, nil
+}
+
+// EncodeTo encodes the input in cfg and writes the finished file to w, for
+// callers that want the encoded bytes rather than a file on disk, such as
+// tests that inspect the output and encode benchmarks. cfg.OutputPath is
+// ignored. FFmpeg's muxers seek back to finish a file (the MP3 info frame,
+// the MP4 index), so the output is staged in a temporary file, removed before
+// EncodeTo returns. The result has no OutputPath, and BytesWritten counts
+// the bytes written to w.
+func EncodeTo(w io.Writer, cfg Config, progressCb ProgressCallback) (EncodeResult, error) {
+	format := cfg.Format
+	if format == "" {
+		format = "mp3"
+	}
+	// The muxer is chosen by the file extension.
+	tmp, err := os.CreateTemp("", "jivedrop-encode-*"+ExtensionFor(format))
+	if err != nil {
+		return EncodeResult{}, fmt.Errorf("failed to create temporary output: %w", err)
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+
+	cfg.OutputPath = tmp.Name()
+	enc, err := New(cfg)
+	if err != nil {
+		return EncodeResult{}, err
+	}
+	defer enc.Close()
+
+	if err := enc.Initialize(); err != nil {
+		return EncodeResult{}, err
+	}
+	result, err := enc.Encode(progressCb)
+	if err != nil {
+		return EncodeResult{}, err
+	}
+	enc.Close()
+
+	f, err := os.Open(tmp.Name())
+	if err != nil {
+		return EncodeResult{}, fmt.Errorf("failed to read temporary output: %w", err)
+	}
+	defer f.Close()
+
+	n, err := io.Copy(w, f)
+	if err != nil {
+		return EncodeResult{}, fmt.Errorf("failed to write encoded output: %w", err)
+	}
+	result.OutputPath = ""
+	result.BytesWritten = n
+	return result, nil
+}
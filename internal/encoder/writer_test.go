@@ -0,0 +1,42 @@
+package encoder
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestCreateReplacement verifies the temporary file sits beside the original
+// with its permissions, not CreateTemp's private 0600.
+func TestCreateReplacement(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "episode.mp3")
+	if err := os.WriteFile(path, []byte("mp3"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(path, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tmp, err := CreateReplacement(path, ".jivedrop-test-*.mp3")
+	if err != nil {
+		t.Fatalf("CreateReplacement() error: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if got := filepath.Dir(tmp.Name()); got != dir {
+		t.Errorf("temporary file in %q, want %q", got, dir)
+	}
+	info, err := tmp.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0o644 {
+		t.Errorf("temporary file mode = %o, want 644", mode)
+	}
+
+	if _, err := CreateReplacement(filepath.Join(dir, "missing.mp3"), "*"); err == nil {
+		t.Error("CreateReplacement() for a missing file: expected error, got nil")
+	}
+}
@@ -2,69 +2,157 @@ package id3
 
 import (
 	"bytes"
+	"encoding/binary"
 	"fmt"
 	"image"
+	"image/color"
 	"image/png"
 	"os"
 
 	"golang.org/x/image/draw"
 )
 
+// MinCoverSize and MaxCoverSize are the Apple Podcasts artwork bounds, as the
+// edge length in pixels of the square image.
+const (
+	MinCoverSize = 1400
+	MaxCoverSize = 3000
+)
+
+// LowResCoverSize is the edge length below which a cover is upscaled so far
+// that the result looks soft, and jivedrop suggests a larger source.
+const LowResCoverSize = 1000
+
+// coverShrinkStep is how many pixels FitCoverArt takes off the edge length on
+// each attempt to meet a byte budget.
+const coverShrinkStep = 200
+
+// DefaultCoverScaler is the scaler used when none is chosen. Bilinear matches
+// the scaler used by Jivefire thumbnail generation.
+const DefaultCoverScaler = "bilinear"
+
+// coverScalers maps the --cover-scaler names to their interpolators: bilinear
+// is fast, catmullrom is slower but sharper on large downscales.
+var coverScalers = map[string]draw.Interpolator{
+	"bilinear":   draw.BiLinear,
+	"catmullrom": draw.CatmullRom,
+}
+
+// coverScaler resolves a scaler name, with "" meaning DefaultCoverScaler.
+func coverScaler(name string) (draw.Interpolator, error) {
+	if name == "" {
+		name = DefaultCoverScaler
+	}
+	scaler, ok := coverScalers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown cover scaler %q: expected bilinear or catmullrom", name)
+	}
+	return scaler, nil
+}
+
 // ScaleCoverArt scales cover art according to Apple Podcasts specifications:
 //   - Images < 1400x1400: upscale to 1400x1400
 //   - Images 1400x1400 to 3000x3000: use as-is (no scaling artifacts)
 //   - Images > 3000x3000: downscale to 3000x3000
 //
-// To avoid needless recompression it returns the original PNG bytes untouched
-// when no scaling is required, and only re-encodes scaled images or non-PNG
-// inputs.
+// To avoid needless recompression it returns the original PNG image data
+// when no scaling is required, and only re-encodes scaled images, non-PNG
+// inputs, and images without an RGB colour model. Grayscale, paletted and
+// CMYK images are converted to RGBA first so every player sees the same colours.
+// The result never carries EXIF, ICC or text metadata: re-encoded images have
+// none, and passed-through PNGs are stripped of every ancillary chunk.
 func ScaleCoverArt(inputPath string) ([]byte, error) {
-	data, err := os.ReadFile(inputPath)
+	return scaleCoverArt(inputPath, draw.BiLinear)
+}
+
+// ValidateCoverArt checks that the file at path is a decodable, square image,
+// the checks ScaleCoverArt makes, so a bad cover can be rejected before the
+// encode starts.
+func ValidateCoverArt(path string) error {
+	data, err := os.ReadFile(path)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read cover art: %w", err)
+		return fmt.Errorf("failed to read cover art: %w", err)
 	}
+	_, _, err = decodeCoverArt(data)
+	return err
+}
 
+// CoverSourceSize returns the pixel dimensions of the image at path, read
+// from its header without decoding the pixels.
+func CoverSourceSize(path string) (width, height int, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read cover art: %w", err)
+	}
+	defer f.Close()
+
+	cfg, _, err := image.DecodeConfig(f)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to decode cover art: %w", err)
+	}
+	return cfg.Width, cfg.Height, nil
+}
+
+// decodeCoverArt decodes cover art and checks it is square, as Apple Podcasts
+// requires. It returns the image and its format name.
+func decodeCoverArt(data []byte) (image.Image, string, error) {
 	img, format, err := image.Decode(bytes.NewReader(data))
 	if err != nil {
-		return nil, fmt.Errorf("failed to decode cover art: %w", err)
+		return nil, "", fmt.Errorf("failed to decode cover art: %w", err)
 	}
 
-	// Apple Podcasts requires square artwork.
 	bounds := img.Bounds()
-	width := bounds.Dx()
-	height := bounds.Dy()
+	if bounds.Dx() != bounds.Dy() {
+		return nil, "", fmt.Errorf("cover art must be square (got %dx%d)", bounds.Dx(), bounds.Dy())
+	}
+	return img, format, nil
+}
 
-	if width != height {
-		return nil, fmt.Errorf("cover art must be square (got %dx%d)", width, height)
+// scaleCoverArt implements ScaleCoverArt with the given scaler.
+func scaleCoverArt(inputPath string, scaler draw.Interpolator) ([]byte, error) {
+	data, err := os.ReadFile(inputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cover art: %w", err)
+	}
+
+	img, format, err := decodeCoverArt(data)
+	if err != nil {
+		return nil, err
 	}
+	width := img.Bounds().Dx()
 
 	var targetSize int
 	var needsScaling bool
 
 	switch {
-	case width < 1400:
-		targetSize = 1400
+	case width < MinCoverSize:
+		targetSize = MinCoverSize
 		needsScaling = true
-	case width > 3000:
-		targetSize = 3000
+	case width > MaxCoverSize:
+		targetSize = MaxCoverSize
 		needsScaling = true
 	}
 
-	// Fast path: an in-spec PNG passes through with its bytes intact.
-	if !needsScaling && format == "png" {
-		return data, nil
+	// Fast path: an in-spec RGB PNG passes through with its pixel data intact,
+	// minus any metadata chunks.
+	rgb := isRGBModel(img.ColorModel())
+	if !needsScaling && format == "png" && rgb {
+		return stripPNGMetadata(data)
+	}
+
+	if !rgb {
+		img = toRGBA(img)
 	}
 
 	var finalImg image.Image
 	if needsScaling {
 		dst := image.NewRGBA(image.Rect(0, 0, targetSize, targetSize))
 
-		// Bilinear matches the scaler used by Jivefire thumbnail generation.
-		draw.BiLinear.Scale(dst, dst.Bounds(), img, img.Bounds(), draw.Over, nil)
+		scaler.Scale(dst, dst.Bounds(), img, img.Bounds(), draw.Over, nil)
 
 		finalImg = dst
 	} else {
-		// Reaches here only for an in-spec non-PNG, re-encoded below.
+		// Reaches here for an in-spec non-PNG or non-RGB image, re-encoded below.
 		finalImg = img
 	}
 
@@ -78,3 +166,118 @@ func ScaleCoverArt(inputPath string) ([]byte, error) {
 
 	return buf.Bytes(), nil
 }
+
+// FitCoverArt scales cover art like ScaleCoverArt and then, while the PNG is
+// larger than maxBytes, re-encodes it smaller in coverShrinkStep steps, never
+// going below MinCoverSize. It returns the artwork and its edge length in
+// pixels. The artwork can still exceed maxBytes at the minimum size; the
+// caller decides how to report that. A maxBytes of 0 disables the budget.
+// scalerName picks the resampling kernel (bilinear or catmullrom; "" means
+// DefaultCoverScaler) for every resize.
+func FitCoverArt(inputPath string, maxBytes int, scalerName string) ([]byte, int, error) {
+	scaler, err := coverScaler(scalerName)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	data, err := scaleCoverArt(inputPath, scaler)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	cfg, err := png.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read scaled cover art: %w", err)
+	}
+	size := cfg.Width
+	if maxBytes <= 0 || len(data) <= maxBytes {
+		return data, size, nil
+	}
+
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to decode scaled cover art: %w", err)
+	}
+
+	// Each attempt scales from the full-size image, so quality loss does not
+	// compound across steps.
+	enc := png.Encoder{CompressionLevel: png.BestCompression}
+	for size > MinCoverSize && len(data) > maxBytes {
+		size = max(size-coverShrinkStep, MinCoverSize)
+		dst := image.NewRGBA(image.Rect(0, 0, size, size))
+		scaler.Scale(dst, dst.Bounds(), img, img.Bounds(), draw.Over, nil)
+
+		var buf bytes.Buffer
+		if err := enc.Encode(&buf, dst); err != nil {
+			return nil, 0, fmt.Errorf("failed to encode scaled image: %w", err)
+		}
+		data = buf.Bytes()
+	}
+
+	return data, size, nil
+}
+
+// isRGBModel reports whether m is one of the RGB colour models, which encode
+// to a truecolour PNG without conversion.
+func isRGBModel(m color.Model) bool {
+	switch m {
+	case color.RGBAModel, color.NRGBAModel, color.RGBA64Model, color.NRGBA64Model:
+		return true
+	}
+	return false
+}
+
+// toRGBA converts img to RGBA with an explicit draw, so grayscale, paletted
+// and CMYK sources are resolved to RGB once, through the standard colour
+// conversions, rather than left to each later step.
+func toRGBA(img image.Image) *image.RGBA {
+	bounds := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, bounds.Min, draw.Src)
+	return dst
+}
+
+// pngSignature is the eight-byte header every PNG file starts with.
+const pngSignature = "\x89PNG\r\n\x1a\n"
+
+// pngMetadataChunks are the text, EXIF and timestamp chunks that bloat the
+// embedded picture and can upset strict players. Colour-space chunks (sRGB,
+// gAMA, cHRM, iCCP) are not among them: they change how the picture is shown.
+var pngMetadataChunks = map[string]bool{
+	"tEXt": true,
+	"zTXt": true,
+	"iTXt": true,
+	"eXIf": true,
+	"tIME": true,
+}
+
+// stripPNGMetadata returns data without its metadata chunks. The chunks kept
+// are copied verbatim, CRCs included, so the pixels are not recompressed.
+func stripPNGMetadata(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, []byte(pngSignature)) {
+		return nil, fmt.Errorf("failed to strip cover art metadata: not a PNG")
+	}
+
+	out := make([]byte, 0, len(data))
+	out = append(out, pngSignature...)
+	for rest := data[len(pngSignature):]; len(rest) > 0; {
+		// Each chunk is a 4-byte length, 4-byte type, the data, and a 4-byte CRC.
+		if len(rest) < 12 {
+			return nil, fmt.Errorf("failed to strip cover art metadata: truncated chunk")
+		}
+		size := 12 + int(binary.BigEndian.Uint32(rest[:4]))
+		if size < 12 || size > len(rest) {
+			return nil, fmt.Errorf("failed to strip cover art metadata: truncated chunk")
+		}
+
+		chunkType := string(rest[4:8])
+		if !pngMetadataChunks[chunkType] {
+			out = append(out, rest[:size]...)
+		}
+		rest = rest[size:]
+		if chunkType == "IEND" {
+			break
+		}
+	}
+	return out, nil
+}
@@ -2,13 +2,19 @@ package id3
 
 import (
 	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
 	"image"
 	"image/color"
 	"image/png"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
+
+	"golang.org/x/image/draw"
 )
 
 // TestScaleCoverArt_ValidSquareImage tests scaling of valid square images
@@ -97,6 +103,81 @@ func TestScaleCoverArt_ValidSquareImage(t *testing.T) {
 	}
 }
 
+// TestValidateCoverArt verifies the early cover check accepts a square image
+// of any size and rejects non-square, undecodable and missing files with the
+// same errors ScaleCoverArt gives.
+func TestValidateCoverArt(t *testing.T) {
+	tmpDir := t.TempDir()
+	write := func(name string, width, height int) string {
+		t.Helper()
+		path := filepath.Join(tmpDir, name)
+		if err := createTestPNG(path, width, height); err != nil {
+			t.Fatalf("Failed to create test PNG: %v", err)
+		}
+		return path
+	}
+
+	notImage := filepath.Join(tmpDir, "cover.png.txt")
+	if err := os.WriteFile(notImage, []byte("not an image"), 0o644); err != nil {
+		t.Fatalf("Failed to write fixture: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		errMatch string
+	}{
+		{name: "square small", path: write("small.png", 300, 300)},
+		{name: "square large", path: write("large.png", 3500, 3500)},
+		{name: "landscape", path: write("wide.png", 2000, 1500), errMatch: "must be square (got 2000x1500)"},
+		{name: "not an image", path: notImage, errMatch: "failed to decode cover art"},
+		{name: "missing", path: filepath.Join(tmpDir, "missing.png"), errMatch: "failed to read cover art"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateCoverArt(tt.path)
+			if tt.errMatch == "" {
+				if err != nil {
+					t.Errorf("ValidateCoverArt() unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errMatch) {
+				t.Errorf("ValidateCoverArt() error = %v, want containing %q", err, tt.errMatch)
+			}
+			if _, scaleErr := ScaleCoverArt(tt.path); scaleErr == nil || scaleErr.Error() != err.Error() {
+				t.Errorf("ScaleCoverArt() error = %v, want the same as ValidateCoverArt", scaleErr)
+			}
+		})
+	}
+}
+
+// TestCoverSourceSize verifies the source dimensions are read from the image
+// header, so a low-resolution cover can be flagged before it is upscaled.
+func TestCoverSourceSize(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "cover.png")
+	if err := createTestPNG(path, 500, 400); err != nil {
+		t.Fatalf("Failed to create test PNG: %v", err)
+	}
+
+	width, height, err := CoverSourceSize(path)
+	if err != nil {
+		t.Fatalf("CoverSourceSize() error: %v", err)
+	}
+	if width != 500 || height != 400 {
+		t.Errorf("CoverSourceSize() = %dx%d, want 500x400", width, height)
+	}
+	if width >= LowResCoverSize {
+		t.Errorf("a %d pixel cover should be under LowResCoverSize (%d)", width, LowResCoverSize)
+	}
+
+	if _, _, err := CoverSourceSize(filepath.Join(tmpDir, "missing.png")); err == nil || !strings.Contains(err.Error(), "failed to read cover art") {
+		t.Errorf("CoverSourceSize() on a missing file error = %v, want a read error", err)
+	}
+}
+
 // TestScaleCoverArt_NonSquareImage tests error handling for non-square images
 func TestScaleCoverArt_NonSquareImage(t *testing.T) {
 	tests := []struct {
@@ -289,51 +370,32 @@ func TestScaleCoverArt_OutputIsPNG(t *testing.T) {
 }
 
 // TestScaleCoverArt_SkipPNGReencoding tests that PNG images in acceptable range
-// are not re-encoded, preserving the original PNG data for performance
+// are not re-encoded: the original file bytes come back unchanged
 func TestScaleCoverArt_SkipPNGReencoding(t *testing.T) {
-	tmpDir := t.TempDir()
-	testImagePath := filepath.Join(tmpDir, "test_3000.png")
-
-	// Create a 3000x3000 PNG (already in acceptable range, no scaling needed)
-	if err := createTestPNG(testImagePath, 3000, 3000); err != nil {
-		t.Fatalf("Failed to create test PNG: %v", err)
-	}
-
-	originalInfo, err := os.Stat(testImagePath)
-	if err != nil {
-		t.Fatalf("Failed to stat original file: %v", err)
-	}
-	originalSize := originalInfo.Size()
-
-	scaledData, err := ScaleCoverArt(testImagePath)
-	if err != nil {
-		t.Fatalf("ScaleCoverArt failed: %v", err)
-	}
-
-	if scaledData == nil {
-		t.Fatal("ScaleCoverArt returned nil data")
-	}
-
-	scaledSize := int64(len(scaledData))
+	for _, size := range []int{1400, 2000, 3000} {
+		t.Run(fmt.Sprintf("%dx%d", size, size), func(t *testing.T) {
+			testImagePath := filepath.Join(t.TempDir(), "test.png")
+			if err := createTestPNG(testImagePath, size, size); err != nil {
+				t.Fatalf("Failed to create test PNG: %v", err)
+			}
 
-	// For PNG images in acceptable range with no scaling, the output should
-	// be very close in size to the original (may not be identical due to
-	// re-encoding, but should preserve the PNG efficiently)
-	// We allow up to 10% deviation to account for PNG compression variations
-	maxDeviation := originalSize / 10
-	if scaledSize > originalSize+maxDeviation || scaledSize < originalSize-maxDeviation {
-		t.Logf("Warning: output size %d differs from original %d by more than 10%%", scaledSize, originalSize)
-	}
+			original, err := os.ReadFile(testImagePath)
+			if err != nil {
+				t.Fatalf("Failed to read original file: %v", err)
+			}
 
-	// Verify output is valid PNG with correct dimensions
-	decodedImg, err := png.Decode(bytes.NewReader(scaledData))
-	if err != nil {
-		t.Fatalf("Failed to decode scaled image: %v", err)
-	}
+			scaledData, err := ScaleCoverArt(testImagePath)
+			if err != nil {
+				t.Fatalf("ScaleCoverArt failed: %v", err)
+			}
 
-	bounds := decodedImg.Bounds()
-	if bounds.Dx() != 3000 || bounds.Dy() != 3000 {
-		t.Errorf("Expected 3000x3000, got %dx%d", bounds.Dx(), bounds.Dy())
+			// createTestPNG writes no metadata chunks, so nothing is stripped
+			// and the passthrough must be byte-for-byte.
+			if !bytes.Equal(scaledData, original) {
+				t.Errorf("output (%d bytes) differs from the original file (%d bytes); expected passthrough",
+					len(scaledData), len(original))
+			}
+		})
 	}
 }
 
@@ -468,6 +530,225 @@ func TestScaleCoverArt_MultipleScalings(t *testing.T) {
 	}
 }
 
+// TestScaleCoverArt_ConvertsToRGBA tests that grayscale and paletted PNGs are
+// re-encoded as RGB PNGs with their colours intact, even when in spec
+func TestScaleCoverArt_ConvertsToRGBA(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	palette := color.Palette{color.Black, red}
+
+	tests := []struct {
+		name string
+		img  image.Image
+		size int
+		want color.Color
+	}{
+		{name: "grayscale in spec", img: image.NewGray(image.Rect(0, 0, 1400, 1400)), size: 1400, want: color.RGBA{A: 255}},
+		{name: "paletted in spec", img: image.NewPaletted(image.Rect(0, 0, 2000, 2000), palette), size: 2000, want: red},
+		{name: "paletted upscaled", img: image.NewPaletted(image.Rect(0, 0, 1000, 1000), palette), size: 1400, want: red},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Paint paletted images with the second palette entry so a lost
+			// conversion would show up as black.
+			if p, ok := tt.img.(*image.Paletted); ok {
+				for i := range p.Pix {
+					p.Pix[i] = 1
+				}
+			}
+
+			path := filepath.Join(t.TempDir(), "cover.png")
+			file, err := os.Create(path)
+			if err != nil {
+				t.Fatalf("Failed to create test image: %v", err)
+			}
+			if err := png.Encode(file, tt.img); err != nil {
+				t.Fatalf("Failed to encode test image: %v", err)
+			}
+			file.Close()
+
+			scaledData, err := ScaleCoverArt(path)
+			if err != nil {
+				t.Fatalf("ScaleCoverArt failed: %v", err)
+			}
+
+			decoded, err := png.Decode(bytes.NewReader(scaledData))
+			if err != nil {
+				t.Fatalf("Failed to decode output image: %v", err)
+			}
+			if !isRGBModel(decoded.ColorModel()) {
+				t.Errorf("output colour model is %T, want RGB", decoded)
+			}
+			if got := decoded.Bounds().Dx(); got != tt.size {
+				t.Errorf("output size = %d, want %d", got, tt.size)
+			}
+			if got := color.RGBAModel.Convert(decoded.At(tt.size/2, tt.size/2)); got != tt.want {
+				t.Errorf("centre pixel = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestScaleCoverArt_StripsMetadata tests that EXIF, text and timestamp chunks
+// are removed on both the passthrough and the re-encode paths, and that the
+// passthrough keeps the colour profile
+func TestScaleCoverArt_StripsMetadata(t *testing.T) {
+	tests := []struct {
+		name     string
+		size     int
+		wantICCP bool
+	}{
+		{name: "passthrough", size: 1400, wantICCP: true},
+		{name: "re-encoded", size: 1000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "cover.png")
+			if err := createTestPNG(path, tt.size, tt.size); err != nil {
+				t.Fatalf("Failed to create test PNG: %v", err)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("Failed to read test PNG: %v", err)
+			}
+			data = insertPNGChunk(data, "tEXt", []byte("Software\x00Camera 9000"))
+			data = insertPNGChunk(data, "eXIf", bytes.Repeat([]byte{0xAB}, 4096))
+			data = insertPNGChunk(data, "tIME", []byte("\x07\xea\x0a\x10\x0c\x00\x00"))
+			data = insertPNGChunk(data, "iCCP", []byte("profile\x00\x00colours"))
+			if err := os.WriteFile(path, data, 0o644); err != nil {
+				t.Fatalf("Failed to write test PNG: %v", err)
+			}
+
+			scaledData, err := ScaleCoverArt(path)
+			if err != nil {
+				t.Fatalf("ScaleCoverArt failed: %v", err)
+			}
+
+			chunkTypes := pngChunkTypes(t, scaledData)
+			for _, chunkType := range chunkTypes {
+				if pngMetadataChunks[chunkType] {
+					t.Errorf("output contains metadata chunk %s", chunkType)
+				}
+			}
+			if got := slices.Contains(chunkTypes, "iCCP"); got != tt.wantICCP {
+				t.Errorf("output keeps the iCCP colour profile = %v, want %v", got, tt.wantICCP)
+			}
+			if _, err := png.Decode(bytes.NewReader(scaledData)); err != nil {
+				t.Errorf("output is not a valid PNG: %v", err)
+			}
+		})
+	}
+}
+
+// insertPNGChunk returns data with a chunk inserted straight after IHDR.
+func insertPNGChunk(data []byte, chunkType string, payload []byte) []byte {
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(payload))) //nolint:gosec // test payloads are small
+	chunk = append(chunk, chunkType...)
+	chunk = append(chunk, payload...)
+	chunk = binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+
+	// The signature (8 bytes) and IHDR chunk (25 bytes) come first.
+	const afterIHDR = 8 + 25
+	return slices.Concat(data[:afterIHDR], chunk, data[afterIHDR:])
+}
+
+// pngChunkTypes lists the chunk types in a PNG file in order.
+func pngChunkTypes(t *testing.T, data []byte) []string {
+	t.Helper()
+	var types []string
+	for rest := data[8:]; len(rest) >= 12; {
+		size := 12 + int(binary.BigEndian.Uint32(rest[:4]))
+		if size > len(rest) {
+			t.Fatalf("truncated chunk in output")
+		}
+		types = append(types, string(rest[4:8]))
+		rest = rest[size:]
+	}
+	return types
+}
+
+// TestFitCoverArt tests that cover art shrinks in steps until it fits the byte
+// budget, and never below the minimum size when the budget is out of reach
+func TestFitCoverArt(t *testing.T) {
+	// One shrink step above the minimum keeps the number of re-encodes, and
+	// so the test, small.
+	edge := MinCoverSize + coverShrinkStep
+	path := filepath.Join(t.TempDir(), "gradient.png")
+	if err := createTestPNG(path, edge, edge); err != nil {
+		t.Fatalf("Failed to create test PNG: %v", err)
+	}
+	full, err := ScaleCoverArt(path)
+	if err != nil {
+		t.Fatalf("ScaleCoverArt failed: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		maxBytes int
+		wantSize int
+		wantFits bool
+	}{
+		{name: "no budget", maxBytes: 0, wantSize: edge, wantFits: true},
+		{name: "already fits", maxBytes: len(full), wantSize: edge, wantFits: true},
+		{name: "one step down", maxBytes: len(full) - 1, wantSize: MinCoverSize, wantFits: true},
+		{name: "out of reach", maxBytes: 1000, wantSize: MinCoverSize, wantFits: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, size, err := FitCoverArt(path, tt.maxBytes, "")
+			if err != nil {
+				t.Fatalf("FitCoverArt failed: %v", err)
+			}
+			if size != tt.wantSize {
+				t.Errorf("size = %d, want %d", size, tt.wantSize)
+			}
+			fits := tt.maxBytes == 0 || len(data) <= tt.maxBytes
+			if fits != tt.wantFits {
+				t.Errorf("%d bytes fits %d budget = %v, want %v", len(data), tt.maxBytes, fits, tt.wantFits)
+			}
+
+			cfg, err := png.DecodeConfig(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("Failed to decode output image: %v", err)
+			}
+			if cfg.Width != size || cfg.Height != size {
+				t.Errorf("output is %dx%d, want %dx%d", cfg.Width, cfg.Height, size, size)
+			}
+		})
+	}
+}
+
+// TestFitCoverArt_Scaler tests the scaler names and that catmullrom output
+// still lands on the Apple Podcasts bounds
+func TestFitCoverArt_Scaler(t *testing.T) {
+	for name, want := range map[string]draw.Interpolator{"": draw.BiLinear, "bilinear": draw.BiLinear, "catmullrom": draw.CatmullRom} {
+		if got, err := coverScaler(name); err != nil || got != want {
+			t.Errorf("coverScaler(%q) = %v, %v; want %v", name, got, err, want)
+		}
+	}
+	if _, err := coverScaler("lanczos"); err == nil {
+		t.Error("coverScaler(\"lanczos\") expected error, got nil")
+	}
+
+	path := filepath.Join(t.TempDir(), "large.png")
+	if err := createTestPNG(path, 4000, 4000); err != nil {
+		t.Fatalf("Failed to create test PNG: %v", err)
+	}
+	data, size, err := FitCoverArt(path, 0, "catmullrom")
+	if err != nil {
+		t.Fatalf("FitCoverArt failed: %v", err)
+	}
+	cfg, err := png.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Failed to decode output image: %v", err)
+	}
+	if size != MaxCoverSize || cfg.Width != MaxCoverSize || cfg.Height != MaxCoverSize {
+		t.Errorf("catmullrom output is %dx%d (size %d), want %dx%d", cfg.Width, cfg.Height, size, MaxCoverSize, MaxCoverSize)
+	}
+}
+
 // Helper function to create test PNG images
 func createTestPNG(path string, width, height int) error {
 	img := image.NewRGBA(image.Rect(0, 0, width, height))
@@ -0,0 +1,95 @@
+package id3
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIsCoverURL(t *testing.T) {
+	tests := map[string]bool{
+		"https://example.com/cover.png": true,
+		"HTTP://example.com/cover.png":  true,
+		"cover.png":                     false,
+		"./http/cover.png":              false,
+		"ftp://example.com/cover.png":   false,
+	}
+	for cover, want := range tests {
+		if got := IsCoverURL(cover); got != want {
+			t.Errorf("IsCoverURL(%q) = %v, want %v", cover, got, want)
+		}
+	}
+}
+
+func TestDownloadCoverArt(t *testing.T) {
+	coverPath := filepath.Join(t.TempDir(), "cover.png")
+	if err := createTestPNG(coverPath, 1400, 1400); err != nil {
+		t.Fatalf("Failed to create test PNG: %v", err)
+	}
+	cover, err := os.ReadFile(coverPath)
+	if err != nil {
+		t.Fatalf("Failed to read test PNG: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/cover.png", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write(cover)
+	})
+	mux.HandleFunc("/page.html", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte("<html></html>"))
+	})
+	mux.HandleFunc("/huge.png", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		// Flush before writing so the body is chunked with no Content-Length.
+		w.(http.Flusher).Flush()
+		_, _ = w.Write(bytes.Repeat([]byte{0}, 2048))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	t.Run("image", func(t *testing.T) {
+		path, err := downloadCoverArt(t.Context(), server.Client(), server.URL+"/cover.png", 1<<20)
+		if err != nil {
+			t.Fatalf("downloadCoverArt failed: %v", err)
+		}
+		defer os.Remove(path)
+
+		// The download feeds straight into the normal scaling path.
+		scaled, err := ScaleCoverArt(path)
+		if err != nil {
+			t.Fatalf("ScaleCoverArt on download failed: %v", err)
+		}
+		if len(scaled) == 0 {
+			t.Error("ScaleCoverArt returned no data")
+		}
+	})
+
+	errTests := []struct {
+		name     string
+		path     string
+		maxBytes int64
+		errPart  string
+	}{
+		{name: "not an image", path: "/page.html", maxBytes: 1 << 20, errPart: "not an image"},
+		{name: "not found", path: "/missing.png", maxBytes: 1 << 20, errPart: "404"},
+		{name: "declared length over cap", path: "/cover.png", maxBytes: 100, errPart: "larger than 100 bytes"},
+		{name: "streamed body over cap", path: "/huge.png", maxBytes: 1024, errPart: "larger than 1024 bytes"},
+	}
+	for _, tt := range errTests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, err := downloadCoverArt(t.Context(), server.Client(), server.URL+tt.path, tt.maxBytes)
+			if err == nil || !strings.Contains(err.Error(), tt.errPart) {
+				t.Errorf("downloadCoverArt(%s): got %v, want error containing %q", tt.path, err, tt.errPart)
+			}
+			if path != "" {
+				t.Errorf("downloadCoverArt(%s) returned path %q on error", tt.path, path)
+			}
+		})
+	}
+}
@@ -5,8 +5,10 @@ package id3
 type TagInfo struct {
 	EpisodeNumber string
 	Title         string
-	Artist        string // Optional: defaults to empty if not provided
-	Album         string // Optional: defaults to empty if not provided
-	Date          string // Optional: Format: "YYYY-MM"
-	Comment       string // Optional: defaults to empty if not provided
+	Artist        string   // Optional: defaults to empty if not provided
+	Album         string   // Optional: defaults to empty if not provided
+	AlbumArtist   string   // Optional: the show name players group episodes by
+	Date          string   // Optional: Format: "YYYY-MM" or "YYYY-MM-DD"
+	Comments      []string // Optional: first is the primary comment; extras get distinct descriptions
+	Explicit      bool     // Optional: marks the episode explicit (iTunes advisory)
 }
@@ -0,0 +1,135 @@
+// Package textdiff renders line-level unified diffs, as diff -u does, for
+// showing a change to a file before it is written.
+package textdiff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Context is the number of unchanged lines shown around each change, as in
+// diff -u.
+const Context = 3
+
+// diffOp is one line of an edit script: kept (' '), removed ('-') or added
+// ('+').
+type diffOp struct {
+	kind byte
+	text string
+}
+
+// Unified returns a unified diff turning a into b, with oldName and newName
+// in the --- and +++ headers, or "" when they are the same. a and b may be an
+// excerpt of a longer file starting after its first offset lines, which are
+// counted into the hunk line numbers. The edit script comes from a longest
+// common subsequence, whose table grows with len(a)*len(b), so pass only the
+// lines that can have changed.
+func Unified(oldName, newName string, a, b []string, offset int) string {
+	ops := diffLines(a, b)
+
+	var hunks [][2]int // op index ranges, each change widened by its context
+	for i, op := range ops {
+		if op.kind == ' ' {
+			continue
+		}
+		start, end := max(i-Context, 0), min(i+Context+1, len(ops))
+		if n := len(hunks); n > 0 && start <= hunks[n-1][1] {
+			hunks[n-1][1] = end
+			continue
+		}
+		hunks = append(hunks, [2]int{start, end})
+	}
+	if len(hunks) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", oldName, newName)
+
+	// oldLine and newLine are the 1-based line numbers reached in a and b.
+	oldLine, newLine, next := offset+1, offset+1, 0
+	for _, hunk := range hunks {
+		for ; next < hunk[0]; next++ {
+			oldLine, newLine = advance(ops[next].kind, oldLine, newLine)
+		}
+		var body strings.Builder
+		oldCount, newCount := 0, 0
+		for _, op := range ops[hunk[0]:hunk[1]] {
+			body.WriteByte(op.kind)
+			body.WriteString(op.text)
+			body.WriteByte('\n')
+			if op.kind != '+' {
+				oldCount++
+			}
+			if op.kind != '-' {
+				newCount++
+			}
+		}
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(oldLine, oldCount), hunkRange(newLine, newCount))
+		sb.WriteString(body.String())
+		for ; next < hunk[1]; next++ {
+			oldLine, newLine = advance(ops[next].kind, oldLine, newLine)
+		}
+	}
+	return sb.String()
+}
+
+// advance moves the old and new line numbers past one op.
+func advance(kind byte, oldLine, newLine int) (int, int) {
+	if kind != '+' {
+		oldLine++
+	}
+	if kind != '-' {
+		newLine++
+	}
+	return oldLine, newLine
+}
+
+// hunkRange formats a hunk's start and line count. A hunk with no lines on
+// one side names the line before it, as diff -u does.
+func hunkRange(start, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start-1)
+	case 1:
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// diffLines returns the edit script from a to b along a longest common
+// subsequence, with removals ahead of additions where lines are replaced.
+func diffLines(a, b []string) []diffOp {
+	// lcs[i][j] is the common subsequence length of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var ops []diffOp
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i++
+			j++
+		case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	return ops
+}
@@ -0,0 +1,63 @@
+package textdiff
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnified(t *testing.T) {
+	lines := func(s string) []string { return strings.Split(s, "\n") }
+
+	tests := []struct {
+		name   string
+		a, b   string
+		offset int
+		want   string
+	}{
+		{
+			name: "identical",
+			a:    "---\ntitle: Test\n---",
+			b:    "---\ntitle: Test\n---",
+			want: "",
+		},
+		{
+			name: "replaced line",
+			a:    "---\ntitle: Test\npodcast_bytes: 1\n---\nBody",
+			b:    "---\ntitle: Test\npodcast_bytes: 2\n---\nBody",
+			want: "--- old\n+++ new\n@@ -1,5 +1,5 @@\n ---\n title: Test\n-podcast_bytes: 1\n+podcast_bytes: 2\n ---\n Body\n",
+		},
+		{
+			name: "inserted lines",
+			a:    "---\ntitle: Test\n---",
+			b:    "---\ntitle: Test\npodcast_duration: \"0:01:00\"\npodcast_bytes: 1000\n---",
+			want: "--- old\n+++ new\n@@ -1,3 +1,5 @@\n ---\n title: Test\n+podcast_duration: \"0:01:00\"\n+podcast_bytes: 1000\n ---\n",
+		},
+		{
+			name: "separate hunks",
+			a:    "a\nb\nc\nd\ne\nf\ng\nh\ni\nj",
+			b:    "A\nb\nc\nd\ne\nf\ng\nh\ni\nJ",
+			want: "--- old\n+++ new\n@@ -1,4 +1,4 @@\n-a\n+A\n b\n c\n d\n@@ -7,4 +7,4 @@\n g\n h\n i\n-j\n+J\n",
+		},
+		{
+			name:   "excerpt",
+			a:      "title: Test\npodcast_bytes: 1\n---",
+			b:      "title: Test\npodcast_bytes: 2\n---",
+			offset: 10,
+			want:   "--- old\n+++ new\n@@ -11,3 +11,3 @@\n title: Test\n-podcast_bytes: 1\n+podcast_bytes: 2\n ---\n",
+		},
+		{
+			name: "into empty",
+			a:    "",
+			b:    "x",
+			want: "--- old\n+++ new\n@@ -1 +1 @@\n-\n+x\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Unified("old", "new", lines(tt.a), lines(tt.b), tt.offset); got != tt.want {
+				t.Errorf("Unified():\ngot  %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+}
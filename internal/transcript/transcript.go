@@ -0,0 +1,257 @@
+// Package transcript reads timed transcripts and caption files (WebVTT, SRT
+// and simple "[MM:SS] text" lines) for embedding in the episode as an ID3
+// SYLT frame and for making chapters from.
+package transcript
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cue is one timed piece of the transcript: when it is spoken and what is
+// said. End is zero when the format does not give one, as for "[MM:SS] text"
+// lines.
+type Cue struct {
+	Start time.Duration
+	End   time.Duration
+	Text  string
+}
+
+// Chapter is a chapter made by Chapters: where it starts and its title.
+type Chapter struct {
+	Start time.Duration
+	Title string
+}
+
+// ChapterPause is the shortest gap between one cue's end and the next cue's
+// start that Chapters will begin a chapter at, so a chapter never starts
+// mid-sentence.
+const ChapterPause = time.Second
+
+// chapterTitleWords is how many of its opening words title a chapter made by
+// Chapters.
+const chapterTitleWords = 8
+
+// Parse reads a timed transcript, detecting its format: WebVTT (starting with
+// a WEBVTT line), SRT (cues timed with "-->"), or simple lines each starting
+// with a bracketed timestamp:
+//
+//	[00:00] Hello and welcome to Linux Matters.
+//	[00:04.500] This week we are talking about terminals.
+//	[1:02:45] Thanks for listening.
+//
+// Timestamps are [HH:]MM:SS with optional fractional seconds, after a full
+// stop or SRT's comma. Blank lines are ignored, and cues must not go back in
+// time. Markup such as <i> or <v Martin> is dropped from SRT and WebVTT cue
+// text, and cues left with no text are skipped.
+func Parse(r io.Reader) ([]Cue, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		lines = append(lines, strings.TrimRight(scanner.Text(), " \t\r"))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read transcript: %w", err)
+	}
+	if len(lines) > 0 {
+		lines[0] = strings.TrimPrefix(lines[0], "\ufeff") // byte order mark
+	}
+
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if line == "WEBVTT" || strings.HasPrefix(line, "WEBVTT ") || strings.HasPrefix(line, "WEBVTT\t") {
+			return parseCues(lines)
+		}
+		break
+	}
+	for _, line := range lines {
+		if strings.Contains(line, "-->") {
+			return parseCues(lines)
+		}
+	}
+	return parseLines(lines)
+}
+
+// ReadFile opens and parses a transcript file. See Parse for the formats.
+func ReadFile(path string) ([]Cue, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open transcript: %w", err)
+	}
+	defer f.Close()
+
+	cues, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("invalid transcript %s: %w", path, err)
+	}
+	return cues, nil
+}
+
+// Chapters groups the cues into coarse chapters of at least minLength. The
+// first chapter starts at zero; each later one starts at the first cue that
+// follows a pause of at least ChapterPause once the chapter before has run
+// for minLength. Cues without an end time count as following a pause. Each
+// chapter is titled with the opening words of its first cue, ready to be
+// refined into a chapters file.
+func Chapters(cues []Cue, minLength time.Duration) []Chapter {
+	if len(cues) == 0 {
+		return nil
+	}
+
+	chapters := []Chapter{{Start: 0, Title: chapterTitle(cues[0].Text)}}
+	for i := 1; i < len(cues); i++ {
+		if cues[i].Start-chapters[len(chapters)-1].Start < minLength {
+			continue
+		}
+		if prev := cues[i-1]; prev.End > 0 && cues[i].Start-prev.End < ChapterPause {
+			continue
+		}
+		chapters = append(chapters, Chapter{Start: cues[i].Start, Title: chapterTitle(cues[i].Text)})
+	}
+	return chapters
+}
+
+// chapterTitle returns the first chapterTitleWords words of text, ending in
+// "…" when there were more.
+func chapterTitle(text string) string {
+	words := strings.Fields(text)
+	if len(words) <= chapterTitleWords {
+		return strings.Join(words, " ")
+	}
+	return strings.TrimRight(strings.Join(words[:chapterTitleWords], " "), ",.;:!?-") + "…"
+}
+
+// parseLines reads "[MM:SS] text" lines.
+func parseLines(lines []string) ([]Cue, error) {
+	var cues []Cue
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		stamp, text, ok := strings.Cut(strings.TrimPrefix(line, "["), "]")
+		if !ok || !strings.HasPrefix(line, "[") {
+			return nil, fmt.Errorf("line %d: want a [MM:SS] timestamp before the text", i+1)
+		}
+		start, err := parseTimestamp(stamp)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		text = strings.TrimSpace(text)
+		if text == "" {
+			return nil, fmt.Errorf("line %d: transcript line at %s has no text", i+1, stamp)
+		}
+		if n := len(cues); n > 0 && start < cues[n-1].Start {
+			return nil, fmt.Errorf("line %d: transcript line at %s is earlier than the line before it", i+1, stamp)
+		}
+
+		cues = append(cues, Cue{Start: start, Text: text})
+	}
+	return cues, nil
+}
+
+// parseCues reads SRT or WebVTT cues: blocks separated by blank lines, each
+// with a "start --> end" timing line followed by its text. Blocks without a
+// timing line, such as the WEBVTT header and NOTE blocks, are skipped. WebVTT
+// cue settings after the end time are ignored.
+func parseCues(lines []string) ([]Cue, error) {
+	var cues []Cue
+	for i := 0; i < len(lines); i++ {
+		startStamp, rest, ok := strings.Cut(lines[i], "-->")
+		if !ok {
+			continue
+		}
+		lineNum := i + 1
+		startStamp = strings.TrimSpace(startStamp)
+		start, err := parseTimestamp(startStamp)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+		endStamp, _, _ := strings.Cut(strings.TrimSpace(rest), " ")
+		end, err := parseTimestamp(endStamp)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+		if end < start {
+			return nil, fmt.Errorf("line %d: cue at %s ends before it starts", lineNum, startStamp)
+		}
+
+		var text []string
+		for i+1 < len(lines) && strings.TrimSpace(lines[i+1]) != "" {
+			i++
+			if t := stripMarkup(lines[i]); t != "" {
+				text = append(text, t)
+			}
+		}
+		if len(text) == 0 {
+			continue
+		}
+		if n := len(cues); n > 0 && start < cues[n-1].Start {
+			return nil, fmt.Errorf("line %d: cue at %s is earlier than the cue before it", lineNum, startStamp)
+		}
+
+		cues = append(cues, Cue{Start: start, End: end, Text: strings.Join(text, " ")})
+	}
+	return cues, nil
+}
+
+// parseTimestamp parses [HH:]MM:SS[.fff] into a duration, also accepting the
+// comma SRT puts before the milliseconds.
+func parseTimestamp(s string) (time.Duration, error) {
+	parts := strings.Split(strings.Replace(s, ",", ".", 1), ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, fmt.Errorf("invalid transcript timestamp %q: want [HH:]MM:SS", s)
+	}
+
+	seconds, err := strconv.ParseFloat(parts[len(parts)-1], 64)
+	if err != nil || math.IsNaN(seconds) || seconds < 0 || seconds >= 60 {
+		return 0, fmt.Errorf("invalid transcript timestamp %q: seconds must be 0-59", s)
+	}
+
+	var whole [2]int
+	for i, part := range parts[:len(parts)-1] {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid transcript timestamp %q: want [HH:]MM:SS", s)
+		}
+		whole[i] = n
+	}
+
+	hours, minutes := 0, whole[0]
+	if len(parts) == 3 {
+		hours, minutes = whole[0], whole[1]
+		if minutes >= 60 {
+			return 0, fmt.Errorf("invalid transcript timestamp %q: minutes must be 0-59", s)
+		}
+	}
+
+	return time.Duration(hours)*time.Hour +
+		time.Duration(minutes)*time.Minute +
+		time.Duration(seconds*float64(time.Second)).Round(time.Millisecond), nil
+}
+
+// stripMarkup removes <...> tags from a cue's text and trims it.
+func stripMarkup(s string) string {
+	var b strings.Builder
+	inTag := false
+	for _, r := range s {
+		switch {
+		case r == '<':
+			inTag = true
+		case r == '>' && inTag:
+			inTag = false
+		case !inTag:
+			b.WriteRune(r)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
@@ -0,0 +1,134 @@
+package transcript
+
+import (
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fixtureCues is what both caption fixtures in testdata hold.
+var fixtureCues = []Cue{
+	{Start: 0, End: 4500 * time.Millisecond, Text: "Hello and welcome to Linux Matters."},
+	{Start: 4500 * time.Millisecond, End: 9 * time.Second, Text: "This week we are talking about terminals and the tools we run in them."},
+	{Start: 12 * time.Second, End: 16250 * time.Millisecond, Text: "First, some news from the world of Linux."},
+	{Start: 16250 * time.Millisecond, End: 20 * time.Second, Text: "There has been a lot of it."},
+	{Start: time.Hour + 2*time.Minute + 45*time.Second, End: time.Hour + 2*time.Minute + 48*time.Second, Text: "Thanks for listening, see you next time."},
+}
+
+func TestReadFile(t *testing.T) {
+	for _, path := range []string{"../../testdata/LMP0.vtt", "../../testdata/LMP0.srt"} {
+		t.Run(path, func(t *testing.T) {
+			if _, err := os.Stat(path); os.IsNotExist(err) {
+				t.Skipf("Test file not found: %s", path)
+			}
+			cues, err := ReadFile(path)
+			if err != nil {
+				t.Fatalf("ReadFile: %v", err)
+			}
+			if !slices.Equal(cues, fixtureCues) {
+				t.Errorf("ReadFile() =\n%+v\nwant\n%+v", cues, fixtureCues)
+			}
+		})
+	}
+}
+
+func TestParseLines(t *testing.T) {
+	input := "\ufeff[00:00] Hello and welcome to Linux Matters.\n" +
+		"[00:04.500] This week, terminals.\n\n" +
+		"[1:02:45] Thanks for listening.\n"
+	cues, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	want := []Cue{
+		{Start: 0, Text: "Hello and welcome to Linux Matters."},
+		{Start: 4500 * time.Millisecond, Text: "This week, terminals."},
+		{Start: time.Hour + 2*time.Minute + 45*time.Second, Text: "Thanks for listening."},
+	}
+	if !slices.Equal(cues, want) {
+		t.Errorf("Parse() = %+v, want %+v", cues, want)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		errPart string
+	}{
+		{name: "no timestamp", input: "Hello there", errPart: "want a [MM:SS] timestamp"},
+		{name: "bad timestamp", input: "[0:0:0:0] Hello", errPart: "invalid transcript timestamp"},
+		{name: "NaN seconds", input: "[00:NaN] Hello", errPart: "invalid transcript timestamp"},
+		{name: "seconds out of range", input: "[00:61] Hello", errPart: "seconds must be 0-59"},
+		{name: "missing text", input: "[00:05]", errPart: "has no text"},
+		{name: "out of order", input: "[00:05] Second\n[00:01] First", errPart: "earlier than the line before"},
+		{name: "bad cue timing", input: "1\nsoon --> later\nHello", errPart: "invalid transcript timestamp"},
+		{name: "cue ends first", input: "00:05.000 --> 00:04.000\nHello", errPart: "ends before it starts"},
+		{name: "cues out of order", input: "00:05.000 --> 00:06.000\nSecond\n\n00:01.000 --> 00:02.000\nFirst", errPart: "earlier than the cue before"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(tt.input))
+			if err == nil || !strings.Contains(err.Error(), tt.errPart) {
+				t.Errorf("Parse(%q): got %v, want error containing %q", tt.input, err, tt.errPart)
+			}
+		})
+	}
+}
+
+func TestChapters(t *testing.T) {
+	tests := []struct {
+		name      string
+		cues      []Cue
+		minLength time.Duration
+		want      []Chapter
+	}{
+		{
+			name:      "splits at the first pause after the minimum",
+			cues:      fixtureCues,
+			minLength: 5 * time.Second,
+			want: []Chapter{
+				{Start: 0, Title: "Hello and welcome to Linux Matters."},
+				{Start: 12 * time.Second, Title: "First, some news from the world of Linux."},
+				{Start: time.Hour + 2*time.Minute + 45*time.Second, Title: "Thanks for listening, see you next time."},
+			},
+		},
+		{
+			name:      "long titles shortened",
+			cues:      fixtureCues[1:3],
+			minLength: time.Second,
+			want: []Chapter{
+				{Start: 0, Title: "This week we are talking about terminals and…"},
+				{Start: 12 * time.Second, Title: "First, some news from the world of Linux."},
+			},
+		},
+		{
+			name:      "cues without ends split on length alone",
+			cues:      []Cue{{Start: 0, Text: "One"}, {Start: time.Minute, Text: "Two"}, {Start: 2 * time.Minute, Text: "Three"}},
+			minLength: 90 * time.Second,
+			want:      []Chapter{{Start: 0, Title: "One"}, {Start: 2 * time.Minute, Title: "Three"}},
+		},
+		{
+			name:      "late first cue starts at zero",
+			cues:      []Cue{{Start: 30 * time.Second, Text: "Welcome"}},
+			minLength: time.Minute,
+			want:      []Chapter{{Start: 0, Title: "Welcome"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Chapters(tt.cues, tt.minLength); !slices.Equal(got, tt.want) {
+				t.Errorf("Chapters() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	if got := Chapters(nil, time.Minute); got != nil {
+		t.Errorf("Chapters() without cues = %v, want none", got)
+	}
+}
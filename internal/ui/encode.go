@@ -20,7 +20,8 @@ type ProgressUpdate struct {
 
 // EncodingCompleteMsg signals that encoding has finished
 type EncodingCompleteMsg struct {
-	Err error
+	Result encoder.EncodeResult
+	Err    error
 }
 
 // frameTickMsg drives the animation clock at a fixed frame rate.
@@ -84,10 +85,18 @@ type EncodeModel struct {
 	settling  bool
 	cancelled bool
 	err       error
+	result    encoder.EncodeResult
 
 	// nonInteractive suppresses the rendered view under WithoutRenderer mode.
 	nonInteractive bool
 
+	// showTitle puts the progress percentage in the terminal window title.
+	showTitle bool
+
+	// onProgress, when set, sees every progress update, including those the
+	// display drops.
+	onProgress encoder.ProgressCallback
+
 	// Animation state
 	anim animState
 }
@@ -124,6 +133,19 @@ func NewEncodeModel(enc *encoder.Encoder, outputMode string, outputBitrate int,
 	}
 }
 
+// ShowProgressInTitle makes the view set the terminal window title to the
+// encode percentage, so progress is visible from the taskbar or tab bar.
+// Bubbletea restores the title when the program exits.
+func (m *EncodeModel) ShowProgressInTitle() {
+	m.showTitle = true
+}
+
+// OnProgress passes every encoder progress update to fn as well as to the
+// display. fn runs on the encoding goroutine.
+func (m *EncodeModel) OnProgress(fn encoder.ProgressCallback) {
+	m.onProgress = fn
+}
+
 // Init initializes the model and starts encoding
 func (m *EncodeModel) Init() tea.Cmd {
 	return tea.Batch(
@@ -203,9 +225,15 @@ func (m *EncodeModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// route.
 		m.cancelled = false
 		m.complete = true
+		m.result = msg.Result
 		m.settling = true
 		m.anim.settleStart = time.Now()
-		m.anim.finalSpeed = m.calculateSpeed()
+		// The encoder's own timing covers the whole encode; the model's
+		// estimate is the fallback when it has none.
+		m.anim.finalSpeed = msg.Result.RealtimeFactor()
+		if m.anim.finalSpeed == 0 {
+			m.anim.finalSpeed = m.calculateSpeed()
+		}
 		return m, m.tickFrame()
 	}
 
@@ -220,30 +248,44 @@ func (m *EncodeModel) View() tea.View {
 		return tea.NewView("")
 	}
 
-	if m.err != nil {
-		return tea.NewView(errorView(m.err))
-	}
-
-	if m.cancelled {
+	var v tea.View
+	switch {
+	case m.err != nil:
+		v = tea.NewView(errorView(m.err))
+	case m.cancelled:
 		// Keep the last progress frame on screen rather than the success view.
-		return tea.NewView(progressView(m))
+		v = tea.NewView(progressView(m))
+	case m.settling:
+		v = tea.NewView(progressView(m))
+	case m.complete:
+		v = tea.NewView(completeView(m))
+	default:
+		v = tea.NewView(progressView(m))
 	}
+	v.WindowTitle = m.windowTitle()
+	return v
+}
 
-	if m.settling {
-		return tea.NewView(progressView(m))
+// windowTitle returns the terminal title for the current progress, or "" to
+// leave the title alone.
+func (m *EncodeModel) windowTitle() string {
+	if !m.showTitle {
+		return ""
 	}
-
-	if m.complete {
-		return tea.NewView(completeView(m))
+	percent := int(m.calculateProgress())
+	if m.complete && m.err == nil && !m.cancelled {
+		percent = 100
 	}
-
-	return tea.NewView(progressView(m))
+	return fmt.Sprintf("jivedrop %d%%", percent)
 }
 
 // startEncoding starts the encoding process in a goroutine
 func (m *EncodeModel) startEncoding() tea.Cmd {
 	return func() tea.Msg {
-		err := m.encoder.Encode(func(samplesProcessed, totalSamples int64) {
+		result, err := m.encoder.Encode(func(samplesProcessed, totalSamples int64) {
+			if m.onProgress != nil {
+				m.onProgress(samplesProcessed, totalSamples)
+			}
 			select {
 			case m.progressChan <- ProgressUpdate{
 				SamplesProcessed: samplesProcessed,
@@ -257,7 +299,7 @@ func (m *EncodeModel) startEncoding() tea.Cmd {
 		// Closing the channel signals completion to waitForProgress.
 		close(m.progressChan)
 
-		return EncodingCompleteMsg{Err: err}
+		return EncodingCompleteMsg{Result: result, Err: err}
 	}
 }
 
@@ -282,42 +324,52 @@ func (m *EncodeModel) tickFrame() tea.Cmd {
 
 // calculateProgress returns progress percentage (0-100)
 func (m *EncodeModel) calculateProgress() float64 {
-	if m.totalSamples == 0 {
-		return 0
-	}
-	return float64(m.samplesProcessed) / float64(m.totalSamples) * 100
+	return Percent(m.samplesProcessed, m.totalSamples)
 }
 
 // calculateSpeed returns encoding speed (e.g., "101.2x realtime")
 func (m *EncodeModel) calculateSpeed() float64 {
-	if m.inputRate == 0 {
+	return Speed(m.samplesProcessed, m.inputRate, time.Since(m.startTime))
+}
+
+// calculateTimeRemaining returns estimated time remaining
+func (m *EncodeModel) calculateTimeRemaining() time.Duration {
+	return TimeRemaining(m.calculateProgress(), time.Since(m.startTime))
+}
+
+// Percent returns how far through the input an encode is, from 0 to 100. An
+// unknown total reports 0.
+func Percent(samplesProcessed, totalSamples int64) float64 {
+	if totalSamples == 0 {
 		return 0
 	}
+	return float64(samplesProcessed) / float64(totalSamples) * 100
+}
 
-	elapsed := time.Since(m.startTime).Seconds()
-	if elapsed == 0 {
+// Speed returns the encoding speed as a multiple of realtime: the seconds of
+// input audio at sampleRate processed per second of elapsed wall-clock time.
+func Speed(samplesProcessed int64, sampleRate int, elapsed time.Duration) float64 {
+	if sampleRate == 0 || elapsed <= 0 {
 		return 0
 	}
 
 	// Calculate audio duration processed (in seconds)
-	audioProcessed := float64(m.samplesProcessed) / float64(m.inputRate)
+	audioProcessed := float64(samplesProcessed) / float64(sampleRate)
 
 	// Speed = audio duration / wall clock time
-	return audioProcessed / elapsed
+	return audioProcessed / elapsed.Seconds()
 }
 
-// calculateTimeRemaining returns estimated time remaining
-func (m *EncodeModel) calculateTimeRemaining() time.Duration {
-	progress := m.calculateProgress()
-	if progress <= 0 || progress >= 100 {
+// TimeRemaining estimates the time left once percent of the encode has taken
+// elapsed. It is zero before any progress and once complete.
+func TimeRemaining(percent float64, elapsed time.Duration) time.Duration {
+	if percent <= 0 || percent >= 100 {
 		return 0
 	}
 
-	elapsed := time.Since(m.startTime)
-
 	// Use progress percentage for accurate estimation
 	// If we've completed X%, the remaining (100-X)% will take proportionally longer
-	totalEstimated := float64(elapsed) * 100.0 / progress
+	totalEstimated := float64(elapsed) * 100.0 / percent
 	remaining := time.Duration(totalEstimated) - elapsed
 
 	return remaining
@@ -362,6 +414,12 @@ func (m *EncodeModel) Error() error {
 	return m.err
 }
 
+// Result returns the encode summary. It is the zero value unless the encode
+// completed successfully.
+func (m *EncodeModel) Result() encoder.EncodeResult {
+	return m.result
+}
+
 // Cancelled reports whether the user interrupted the encode with Ctrl+C.
 func (m *EncodeModel) Cancelled() bool {
 	return m.cancelled
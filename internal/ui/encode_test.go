@@ -82,3 +82,41 @@ func TestEncodeModel_ErrorAfterCancel(t *testing.T) {
 		t.Errorf("error should not settle")
 	}
 }
+
+// TestEncodeModel_Result verifies that a successful completion keeps the
+// encode result for the caller, while a failed one leaves it zero.
+func TestEncodeModel_Result(t *testing.T) {
+	want := encoder.EncodeResult{OutputPath: "out.mp3", DurationSecs: 27, SamplesProcessed: 1190700, BytesWritten: 378000}
+
+	m := newTestModel(t)
+	m.Update(EncodingCompleteMsg{Result: want})
+	if got := m.Result(); got != want {
+		t.Errorf("Result() = %+v, want %+v", got, want)
+	}
+
+	m = newTestModel(t)
+	m.Update(EncodingCompleteMsg{Result: want, Err: errors.New("write trailer failed")})
+	if got := m.Result(); got != (encoder.EncodeResult{}) {
+		t.Errorf("Result() after failure = %+v, want zero value", got)
+	}
+}
+
+// TestEncodeModel_WindowTitle verifies the terminal title tracks the encode
+// percentage only once enabled, and reads 100% after a successful encode.
+func TestEncodeModel_WindowTitle(t *testing.T) {
+	m := newTestModel(t)
+	m.Update(ProgressUpdate{SamplesProcessed: 425, TotalSamples: 1000})
+	if got := m.windowTitle(); got != "" {
+		t.Errorf("windowTitle() before ShowProgressInTitle = %q, want empty", got)
+	}
+
+	m.ShowProgressInTitle()
+	if got, want := m.windowTitle(), "jivedrop 42%"; got != want {
+		t.Errorf("windowTitle() = %q, want %q", got, want)
+	}
+
+	m.Update(EncodingCompleteMsg{})
+	if got, want := m.windowTitle(), "jivedrop 100%"; got != want {
+		t.Errorf("windowTitle() after completion = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,78 @@
+package ui
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"time"
+
+	"github.com/linuxmatters/jivedrop/internal/encoder"
+)
+
+// JSONProgress writes encode progress as JSON lines, one object per update,
+// for GUI and web front-ends that render their own progress. It uses the same
+// percentage, speed and time-remaining calculations as EncodeModel.
+type JSONProgress struct {
+	out        *json.Encoder
+	sampleRate int
+	start      time.Time
+	now        func() time.Time
+}
+
+// progressEvent is one progress update.
+type progressEvent struct {
+	Samples    int64   `json:"samples"`
+	Total      int64   `json:"total"`
+	Percent    float64 `json:"percent"`
+	Speed      float64 `json:"speed"`
+	ETASeconds int64   `json:"eta_seconds"`
+}
+
+// doneEvent is the last event of an encode, carrying its result or error.
+type doneEvent struct {
+	Done            bool    `json:"done"`
+	Output          string  `json:"output,omitempty"`
+	DurationSeconds float64 `json:"duration_seconds,omitempty"`
+	Bytes           int64   `json:"bytes,omitempty"`
+	Speed           float64 `json:"speed,omitempty"`
+	ElapsedSeconds  float64 `json:"elapsed_seconds"`
+	Error           string  `json:"error,omitempty"`
+}
+
+// NewJSONProgress returns a JSONProgress writing to w for an encode of input
+// at sampleRate, timed from now.
+func NewJSONProgress(w io.Writer, sampleRate int) *JSONProgress {
+	return &JSONProgress{out: json.NewEncoder(w), sampleRate: sampleRate, start: time.Now(), now: time.Now}
+}
+
+// Update writes a progress event. It is an encoder.ProgressCallback.
+func (p *JSONProgress) Update(samplesProcessed, totalSamples int64) {
+	elapsed := p.now().Sub(p.start)
+	percent := Percent(samplesProcessed, totalSamples)
+	_ = p.out.Encode(progressEvent{
+		Samples:    samplesProcessed,
+		Total:      totalSamples,
+		Percent:    roundTenth(percent),
+		Speed:      roundTenth(Speed(samplesProcessed, p.sampleRate, elapsed)),
+		ETASeconds: int64(TimeRemaining(percent, elapsed).Round(time.Second).Seconds()),
+	})
+}
+
+// Done writes the final event: the encode result, or err when it failed.
+func (p *JSONProgress) Done(result encoder.EncodeResult, err error) {
+	event := doneEvent{Done: true, ElapsedSeconds: roundTenth(p.now().Sub(p.start).Seconds())}
+	if err != nil {
+		event.Error = err.Error()
+	} else {
+		event.Output = result.OutputPath
+		event.DurationSeconds = roundTenth(result.AudioDuration.Seconds())
+		event.Bytes = result.BytesWritten
+		event.Speed = roundTenth(result.RealtimeFactor())
+	}
+	_ = p.out.Encode(event)
+}
+
+// roundTenth rounds x to one decimal place, plenty for a progress display.
+func roundTenth(x float64) float64 {
+	return math.Round(x*10) / 10
+}
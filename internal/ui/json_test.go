@@ -0,0 +1,49 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/linuxmatters/jivedrop/internal/encoder"
+)
+
+// TestJSONProgress verifies each update is one JSON line with the same
+// speed and time-remaining figures the progress display shows, and that the
+// done event carries the result or the error.
+func TestJSONProgress(t *testing.T) {
+	var out bytes.Buffer
+	p := NewJSONProgress(&out, 44100)
+	p.now = func() time.Time { return p.start.Add(2 * time.Second) }
+
+	// 20s of audio in 2s is 10x realtime; a quarter done leaves 6s.
+	p.Update(20*44100, 80*44100)
+	p.Done(encoder.EncodeResult{
+		OutputPath:    "LMP67.mp3",
+		BytesWritten:  1234,
+		AudioDuration: 80 * time.Second,
+		Elapsed:       8 * time.Second,
+	}, nil)
+	p.Done(encoder.EncodeResult{}, errors.New("encoding cancelled"))
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	want := []string{
+		`{"samples":882000,"total":3528000,"percent":25,"speed":10,"eta_seconds":6}`,
+		`{"done":true,"output":"LMP67.mp3","duration_seconds":80,"bytes":1234,"speed":10,"elapsed_seconds":2}`,
+		`{"done":true,"elapsed_seconds":2,"error":"encoding cancelled"}`,
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines, want %d:\n%s", len(lines), len(want), out.String())
+	}
+	for i, line := range lines {
+		if !json.Valid([]byte(line)) {
+			t.Errorf("line %d is not valid JSON: %s", i, line)
+		}
+		if line != want[i] {
+			t.Errorf("line %d = %s, want %s", i, line, want[i])
+		}
+	}
+}